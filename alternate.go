@@ -0,0 +1,159 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// AlternatesKey is a ctx key which can be provided to Render() with a
+// []Alternate value, to declare alternate representations for that render
+// only, in addition to those registered with Loader.SetAlternates().
+//
+// For example:
+//
+//	ld.Render(w, r, "blog/index.html", pt.M{
+//		pt.AlternatesKey: []pt.Alternate{
+//			{Href: "/blog/feed.xml", Type: "application/rss+xml", Title: "Blog"},
+//		},
+//	})
+const AlternatesKey = "_alternates"
+
+// Alternate is an alternate representation of a page (e.g. a RSS feed, JSON
+// version, print view or translation), which is sent as a "Link" header, and
+// output as a "<link rel="alternate">" tag by the "alternates" tag.
+type Alternate struct {
+	// Href is the URL of the alternate representation. "{path}" is replaced
+	// with the path of the request, e.g. "{path}.json" or "{path}?print=1".
+	Href string
+	// Type is the optional media type, e.g. "application/rss+xml".
+	Type string
+	// Media is the optional media query, e.g. "print".
+	Media string
+	// Hreflang is the optional language, e.g. "fr".
+	Hreflang string
+	// Title is the optional title.
+	Title string
+}
+
+// attrs returns the attributes of the alternate (other than href), in the
+// provided format.
+func (a Alternate) attrs(format func(name, value string) string) string {
+	var b strings.Builder
+
+	for _, attr := range [...][2]string{
+		{"type", a.Type},
+		{"media", a.Media},
+		{"hreflang", a.Hreflang},
+		{"title", a.Title},
+	} {
+		if attr[1] != "" {
+			b.WriteString(format(attr[0], attr[1]))
+		}
+	}
+	return b.String()
+}
+
+// HTML returns the "<link>" tag of the alternate.
+func (a Alternate) HTML() string {
+	return `<link rel="alternate" href="` + html.EscapeString(a.Href) + `"` + a.attrs(func(name, value string) string {
+		return " " + name + `="` + html.EscapeString(value) + `"`
+	}) + ">"
+}
+
+// Header returns the "Link" header value of the alternate.
+func (a Alternate) Header() string {
+	return "<" + a.Href + `>; rel="alternate"` + a.attrs(func(name, value string) string {
+		return "; " + name + "=" + fmt.Sprintf("%q", value)
+	})
+}
+
+type alternatePreset struct {
+	pattern    string
+	alternates []Alternate
+}
+
+// SetAlternates registers alternate representations, which apply to every
+// render of a template whose path matches pattern (see path.Match(), e.g.
+// "blog/*"). Alternates are sent as "Link" headers, and exposed as the
+// "alternates" ctx key, which the "alternates" tag outputs as "<link>" tags:
+//
+//	<head>{% alternates %}</head>
+//
+// Panics if the pattern is malformed. For example:
+//
+//	ld.SetAlternates("docs/*",
+//		pt.Alternate{Href: "{path}?print=1", Media: "print"},
+//		pt.Alternate{Href: "{path}.json", Type: "application/json"},
+//	)
+func (ld *Loader) SetAlternates(pattern string, alternates ...Alternate) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(fmt.Sprintf("invalid alternates pattern %q: %v", pattern, err))
+	}
+
+	ld.alternatesMu.Lock()
+	ld.alternates = append(ld.alternates, alternatePreset{pattern: pattern, alternates: alternates})
+	ld.alternatesMu.Unlock()
+}
+
+// applyAlternates resolves the alternates of the render, from all matching
+// presets and the AlternatesKey ctx key, setting the "Link" headers and the
+// "alternates" ctx key.
+func (ld *Loader) applyAlternates(w http.ResponseWriter, r *http.Request, tpath string, ctx map[string]interface{}) {
+	var alternates []Alternate
+
+	ld.alternatesMu.RLock()
+	for _, preset := range ld.alternates {
+		if ok, _ := path.Match(preset.pattern, tpath); ok {
+			alternates = append(alternates, preset.alternates...)
+		}
+	}
+	ld.alternatesMu.RUnlock()
+
+	if extra, ok := ctx[AlternatesKey].([]Alternate); ok {
+		alternates = append(alternates, extra...)
+	}
+
+	if len(alternates) == 0 {
+		return
+	}
+
+	for i := range alternates {
+		alternates[i].Href = strings.ReplaceAll(alternates[i].Href, "{path}", r.URL.Path)
+		w.Header().Add("Link", alternates[i].Header())
+	}
+
+	if _, ok := ctx["alternates"]; !ok {
+		ctx["alternates"] = alternates
+	}
+}
+
+type tagAlternatesNode struct{}
+
+func (node *tagAlternatesNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	alternates, _ := ctx.Public["alternates"].([]Alternate)
+
+	for _, a := range alternates {
+		_, _ = writer.WriteString(a.HTML())
+	}
+	return nil
+}
+
+// tagAlternatesParser parses the "alternates" tag, which outputs the
+// "<link rel="alternate">" tags for the alternates of the render, see
+// Loader.SetAlternates().
+func tagAlternatesParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("The alternates-tag does not take any arguments.", nil)
+	}
+
+	return &tagAlternatesNode{}, nil
+}
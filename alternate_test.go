@@ -0,0 +1,50 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAlternates(t *testing.T) {
+	ld := New("alternates", Config{
+		FS: fstest.MapFS{"docs/intro.html": {Data: []byte(`{% alternates %}`)}},
+	})
+	ld.SetAlternates("docs/*", Alternate{Href: "{path}.json", Type: "application/json"})
+	ld.SetAlternates("blog/*", Alternate{Href: "/blog/feed.xml"})
+
+	w := httptest.NewRecorder()
+	ld.Render(w, httptest.NewRequest(http.MethodGet, "/docs/intro", nil), "docs/intro.html", M{
+		AlternatesKey: []Alternate{{Href: "/docs/intro?print=1", Media: "print", Title: `"Print"`}},
+	})
+
+	wantLinks := []string{
+		`</docs/intro.json>; rel="alternate"; type="application/json"`,
+		`</docs/intro?print=1>; rel="alternate"; media="print"; title="\"Print\""`,
+	}
+	if got := w.Header().Values("Link"); !reflect.DeepEqual(got, wantLinks) {
+		t.Errorf("Link = %q, want %q", got, wantLinks)
+	}
+
+	want := `<link rel="alternate" href="/docs/intro.json" type="application/json">` +
+		`<link rel="alternate" href="/docs/intro?print=1" media="print" title="&#34;Print&#34;">`
+	if got := w.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestSetAlternatesInvalidPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("SetAlternates() with a malformed pattern didn't panic")
+		}
+	}()
+
+	New("alternates-invalid", Config{FS: fstest.MapFS{}}).SetAlternates("[", Alternate{Href: "/"})
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// AssetManifest provides per-file content hashes for static assets, which can
+// be used for cache busting. Unlike "cachets", a changed file only changes its
+// own version, and unchanged files keep the same version across deploys.
+// Hashes are computed lazily, and cached for the lifetime of the manifest.
+type AssetManifest struct {
+	fs fs.FS
+
+	mu     sync.RWMutex
+	hashes map[string]string
+}
+
+// NewAssetManifest returns a new asset manifest for the provided filesystem,
+// which should be the same filesystem static assets are served from (e.g. with
+// FileServer()).
+func NewAssetManifest(fsys fs.FS) *AssetManifest {
+	return &AssetManifest{fs: fsys, hashes: make(map[string]string)}
+}
+
+// Version returns the content hash of the provided asset path, or an empty
+// string if the asset doesn't exist.
+func (m *AssetManifest) Version(name string) string {
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+
+	m.mu.RLock()
+	hash, ok := m.hashes[name]
+	m.mu.RUnlock()
+
+	if ok {
+		return hash
+	}
+
+	data, err := fs.ReadFile(m.fs, name)
+	if err != nil {
+		return ""
+	}
+
+	hash = contentHash(data)
+
+	m.mu.Lock()
+	m.hashes[name] = hash
+	m.mu.Unlock()
+
+	return hash
+}
+
+// contentHash returns the (truncated) content hash used as asset versions.
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// Paths returns the paths of all assets within the manifest, sorted.
+func (m *AssetManifest) Paths() ([]string, error) {
+	var paths []string
+
+	err := fs.WalkDir(m.fs, ".", func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			paths = append(paths, fpath)
+		}
+		return nil
+	})
+
+	sort.Strings(paths)
+	return paths, err
+}
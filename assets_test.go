@@ -0,0 +1,62 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAssetManifest(t *testing.T) {
+	fsys := fstest.MapFS{
+		"css/app.css": {Data: []byte("body{}")},
+		"js/app.js":   {Data: []byte("app")},
+		"js/other.js": {Data: []byte("app")},
+	}
+	m := NewAssetManifest(fsys)
+
+	version := m.Version("/css/app.css")
+	if len(version) != 12 || version != m.Version("css/app.css") {
+		t.Errorf("Version() = %q, want a 12 character hash, independent of leading slashes", version)
+	}
+	if m.Version("js/app.js") != m.Version("js/other.js") {
+		t.Error("assets with the same content have different versions")
+	}
+	if m.Version("js/app.js") == version {
+		t.Error("assets with different content have the same version")
+	}
+	if v := m.Version("missing.css"); v != "" {
+		t.Errorf("Version() of a missing asset = %q, want empty", v)
+	}
+
+	paths, err := m.Paths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"css/app.css", "js/app.js", "js/other.js"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Paths() = %v, want %v", paths, want)
+	}
+}
+
+func TestStaticURLAssets(t *testing.T) {
+	assets := NewAssetManifest(fstest.MapFS{"css/app.css": {Data: []byte("body{}")}})
+	ld := New("assets", Config{
+		FS:            fstest.MapFS{"index.html": {Data: []byte(`{% static "css/app.css" %} {% static "missing.css" %}`)}},
+		Assets:        assets,
+		StaticBaseURL: "https://cdn.example.com/static/",
+	})
+
+	out, err := ld.RenderBytes("index.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "https://cdn.example.com/static/css/app.css?v=" + assets.Version("css/app.css") +
+		" https://cdn.example.com/static/missing.css"
+	if string(out) != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
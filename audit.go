@@ -0,0 +1,338 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+var (
+	reAuditToken   = regexp.MustCompile(`(?s){{-?(.*?)-?}}|{%-?(.*?)-?%}`)
+	reAuditSafe    = regexp.MustCompile(`\|\s*safe\b`)
+	reAuditInclude = regexp.MustCompile(`^(?:include|extends|import)\s+["']([^"']+)["']`)
+)
+
+// auditValueMax is the maximum number of bytes of an unescaped value which
+// are logged by Config.AuditSafe.
+const auditValueMax = 256
+
+// SafeUsage is a single raw-HTML sink found within a template, as reported by
+// Loader.AuditSafe().
+type SafeUsage struct {
+	// Path is the template path the usage was found in.
+	Path string
+	// Line is the line number within the template.
+	Line int
+	// Expr is the raw expression or tag, without delimiters.
+	Expr string
+	// Reason is why the expression is considered unescaped, either "safe
+	// filter", "autoescape off", or "no autoescape path" (for templates matching
+	// Config.NoAutoescapePaths).
+	Reason string
+}
+
+func (u SafeUsage) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", u.Path, u.Line, u.Expr, u.Reason)
+}
+
+// safeSink is a SafeUsage, along with its location within the template
+// source. variable is set for "{{ }}" outputs, as opposed to tags.
+type safeSink struct {
+	SafeUsage
+	start, end int
+	variable   bool
+}
+
+// AuditSafe statically scans the provided templates (and any templates they
+// include, extend or import by literal path) for every use of the "safe"
+// filter, and every variable that is output within an
+// "{% autoescape off %}" block, or within templates matching
+// Config.NoAutoescapePaths. This is meant to be used during security reviews
+// or in CI, to enumerate raw-HTML sinks.
+//
+// As this scans the template sources, templates referenced dynamically (e.g.
+// "{% include tpl %}") aren't scanned, and values marked safe outside of
+// templates (e.g. pongo2.AsSafeValue()) or by custom filters aren't
+// reported.
+//
+// See also Config.AuditSafe, which also logs the values which reach the
+// output through these sinks during Render().
+func (ld *Loader) AuditSafe(paths ...string) ([]SafeUsage, error) {
+	return ld.auditSafe(ld.conf(), nil, paths...)
+}
+
+// auditSafe is AuditSafe(), resolving templates from the theme (if any)
+// before the loader, in the same way as renders.
+func (ld *Loader) auditSafe(conf *Config, theme *themeSet, paths ...string) ([]SafeUsage, error) {
+	loaders := []pongo2.TemplateLoader{ld.loader}
+	if theme != nil {
+		loaders = []pongo2.TemplateLoader{theme.loader, ld.loader}
+	}
+
+	var usages []SafeUsage
+	seen := make(map[string]bool)
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		if seen[path] {
+			return nil
+		}
+		seen[path] = true
+
+		var (
+			src    []byte
+			loader pongo2.TemplateLoader
+			err    error
+		)
+
+		for _, loader = range loaders {
+			if src, err = loaderSource(loader, path); !isNotFound(err) {
+				break
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		sinks, refs := auditSource(path, src, !conf.noAutoescape(path))
+		for _, sink := range sinks {
+			usages = append(usages, sink.SafeUsage)
+		}
+
+		for _, ref := range refs {
+			if err = walk(loader.Abs(path, ref)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, path := range paths {
+		if err := walk(path); err != nil {
+			return usages, err
+		}
+
+		// Templates rendered with Render() are wrapped in the layout.
+		if conf.DefaultLayout != "" {
+			if err := walk(conf.DefaultLayout); err != nil {
+				return usages, err
+			}
+		}
+	}
+
+	return usages, nil
+}
+
+// logSafe logs all raw-HTML sinks for the provided template to the error
+// logger, once per template path (and theme).
+func (ld *Loader) logSafe(conf *Config, theme *themeSet, path string) {
+	key := path
+	if theme != nil {
+		key = theme.theme.Name + ":" + path
+	}
+
+	if _, loaded := ld.audited.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+
+	usages, err := ld.auditSafe(conf, theme, path)
+	if err != nil {
+		conf.logf(LevelError, "safe audit: %s: %v", path, err)
+	}
+
+	for _, u := range usages {
+		conf.logf(LevelWarn, "safe audit: %s", u.String())
+	}
+}
+
+// source returns the raw template source for the provided path.
+func (ld *Loader) source(path string) ([]byte, error) {
+	return loaderSource(ld.loader, path)
+}
+
+// loaderSource returns the raw template source for the provided path from
+// the template loader.
+func loaderSource(loader pongo2.TemplateLoader, path string) ([]byte, error) {
+	rd, err := loader.Get(loader.Abs("", path))
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := rd.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	return io.ReadAll(rd)
+}
+
+// auditSource returns all raw-HTML sinks within src, and any statically
+// referenced templates. autoescape is the initial autoescape state of the
+// template.
+func auditSource(path string, src []byte, autoescape bool) (sinks []safeSink, refs []string) {
+	var escaping []bool
+
+	add := func(loc []int, line int, expr, reason string) {
+		sinks = append(sinks, safeSink{
+			SafeUsage: SafeUsage{Path: path, Line: line, Expr: expr, Reason: reason},
+			start:     loc[0],
+			end:       loc[1],
+			variable:  loc[2] >= 0,
+		})
+	}
+
+	for _, loc := range reAuditToken.FindAllSubmatchIndex(src, -1) {
+		line := 1 + strings.Count(string(src[:loc[0]]), "\n")
+
+		if loc[2] >= 0 {
+			expr := strings.TrimSpace(string(src[loc[2]:loc[3]]))
+
+			switch {
+			case reAuditSafe.MatchString(expr):
+				add(loc, line, expr, "safe filter")
+			case len(escaping) > 0 && !escaping[len(escaping)-1]:
+				add(loc, line, expr, "autoescape off")
+			case len(escaping) == 0 && !autoescape:
+				add(loc, line, expr, "no autoescape path")
+			}
+			continue
+		}
+
+		tag := strings.TrimSpace(string(src[loc[4]:loc[5]]))
+		fields := strings.Fields(tag)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "autoescape":
+			escaping = append(escaping, len(fields) < 2 || fields[1] != "off")
+			if len(fields) > 1 && fields[1] == "off" {
+				add(loc, line, tag, "autoescape off")
+			}
+		case "endautoescape":
+			if len(escaping) > 0 {
+				escaping = escaping[:len(escaping)-1]
+			}
+		case "filter":
+			if reAuditSafe.MatchString("|" + strings.Join(fields[1:], "")) {
+				add(loc, line, tag, "safe filter")
+			}
+		default:
+			if m := reAuditInclude.FindStringSubmatch(tag); m != nil {
+				refs = append(refs, m[1])
+			}
+		}
+	}
+
+	return sinks, refs
+}
+
+// auditQuoter escapes values for pongo2 string literals, which only support
+// escaped quotes and backslashes, and can't contain newlines.
+var auditQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", " ", "\n", " ")
+
+// instrumentSinks wraps each variable sink within src in the "auditsink" tag,
+// which logs the values output through it, see Config.AuditSafe. Like
+// setAutoescape(), the tags never contain newlines, so line numbers are
+// preserved. Whitespace control of the variable is moved to the tags, so it
+// still applies to the surrounding text.
+func instrumentSinks(path string, src []byte, autoescape bool) []byte {
+	sinks, _ := auditSource(path, src, autoescape)
+
+	var buf bytes.Buffer
+	var last int
+
+	for _, sink := range sinks {
+		if !sink.variable {
+			continue
+		}
+
+		token := src[sink.start:sink.end]
+
+		start := "{% "
+		if bytes.HasPrefix(token, []byte("{{-")) {
+			start = "{%- "
+		}
+		end := " %}"
+		if bytes.HasSuffix(token, []byte("-}}")) {
+			end = " -%}"
+		}
+
+		buf.Write(src[last:sink.start])
+		buf.WriteString(start + `auditsink "` + auditQuoter.Replace(sink.Expr) + `" "` + sink.Reason + `" %}`)
+		buf.Write(token)
+		buf.WriteString("{% endauditsink" + end)
+		last = sink.end
+	}
+
+	if last == 0 {
+		return src
+	}
+
+	buf.Write(src[last:])
+	return buf.Bytes()
+}
+
+type tagAuditSinkNode struct {
+	usage   SafeUsage
+	wrapper *pongo2.NodeWrapper
+}
+
+func (node *tagAuditSinkNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	state := stateFromCtx(ctx)
+	if state == nil || !state.conf.AuditSafe {
+		return node.wrapper.Execute(ctx, writer)
+	}
+
+	var buf bytes.Buffer
+
+	if err := node.wrapper.Execute(ctx, &buf); err != nil {
+		return err
+	}
+
+	if buf.Len() > 0 {
+		value := buf.Bytes()
+		if len(value) > auditValueMax {
+			value = value[:auditValueMax]
+		}
+
+		state.conf.logf(LevelWarn, "safe audit: %s: unescaped output %q", node.usage.String(), value)
+	}
+
+	_, _ = writer.Write(buf.Bytes())
+	return nil
+}
+
+// tagAuditSinkParser parses the "auditsink" tag, which is inserted around
+// raw-HTML sinks by instrumentSinks() when Config.AuditSafe is enabled, and
+// isn't meant to be used directly.
+func tagAuditSinkParser(doc *pongo2.Parser, start *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	expr := arguments.MatchType(pongo2.TokenString)
+	reason := arguments.MatchType(pongo2.TokenString)
+	if expr == nil || reason == nil || arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed auditsink-tag arguments.", nil)
+	}
+
+	node := &tagAuditSinkNode{usage: SafeUsage{
+		Path:   strings.TrimSuffix(start.Filename, layoutSuffix),
+		Line:   start.Line,
+		Expr:   expr.Val,
+		Reason: reason.Val,
+	}}
+
+	var err *pongo2.Error
+	node.wrapper, _, err = doc.WrapUntilTag("endauditsink")
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
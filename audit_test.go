@@ -0,0 +1,119 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAuditSafe(t *testing.T) {
+	ld := New("audit", Config{
+		FS: fstest.MapFS{
+			"layout.html":    {Data: []byte(`{% block content %}{% endblock %}{{ footer|safe }}`)},
+			"index.html":     {Data: []byte("{{ a }}\n{{ b|safe }}\n{% include \"nav.html\" %}")},
+			"nav.html":       {Data: []byte(`{% autoescape off %}{{ c }}{% endautoescape %}{{ d }}`)},
+			"raw/embed.html": {Data: []byte(`{{ e }}{% autoescape on %}{{ f }}{% endautoescape %}`)},
+		},
+		DefaultLayout:     "layout.html",
+		NoAutoescapePaths: []string{"raw/"},
+	})
+
+	usages, err := ld.AuditSafe("index.html", "raw/embed.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []SafeUsage{
+		{Path: "index.html", Line: 2, Expr: "b|safe", Reason: "safe filter"},
+		{Path: "nav.html", Line: 1, Expr: "autoescape off", Reason: "autoescape off"},
+		{Path: "nav.html", Line: 1, Expr: "c", Reason: "autoescape off"},
+		{Path: "layout.html", Line: 1, Expr: "footer|safe", Reason: "safe filter"},
+		{Path: "raw/embed.html", Line: 1, Expr: "e", Reason: "no autoescape path"},
+	}
+
+	if len(usages) != len(want) {
+		t.Fatalf("AuditSafe() = %v, want %v", usages, want)
+	}
+	for i := range want {
+		if usages[i] != want[i] {
+			t.Errorf("usage %d = %v, want %v", i, usages[i], want[i])
+		}
+	}
+}
+
+func TestAuditSafeTheme(t *testing.T) {
+	ld := New("audit-theme", Config{
+		FS: fstest.MapFS{
+			"index.html": {Data: []byte(`{{ a }}{% include "nav.html" %}`)},
+			"nav.html":   {Data: []byte(`{{ b }}`)},
+		},
+	})
+	ld.RegisterTheme(&Theme{
+		Name:      "acme",
+		Templates: fstest.MapFS{"index.html": {Data: []byte(`{{ a|safe }}{% include "nav.html" %}`)}},
+	})
+
+	ld.themesMu.RLock()
+	theme := ld.themes["acme"]
+	ld.themesMu.RUnlock()
+
+	usages, err := ld.auditSafe(ld.conf(), theme, "index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(usages) != 1 || usages[0].Expr != "a|safe" {
+		t.Errorf("auditSafe() = %v, want the theme template sink", usages)
+	}
+}
+
+func TestAuditSafeRender(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layout.html": {Data: []byte(`<main>{% block content %}{% endblock %}</main>`)},
+		"index.html":  {Data: []byte("<p>{{ a }}</p>\n{{- b|safe -}}\n{% autoescape off %}{{ c }}{% endautoescape %}")},
+	}
+	ctx := map[string]interface{}{"a": "<a>", "b": "<b>x</b>", "c": "<i>"}
+
+	render := func(audit bool) (body, logs string) {
+		var logger bytes.Buffer
+
+		ld := New("audit-render", Config{
+			FS:            fsys,
+			DefaultLayout: "layout.html",
+			AuditSafe:     audit,
+			ErrorLogger:   &logger,
+		})
+
+		rec := httptest.NewRecorder()
+		ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "index.html", ctx)
+		return rec.Body.String(), logger.String()
+	}
+
+	want, _ := render(false)
+	body, logs := render(true)
+
+	if body != want {
+		t.Errorf("body = %q, want %q (same as without AuditSafe)", body, want)
+	}
+
+	for _, line := range []string{
+		`safe audit: index.html:2: b|safe (safe filter)` + "\n",
+		`safe audit: index.html:2: b|safe (safe filter): unescaped output "<b>x</b>"`,
+		`safe audit: index.html:3: c (autoescape off): unescaped output "<i>"`,
+	} {
+		if !strings.Contains(logs, line) {
+			t.Errorf("logs = %q, want %q", logs, line)
+		}
+	}
+
+	if n := strings.Count(logs, "unescaped output"); n != 2 {
+		t.Errorf("logged %d unescaped outputs, want 2:\n%s", n, logs)
+	}
+}
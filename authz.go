@@ -0,0 +1,128 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// Authorizer checks if a request is allowed to perform an action (e.g.
+// "posts:edit"), optionally on a resource (which is nil if not provided), see
+// Config.Authorizer. Implementations typically look up the principal of the
+// request (see Loader.Principal()), and delegate to the same policy engine as
+// handlers.
+type Authorizer interface {
+	Can(r *http.Request, action string, resource interface{}) bool
+}
+
+// AuthorizerFunc is an adapter to allow the use of ordinary functions as an
+// Authorizer.
+type AuthorizerFunc func(r *http.Request, action string, resource interface{}) bool
+
+// Can implements Authorizer.
+func (f AuthorizerFunc) Can(r *http.Request, action string, resource interface{}) bool {
+	return f(r, action, resource)
+}
+
+// Can returns true if the request is allowed to perform the action, using
+// Config.Authorizer. Always returns false if no Authorizer is provided.
+func (ld *Loader) Can(r *http.Request, action string, resource interface{}) bool {
+	authz := ld.conf().Authorizer
+	return authz != nil && r != nil && authz.Can(r, action, resource)
+}
+
+type tagCanNode struct {
+	action      pongo2.IEvaluator
+	resource    pongo2.IEvaluator
+	wrapper     *pongo2.NodeWrapper
+	elseWrapper *pongo2.NodeWrapper
+}
+
+func (node *tagCanNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	action, err := node.action.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	var resource interface{}
+	if node.resource != nil {
+		value, err := node.resource.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		resource = value.Interface()
+	}
+
+	state := stateFromCtx(ctx)
+	if state == nil {
+		return ctx.Error("can tag used outside of a pt.Loader render", nil)
+	}
+
+	authz := state.conf.Authorizer
+	if authz == nil {
+		return ctx.Error("can tag requires Config.Authorizer", nil)
+	}
+
+	if state.r != nil && authz.Can(state.r, action.String(), resource) {
+		return node.wrapper.Execute(ctx, writer)
+	}
+
+	if node.elseWrapper != nil {
+		return node.elseWrapper.Execute(ctx, writer)
+	}
+	return nil
+}
+
+// tagCanParser parses the "can" tag, which only outputs its contents if the
+// request is allowed to perform the action (optionally on a resource), using
+// Config.Authorizer, with an optional "else" branch. Renders outside of a
+// request (e.g. RenderBytes()) are never allowed. For example:
+//
+//	{% can "posts:edit" post %}
+//		<a href="/posts/{{ post.ID }}/edit">Edit</a>
+//	{% endcan %}
+func tagCanParser(doc *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	action, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	node := &tagCanNode{action: action}
+
+	if arguments.Remaining() > 0 {
+		if node.resource, err = arguments.ParseExpression(); err != nil {
+			return nil, err
+		}
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed can-tag arguments.", nil)
+	}
+
+	wrapper, endargs, err := doc.WrapUntilTag("else", "endcan")
+	if err != nil {
+		return nil, err
+	}
+	node.wrapper = wrapper
+
+	if endargs.Count() > 0 {
+		return nil, endargs.Error("Arguments not allowed here.", nil)
+	}
+
+	if wrapper.Endtag == "else" {
+		node.elseWrapper, endargs, err = doc.WrapUntilTag("endcan")
+		if err != nil {
+			return nil, err
+		}
+
+		if endargs.Count() > 0 {
+			return nil, endargs.Error("Arguments not allowed here.", nil)
+		}
+	}
+
+	return node, nil
+}
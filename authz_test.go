@@ -0,0 +1,54 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCanTag(t *testing.T) {
+	ld := New("authz", Config{
+		FS: fstest.MapFS{
+			"index.html": {Data: []byte(`{% can "posts:edit" post %}edit{% else %}view{% endcan %}`)},
+		},
+		Authorizer: AuthorizerFunc(func(r *http.Request, action string, resource interface{}) bool {
+			return action == "posts:edit" && resource == r.Header.Get("X-Owner")
+		}),
+	})
+
+	for owner, want := range map[string]string{"alice": "edit", "bob": "view"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Owner", owner)
+
+		w := httptest.NewRecorder()
+		ld.Render(w, r, "index.html", M{"post": "alice"})
+
+		if got := w.Body.String(); got != want {
+			t.Errorf("owner %s: output = %q, want %q", owner, got, want)
+		}
+	}
+
+	// Renders outside of a request are never allowed.
+	if out, err := ld.RenderBytes("index.html", M{"post": "alice"}); err != nil || string(out) != "view" {
+		t.Errorf("RenderBytes() = %q, %v, want view", out, err)
+	}
+}
+
+func TestCanTagRequiresAuthorizer(t *testing.T) {
+	ld := New("authz-none", Config{
+		FS: fstest.MapFS{"index.html": {Data: []byte(`{% can "posts:edit" %}edit{% endcan %}`)}},
+	})
+
+	if ld.Can(httptest.NewRequest(http.MethodGet, "/", nil), "posts:edit", nil) {
+		t.Error("Can() without an Authorizer = true")
+	}
+
+	if err := ld.RenderE(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "index.html", nil); err == nil {
+		t.Error("can tag without Config.Authorizer didn't fail")
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// templateCache is the parsed template cache used when Config.CacheParsed is
+// enabled. This is used instead of the pongo2 cache, so we can track usage,
+// and bound its size.
+type templateCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // most recently used first.
+	bytes   int
+	gen     uint64 // incremented on invalidation, see get().
+
+	// flights coalesces concurrent parses of the same template, which happen
+	// outside of mu, so cache hits aren't blocked by parsing.
+	flights flightGroup
+
+	hits      uint64
+	misses    uint64
+	evictions uint64 // only LRU evictions, see cachePolicy.
+}
+
+type templateCacheEntry struct {
+	path   string
+	tpl    *pongo2.Template
+	parsed time.Time
+	size   int
+}
+
+// cachePolicy is the expiry and size policy of the parsed template cache.
+type cachePolicy struct {
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int
+}
+
+func newTemplateCache() *templateCache {
+	return &templateCache{entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+// get returns the cached template for path, or parses it with fn (which also
+// returns the size of the template) and caches the result. Templates cached
+// for longer than the policy TTL are parsed again, and the least recently
+// used templates are evicted once the cache exceeds the policy limits.
+// Parsing happens without holding the cache lock, and concurrent parses of
+// the same path are coalesced.
+func (c *templateCache) get(
+	path string,
+	policy cachePolicy,
+	fn func(path string) (*pongo2.Template, int, error),
+) (*pongo2.Template, error) {
+	c.mu.Lock()
+	if el, ok := c.entries[path]; ok {
+		entry := el.Value.(*templateCacheEntry)
+
+		if policy.ttl <= 0 || time.Since(entry.parsed) < policy.ttl {
+			c.lru.MoveToFront(el)
+			c.mu.Unlock()
+			atomic.AddUint64(&c.hits, 1)
+			return entry.tpl, nil
+		}
+	}
+	gen := c.gen
+	c.mu.Unlock()
+
+	atomic.AddUint64(&c.misses, 1)
+
+	tpl, err := c.flights.do(path, func() (interface{}, error) {
+		tpl, size, err := fn(path)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		// Templates invalidated while being parsed may be stale, so they are
+		// returned, but not cached.
+		if c.gen != gen {
+			return tpl, nil
+		}
+
+		if el, ok := c.entries[path]; ok {
+			c.remove(el)
+		}
+
+		c.entries[path] = c.lru.PushFront(&templateCacheEntry{path: path, tpl: tpl, parsed: time.Now(), size: size})
+		c.bytes += size
+
+		// The template which was just added is never evicted.
+		for c.lru.Len() > 1 && ((policy.maxEntries > 0 && c.lru.Len() > policy.maxEntries) ||
+			(policy.maxBytes > 0 && c.bytes > policy.maxBytes)) {
+			c.remove(c.lru.Back())
+			atomic.AddUint64(&c.evictions, 1)
+		}
+
+		return tpl, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tpl.(*pongo2.Template), nil
+}
+
+// remove removes the cache element. c.mu must be held.
+func (c *templateCache) remove(el *list.Element) {
+	entry := c.lru.Remove(el).(*templateCacheEntry)
+	delete(c.entries, entry.path)
+	c.bytes -= entry.size
+}
+
+// invalidate removes all cached templates for which fn returns true.
+func (c *templateCache) invalidate(fn func(path string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+
+	for path, el := range c.entries {
+		if fn(path) {
+			c.remove(el)
+		}
+	}
+}
+
+func (c *templateCache) stats() CacheStats {
+	c.mu.Lock()
+	entries, size := len(c.entries), c.bytes
+	c.mu.Unlock()
+
+	stats := CacheStats{
+		Entries:   entries,
+		Bytes:     size,
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+	}
+
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+
+	return stats
+}
+
+// CacheStats are the usage statistics of a single cache.
+type CacheStats struct {
+	Entries int    `json:"entries"`
+	Bytes   int    `json:"bytes"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	// Evictions is the number of entries evicted to stay within the size
+	// limits. Expired and invalidated entries aren't counted.
+	Evictions uint64  `json:"evictions"`
+	HitRatio  float64 `json:"hit_ratio"`
+}
+
+// Stats are the usage statistics of a Loader. See Loader.Stats().
+type Stats struct {
+	// ParseCache are the statistics of the parsed template cache, which is only
+	// used when Config.CacheParsed is enabled.
+	ParseCache CacheStats `json:"parse_cache"`
+	// Profiles are the execution profiles of templates and template sections,
+	// keyed by template path (and "path#section" for sections). Only recorded
+	// when Config.Profile is enabled.
+	Profiles map[string]ProfileStats `json:"profiles,omitempty"`
+	// Warm are the results of the last call to Loader.ParseAll(), if any.
+	Warm *WarmStats `json:"warm,omitempty"`
+	// SlowRenders is the number of renders which exceeded
+	// Config.SlowRenderThreshold.
+	SlowRenders uint64 `json:"slow_renders"`
+	// Usage is the sampled usage of templates and blocks, keyed by template
+	// path (and "path#block" for blocks). Only recorded when
+	// Config.UsageSampleRate is provided.
+	Usage map[string]UsageStats `json:"usage,omitempty"`
+}
+
+// Stats returns the current usage statistics of the loader.
+func (ld *Loader) Stats() Stats {
+	warm, _ := ld.warm.Load().(*WarmStats)
+
+	return Stats{
+		ParseCache:  ld.cache.stats(),
+		Profiles:    ld.profiles.snapshot(),
+		Warm:        warm,
+		SlowRenders: atomic.LoadUint64(&ld.slowRenders),
+		Usage:       ld.usage.snapshot(),
+	}
+}
+
+// caches returns all parsed template caches of the loader, including those of
+// registered themes.
+func (ld *Loader) caches() []*templateCache {
+	caches := []*templateCache{ld.cache, ld.dataCache}
+
+	ld.themesMu.RLock()
+	for _, theme := range ld.themes {
+		caches = append(caches, theme.cache)
+	}
+	ld.themesMu.RUnlock()
+
+	return caches
+}
+
+// Invalidate removes the provided template from the parse cache (see
+// Config.CacheParsed), along with all cached templates which include, extend
+// or import it, as referenced templates are compiled into the templates which
+// reference them. The template is parsed again on its next use. Only
+// templates referenced using string literals are tracked, see
+// InvalidateAll() for other cases.
+//
+// For example, within a deploy hook:
+//
+//	ld.Invalidate("partials/banner.html")
+func (ld *Loader) Invalidate(path string) {
+	deps := make(map[string]bool)
+	ld.usage.resetRefs()
+
+	for _, cache := range ld.caches() {
+		cache.invalidate(func(key string) bool {
+			return ld.references(strings.TrimSuffix(key, layoutSuffix), path, deps)
+		})
+	}
+
+	// The layout is compiled into every template wrapped with it.
+	if path == ld.conf().DefaultLayout {
+		for _, cache := range ld.caches() {
+			cache.invalidate(func(key string) bool {
+				return strings.HasSuffix(key, layoutSuffix)
+			})
+		}
+	}
+}
+
+// InvalidateAll removes all templates from the parse cache, so they are
+// parsed again on their next use.
+func (ld *Loader) InvalidateAll() {
+	ld.usage.resetRefs()
+
+	for _, cache := range ld.caches() {
+		cache.invalidate(func(string) bool { return true })
+	}
+}
+
+// references checks if tpath is target, or (transitively) statically
+// references it. memo caches the results for each template, and also guards
+// against cycles.
+func (ld *Loader) references(tpath, target string, memo map[string]bool) bool {
+	if tpath == target {
+		return true
+	}
+
+	if result, ok := memo[tpath]; ok {
+		return result
+	}
+	memo[tpath] = false
+
+	src, err := ld.source(tpath)
+	if err != nil {
+		return false
+	}
+
+	_, refs := auditSource(tpath, src, true)
+	for _, ref := range refs {
+		if ld.references(ld.loader.Abs(tpath, ref), target, memo) {
+			memo[tpath] = true
+			return true
+		}
+	}
+
+	return false
+}
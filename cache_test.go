@@ -0,0 +1,132 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestTemplateCacheHitDuringParse(t *testing.T) {
+	c := newTemplateCache()
+	policy := cachePolicy{}
+	tpl := &pongo2.Template{}
+
+	parsed := func(string) (*pongo2.Template, int, error) { return tpl, 0, nil }
+	if _, err := c.get("a.html", policy, parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		_, _ = c.get("slow.html", policy, func(string) (*pongo2.Template, int, error) {
+			close(started)
+			<-release
+			return tpl, 0, nil
+		})
+	}()
+	<-started
+	defer close(release)
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = c.get("a.html", policy, parsed)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cache hit blocked by a parse of another template")
+	}
+}
+
+func TestTemplateCacheCoalescesParses(t *testing.T) {
+	c := newTemplateCache()
+	tpl := &pongo2.Template{}
+
+	var calls int32
+	release := make(chan struct{})
+	fn := func(string) (*pongo2.Template, int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return tpl, 0, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := c.get("a.html", cachePolicy{}, fn); err != nil || got != tpl {
+				t.Errorf("get() = %p, %v", got, err)
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("parsed %d times, want 1", n)
+	}
+}
+
+func TestTemplateCacheEvictions(t *testing.T) {
+	c := newTemplateCache()
+	fn := func(string) (*pongo2.Template, int, error) { return &pongo2.Template{}, 1, nil }
+
+	policy := cachePolicy{maxEntries: 2}
+	for _, path := range []string{"a.html", "b.html", "c.html"} {
+		if _, err := c.get(path, policy, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if stats := c.stats(); stats.Entries != 2 || stats.Evictions != 1 {
+		t.Fatalf("entries = %d, evictions = %d, want 2, 1", stats.Entries, stats.Evictions)
+	}
+
+	// Expired entries are parsed again, and invalidated entries are dropped,
+	// neither of which are evictions.
+	time.Sleep(2 * time.Millisecond)
+	if _, err := c.get("c.html", cachePolicy{maxEntries: 2, ttl: time.Millisecond}, fn); err != nil {
+		t.Fatal(err)
+	}
+	c.invalidate(func(string) bool { return true })
+
+	if stats := c.stats(); stats.Entries != 0 || stats.Evictions != 1 {
+		t.Errorf("entries = %d, evictions = %d, want 0, 1", stats.Entries, stats.Evictions)
+	}
+}
+
+func TestTemplateCacheInvalidateDuringParse(t *testing.T) {
+	c := newTemplateCache()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = c.get("a.html", cachePolicy{}, func(string) (*pongo2.Template, int, error) {
+			close(started)
+			<-release
+			return &pongo2.Template{}, 0, nil
+		})
+	}()
+
+	<-started
+	c.invalidate(func(string) bool { return true })
+	close(release)
+	<-done
+
+	if n := c.stats().Entries; n != 0 {
+		t.Errorf("stale template cached after invalidation: entries = %d", n)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// CacheTSFor returns a cache-busting value for the provided static asset
+// path, read from Config.StaticFS (or the filesystem of Config.Assets), which
+// only changes when the asset itself changes. This is the modification time
+// of the asset, or its content hash for filesystems without modification
+// times (e.g. embed.FS). If the asset can't be found, the timestamp of when
+// the loader was created is used (same as "cachets"). This is available in
+// templates as the "cachets_for" ctx function:
+//
+//	<link rel="stylesheet" href="/static/css/app.css?t={{ cachets_for("css/app.css") }}">
+func (ld *Loader) CacheTSFor(name string) string {
+	return ld.cacheTSFor(ld.conf(), name)
+}
+
+// cacheTSFor is CacheTSFor(), using the provided config.
+func (ld *Loader) cacheTSFor(conf *Config, name string) string {
+	fsys := conf.staticFS()
+	if fsys == nil {
+		return strconv.FormatInt(ld.ts.Unix(), 10)
+	}
+
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return strconv.FormatInt(ld.ts.Unix(), 10)
+	}
+
+	if !info.ModTime().IsZero() {
+		return strconv.FormatInt(info.ModTime().Unix(), 10)
+	}
+
+	// Filesystems without modification times are typically embedded, so the
+	// content can't change while running. Config.Assets already caches the
+	// hashes of the same filesystem.
+	if conf.Assets != nil {
+		if version := conf.Assets.Version(name); version != "" {
+			return version
+		}
+		return strconv.FormatInt(ld.ts.Unix(), 10)
+	}
+
+	if hash, ok := ld.assetHashes.Load(name); ok {
+		return hash.(string)
+	}
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return strconv.FormatInt(ld.ts.Unix(), 10)
+	}
+
+	hash := contentHash(data)
+
+	ld.assetHashes.Store(name, hash)
+	return hash
+}
@@ -0,0 +1,46 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"strconv"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCacheTSFor(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"css/app.css": {Data: []byte("body{}")},
+		"js/app.js":   {Data: []byte("app"), ModTime: modified},
+	}
+
+	ld := New("cachets-static", Config{FS: fstest.MapFS{}, StaticFS: fsys})
+
+	if got, want := ld.CacheTSFor("/css/app.css"), contentHash([]byte("body{}")); got != want {
+		t.Errorf("CacheTSFor(css/app.css) = %q, want %q", got, want)
+	}
+	if got, want := ld.CacheTSFor("js/app.js"), strconv.FormatInt(modified.Unix(), 10); got != want {
+		t.Errorf("CacheTSFor(js/app.js) = %q, want %q", got, want)
+	}
+	if got, want := ld.CacheTSFor("missing.css"), strconv.FormatInt(ld.ts.Unix(), 10); got != want {
+		t.Errorf("CacheTSFor(missing.css) = %q, want %q", got, want)
+	}
+}
+
+func TestCacheTSForAssets(t *testing.T) {
+	assets := NewAssetManifest(fstest.MapFS{"css/app.css": {Data: []byte("body{}")}})
+	ld := New("cachets-assets", Config{FS: fstest.MapFS{}, Assets: assets})
+
+	if got, want := ld.CacheTSFor("css/app.css"), assets.Version("css/app.css"); got != want {
+		t.Errorf("CacheTSFor() = %q, want %q", got, want)
+	}
+
+	ld.assetHashes.Range(func(key, _ interface{}) bool {
+		t.Errorf("hash of %v cached outside of Config.Assets", key)
+		return true
+	})
+}
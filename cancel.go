@@ -0,0 +1,113 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// detachedContext keeps the values of its parent context, however it is never
+// canceled, and has no deadline (the same as context.WithoutCancel(), which
+// requires Go 1.21).
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+// withoutCancel returns a context which keeps the values of ctx, but is never
+// canceled.
+func withoutCancel(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
+
+// renderCanceled is used to unwind template execution (through pongo2) once
+// the request context has been canceled.
+type renderCanceled struct {
+	err error
+}
+
+// cancelWriter aborts template execution on the next write, once ctx is
+// canceled. pongo2 has no way of canceling execution, however all output
+// goes through the writer, so loops which produce output are aborted
+// promptly.
+type cancelWriter struct {
+	w   io.Writer
+	ctx context.Context
+}
+
+func (cw *cancelWriter) Write(p []byte) (int, error) {
+	select {
+	case <-cw.ctx.Done():
+		panic(renderCanceled{err: cw.ctx.Err()})
+	default:
+	}
+	return cw.w.Write(p)
+}
+
+// executeCancelable executes the job in the same way as execute(), aborting
+// once the request context is canceled. Buffered executions are executed into
+// a local buffer (rather than pongo2's), as pongo2 would otherwise only write
+// to the cancelWriter once execution completed.
+func (ld *Loader) executeCancelable(w io.Writer, j *renderJob) (err error) {
+	ctx := j.r.Context()
+
+	if err = ctx.Err(); err != nil {
+		return ld.canceled(j, err)
+	}
+
+	var buf *bytes.Buffer
+	out := w
+
+	if _, ok := w.(*flushWriter); !ok {
+		buf = &bytes.Buffer{}
+		out = buf
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			c, ok := rec.(renderCanceled)
+			if !ok {
+				panic(rec)
+			}
+			err = ld.canceled(j, c.err)
+		}
+	}()
+
+	cw := &cancelWriter{w: out, ctx: ctx}
+
+	if j.conf.Profile {
+		counter := &countingWriter{w: cw}
+		sample := startProfile()
+
+		err = j.tpl.ExecuteWriterUnbuffered(j.ctx, counter)
+		ld.profiles.record(j.path, sample, counter.n)
+	} else {
+		err = j.tpl.ExecuteWriterUnbuffered(j.ctx, cw)
+	}
+
+	if err == nil && buf != nil {
+		_, err = buf.WriteTo(w)
+	}
+
+	return ld.execErr(j.conf, err)
+}
+
+// canceled calls the Config.OnCancel hook, and returns the error for a
+// canceled render.
+func (ld *Loader) canceled(j *renderJob, err error) error {
+	if j.conf.OnCancel != nil {
+		j.conf.OnCancel(j.r, j.path, err)
+	}
+	return fmt.Errorf("%w: %s: %v", ErrRenderCanceled, j.path, err)
+}
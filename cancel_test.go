@@ -0,0 +1,66 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRenderCanceled(t *testing.T) {
+	var canceled []string
+
+	ld := New("cancel", Config{
+		FS: fstest.MapFS{
+			"index.html": {Data: []byte(`{% for i in items %}{{ i }}{% if forloop.Counter == 2 %}{{ cancel() }}{% endif %}{% endfor %}`)},
+		},
+		OnCancel: func(_ *http.Request, path string, _ error) { canceled = append(canceled, path) },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	err := ld.RenderE(w, r, "index.html", M{
+		"items":  make([]int, 1000),
+		"cancel": func() string { cancel(); return "" },
+	})
+
+	if !errors.Is(err, ErrRenderCanceled) {
+		t.Fatalf("RenderE() = %v, want ErrRenderCanceled", err)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("canceled render wrote %d bytes", w.Body.Len())
+	}
+	if len(canceled) != 1 || canceled[0] != "index.html" {
+		t.Errorf("OnCancel calls = %v", canceled)
+	}
+
+	// Render() ignores canceled renders, rather than panicking.
+	ld.Render(httptest.NewRecorder(), r, "index.html", M{"items": []int{1}})
+}
+
+func TestWithoutCancel(t *testing.T) {
+	type key struct{}
+
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), key{}, "v"), time.Millisecond)
+	cancel()
+
+	ctx := withoutCancel(parent)
+	if ctx.Err() != nil || ctx.Done() != nil {
+		t.Error("detached context is canceled")
+	}
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("detached context has a deadline")
+	}
+	if ctx.Value(key{}) != "v" {
+		t.Error("detached context lost the values of its parent")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+)
+
+// CaptureFunc receives the final output of a render, see Loader.CaptureBody().
+// The body must not be modified, or retained after the function returns
+// (copy it if needed).
+type CaptureFunc func(r *http.Request, path string, code int, body []byte)
+
+type capturePreset struct {
+	pattern string
+	fn      CaptureFunc
+}
+
+// CaptureBody registers fn to receive the final output (after post-processing,
+// like critical CSS inlining, and before compression) of templates whose path
+// matches pattern (see path.Match(), e.g. "contracts/*"), which is useful for
+// auditing or archiving generated documents without rendering them twice. The
+// function is called before the response is written, including for responses
+// which end up as a 304 Not Modified. All matching functions are called, in
+// the order they were registered. Only applies to Render(), RenderE() and
+// RenderStatus(), which buffer the output of matching templates. Panics if
+// the pattern is malformed.
+//
+// For example:
+//
+//	ld.CaptureBody("contracts/*.html", func(r *http.Request, path string, code int, body []byte) {
+//		archive.Store(r.Context(), path, append([]byte(nil), body...))
+//	})
+func (ld *Loader) CaptureBody(pattern string, fn CaptureFunc) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(fmt.Sprintf("invalid capture pattern %q: %v", pattern, err))
+	}
+
+	ld.capturesMu.Lock()
+	ld.captures = append(ld.captures, capturePreset{pattern: pattern, fn: fn})
+	ld.capturesMu.Unlock()
+}
+
+// captureFuncs returns the capture functions for the template path, if any.
+func (ld *Loader) captureFuncs(tpath string) []CaptureFunc {
+	ld.capturesMu.RLock()
+	defer ld.capturesMu.RUnlock()
+
+	var fns []CaptureFunc
+	for _, preset := range ld.captures {
+		if ok, _ := path.Match(preset.pattern, tpath); ok {
+			fns = append(fns, preset.fn)
+		}
+	}
+	return fns
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCaptureBody(t *testing.T) {
+	ld := New("capture", Config{
+		FS: fstest.MapFS{
+			"contracts/a.html": {Data: []byte(`contract {{ id }}`)},
+			"index.html":       {Data: []byte(`index`)},
+		},
+	})
+
+	type capture struct {
+		path string
+		code int
+		body string
+	}
+	var captured []capture
+
+	for i := 0; i < 2; i++ {
+		ld.CaptureBody("contracts/*", func(_ *http.Request, path string, code int, body []byte) {
+			captured = append(captured, capture{path: path, code: code, body: string(body)})
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	ld.RenderStatus(httptest.NewRecorder(), r, http.StatusCreated, "contracts/a.html", M{"id": 1})
+	ld.Render(httptest.NewRecorder(), r, "index.html", nil)
+
+	want := capture{path: "contracts/a.html", code: http.StatusCreated, body: "contract 1"}
+	if len(captured) != 2 || captured[0] != want || captured[1] != want {
+		t.Errorf("captured = %+v, want %+v twice", captured, want)
+	}
+}
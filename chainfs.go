@@ -0,0 +1,98 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+// chainFS is a fs.FS which tries each layer in order, see ChainFS().
+type chainFS []fs.FS
+
+// ChainFS returns a fs.FS which opens files from the first layer which
+// contains them, e.g. a directory on disk with overrides, followed by an
+// embed.FS with the defaults. Directory listings are merged, so ParseAll(),
+// List() and Config.Watch see the templates of all layers. Errors other than
+// fs.ErrNotExist aren't masked by later layers.
+//
+// For example:
+//
+//	ld := pt.New("", pt.Config{
+//		FS: pt.ChainFS(os.DirFS("overrides"), defaultTemplates),
+//	})
+func ChainFS(layers ...fs.FS) fs.FS {
+	return chainFS(layers)
+}
+
+// OverlayFS returns a fs.FS for theming, which opens files from the overlays
+// first (in order, e.g. a tenant theme followed by a shared theme), falling
+// back to base. This is the same as ChainFS() with base as the last layer.
+// Files which exist in neither return an error wrapping fs.ErrNotExist, so
+// Config.NotFoundHandler is still invoked for missing templates.
+//
+// For example:
+//
+//	ld := pt.New("", pt.Config{
+//		FS: pt.OverlayFS(defaultTemplates, os.DirFS("themes/acme")),
+//	})
+func OverlayFS(base fs.FS, overlays ...fs.FS) fs.FS {
+	layers := make(chainFS, 0, len(overlays)+1)
+	layers = append(layers, overlays...)
+	return append(layers, base)
+}
+
+func (c chainFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	for _, layer := range c {
+		f, err := layer.Open(name)
+		if err == nil {
+			return f, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir merges the entries of the directory from all layers, where entries
+// of earlier layers take priority.
+func (c chainFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	seen := make(map[string]bool)
+	found := false
+
+	for _, layer := range c {
+		layerEntries, err := fs.ReadDir(layer, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		found = true
+		for _, entry := range layerEntries {
+			if !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
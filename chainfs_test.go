@@ -0,0 +1,79 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+type errFS struct{ err error }
+
+func (e errFS) Open(string) (fs.File, error) { return nil, e.err }
+
+func TestChainFS(t *testing.T) {
+	overrides := fstest.MapFS{"index.html": {Data: []byte("override")}}
+	defaults := fstest.MapFS{
+		"index.html": {Data: []byte("default")},
+		"partials/a": {Data: []byte("a")},
+		"about.html": {Data: []byte("about")},
+	}
+
+	fsys := ChainFS(overrides, defaults)
+
+	if data, err := fs.ReadFile(fsys, "index.html"); err != nil || string(data) != "override" {
+		t.Errorf("ReadFile(index.html) = %q, %v, want override", data, err)
+	}
+	if data, err := fs.ReadFile(fsys, "about.html"); err != nil || string(data) != "about" {
+		t.Errorf("ReadFile(about.html) = %q, %v, want about", data, err)
+	}
+	if _, err := fsys.Open("missing.html"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open(missing.html) = %v, want fs.ErrNotExist", err)
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"about.html", "index.html", "partials"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ReadDir(.) = %v, want %v", names, want)
+	}
+
+	// Other errors aren't masked by later layers.
+	failed := errors.New("permission denied")
+	if _, err := ChainFS(errFS{failed}, defaults).Open("index.html"); !errors.Is(err, failed) {
+		t.Errorf("Open() = %v, want the error of the first layer", err)
+	}
+}
+
+func TestOverlayFS(t *testing.T) {
+	base := fstest.MapFS{"index.html": {Data: []byte("base")}, "nav.html": {Data: []byte("base nav")}}
+	shared := fstest.MapFS{"nav.html": {Data: []byte("shared nav")}}
+	tenant := fstest.MapFS{"index.html": {Data: []byte("tenant")}}
+
+	ld := New("overlay", Config{FS: OverlayFS(base, tenant, shared)})
+
+	for path, want := range map[string]string{"index.html": "tenant", "nav.html": "shared nav"} {
+		if out, err := ld.RenderBytes(path, nil); err != nil || string(out) != want {
+			t.Errorf("RenderBytes(%s) = %q, %v, want %q", path, out, err, want)
+		}
+	}
+
+	list, err := ld.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"index.html", "nav.html"}; !reflect.DeepEqual(list, want) {
+		t.Errorf("List() = %v, want %v", list, want)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// parseHexColor parses a "#rgb" or "#rrggbb" color (the "#" is optional),
+// returning the channels in the range 0-1.
+func parseHexColor(s string) (r, g, b float64, err error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+
+	if len(s) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid hex color %q", s)
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid hex color %q", s)
+	}
+
+	return float64(v>>16&0xFF) / 255, float64(v>>8&0xFF) / 255, float64(v&0xFF) / 255, nil
+}
+
+func formatHexColor(r, g, b float64) string {
+	channel := func(v float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", channel(r), channel(g), channel(b))
+}
+
+// rgbToHSL converts rgb (0-1) to hue (0-360), saturation and lightness (0-1).
+func rgbToHSL(r, g, b float64) (h, s, l float64) {
+	hi := math.Max(r, math.Max(g, b))
+	lo := math.Min(r, math.Min(g, b))
+	l = (hi + lo) / 2
+
+	if hi == lo {
+		return 0, 0, l
+	}
+
+	d := hi - lo
+	if l > 0.5 {
+		s = d / (2 - hi - lo)
+	} else {
+		s = d / (hi + lo)
+	}
+
+	switch hi {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+
+	return h * 60, s, l
+}
+
+// hslToRGB converts hue (0-360), saturation and lightness (0-1) to rgb (0-1).
+func hslToRGB(h, s, l float64) (r, g, b float64) {
+	if s == 0 {
+		return l, l, l
+	}
+
+	q := l * (1 + s)
+	if l >= 0.5 {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+
+	hue := func(t float64) float64 {
+		t = math.Mod(t+1, 1)
+
+		switch {
+		case t < 1.0/6:
+			return p + (q-p)*6*t
+		case t < 0.5:
+			return q
+		case t < 2.0/3:
+			return p + (q-p)*(2.0/3-t)*6
+		default:
+			return p
+		}
+	}
+
+	h /= 360
+	return hue(h + 1.0/3), hue(h), hue(h - 1.0/3)
+}
+
+// adjustLightness adjusts the lightness of the color by amount percentage
+// points (e.g. 10 lightens "#808080" from 50% to 60% lightness), in the same
+// way as the Sass lighten() and darken() functions.
+func adjustLightness(in, param *pongo2.Value, sign float64, name string) (*pongo2.Value, *pongo2.Error) {
+	r, g, b, err := parseHexColor(in.String())
+	if err != nil {
+		return nil, &pongo2.Error{Sender: "filter:" + name, OrigError: err}
+	}
+
+	amount := 10.0
+	if !param.IsNil() {
+		amount = param.Float()
+	}
+
+	h, s, l := rgbToHSL(r, g, b)
+	l = math.Max(0, math.Min(1, l+sign*amount/100))
+
+	return pongo2.AsValue(formatHexColor(hslToRGB(h, s, l))), nil
+}
+
+// filterLighten lightens a hex color by the provided percentage points of
+// lightness (defaults to 10). For example:
+//
+//	{{ theme.primary|lighten:20 }} -> "#5282e0" (for "#1f4fad")
+func filterLighten(in, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	return adjustLightness(in, param, 1, "lighten")
+}
+
+// filterDarken darkens a hex color by the provided percentage points of
+// lightness (defaults to 10). For example:
+//
+//	<button style="border-color: {{ theme.primary|darken:10 }}">
+func filterDarken(in, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	return adjustLightness(in, param, -1, "darken")
+}
+
+// filterContrastText returns the text color with the highest contrast against
+// the provided hex background color, using WCAG relative luminance. The
+// optional parameter is the dark and light colors to choose between,
+// separated by a comma (defaults to "#000000,#ffffff"). For example:
+//
+//	<span style="background: {{ tag.color }}; color: {{ tag.color|contrast_text }}">
+func filterContrastText(in, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	r, g, b, err := parseHexColor(in.String())
+	if err != nil {
+		return nil, &pongo2.Error{Sender: "filter:contrast_text", OrigError: err}
+	}
+
+	dark, light := "#000000", "#ffffff"
+	if p := param.String(); p != "" {
+		colors := strings.Split(p, ",")
+		if len(colors) != 2 {
+			return nil, &pongo2.Error{
+				Sender:    "filter:contrast_text",
+				OrigError: fmt.Errorf("expected two comma-separated colors, got %q", p),
+			}
+		}
+		dark, light = strings.TrimSpace(colors[0]), strings.TrimSpace(colors[1])
+	}
+
+	bg := relativeLuminance(r, g, b)
+
+	contrast := func(color string) float64 {
+		cr, cg, cb, err := parseHexColor(color)
+		if err != nil {
+			return 0
+		}
+
+		fg := relativeLuminance(cr, cg, cb)
+		return (math.Max(bg, fg) + 0.05) / (math.Min(bg, fg) + 0.05)
+	}
+
+	if contrast(dark) >= contrast(light) {
+		return pongo2.AsValue(dark), nil
+	}
+	return pongo2.AsValue(light), nil
+}
+
+// relativeLuminance returns the WCAG relative luminance of the rgb (0-1)
+// color.
+func relativeLuminance(r, g, b float64) float64 {
+	linear := func(c float64) float64 {
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
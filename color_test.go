@@ -0,0 +1,64 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestColorFilters(t *testing.T) {
+	tests := []struct {
+		tpl  string
+		want string
+	}{
+		{`{{ "#1f4fad"|lighten:20 }}`, "#5282e0"},
+		{`{{ "fff"|darken:50 }}`, "#808080"},
+		{`{{ "#000"|lighten:100 }}`, "#ffffff"},
+		{`{{ "#ffffff"|lighten }}`, "#ffffff"},
+		{`{{ "#ffff00"|contrast_text }}`, "#000000"},
+		{`{{ "#1f4fad"|contrast_text }}`, "#ffffff"},
+		{`{{ "#1f4fad"|contrast_text:"#111111, #eeeeee" }}`, "#eeeeee"},
+	}
+
+	for _, tt := range tests {
+		ld := New("color", Config{FS: fstest.MapFS{"index.html": {Data: []byte(tt.tpl)}}})
+
+		out, err := ld.RenderBytes("index.html", nil)
+		if err != nil {
+			t.Errorf("%s: %v", tt.tpl, err)
+			continue
+		}
+		if string(out) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.tpl, out, tt.want)
+		}
+	}
+}
+
+func TestColorFiltersInvalid(t *testing.T) {
+	for _, tpl := range []string{
+		`{{ "red"|lighten }}`,
+		`{{ "#12345"|darken }}`,
+		`{{ "#ffffff"|contrast_text:"#000000" }}`,
+	} {
+		ld := New("color-invalid", Config{FS: fstest.MapFS{"index.html": {Data: []byte(tpl)}}})
+
+		if _, err := ld.RenderBytes("index.html", nil); err == nil {
+			t.Errorf("%s: expected an error", tpl)
+		}
+	}
+}
+
+func TestHSLRoundTrip(t *testing.T) {
+	for _, hex := range []string{"#000000", "#ffffff", "#1f4fad", "#ff0000", "#00ff00", "#0000ff", "#7f3a99"} {
+		r, g, b, err := parseHexColor(hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := formatHexColor(hslToRGB(rgbToHSL(r, g, b))); got != hex {
+			t.Errorf("round trip of %s = %s", hex, got)
+		}
+	}
+}
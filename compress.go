@@ -0,0 +1,239 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// compressibleTypes are the content type prefixes which are compressed.
+// Other types (e.g. images) are generally already compressed.
+var compressibleTypes = []string{
+	"text/",
+	"application/json",
+	"application/ld+json",
+	"application/manifest+json",
+	"application/javascript",
+	"application/xml",
+	"application/xhtml+xml",
+	"application/rss+xml",
+	"application/atom+xml",
+	"image/svg+xml",
+}
+
+var gzipPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+// Compress returns a middleware which gzip compresses responses, when the
+// client supports it, and the response is at least minSize bytes (defaults to
+// 1024 if <= 0). Only compressible content types (e.g. HTML, JSON, XML, CSS
+// and JavaScript) are compressed. This is useful for responses which aren't
+// rendered through a Loader (e.g. JSON()), see also Config.Compress.
+//
+// For example:
+//
+//	r.Use(pt.Compress(1024))
+func Compress(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cw := newCompressWriter(w, r, minSize)
+			if cw == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// compressWriter buffers the start of the response (up to minSize bytes) to
+// decide if it should be compressed, once the response is large enough, is
+// flushed, or has completed.
+type compressWriter struct {
+	http.ResponseWriter
+
+	minSize int
+	code    int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+// newCompressWriter returns a compressWriter for the response, or nil if the
+// client doesn't accept gzip encoding. As the response varies based on the
+// Accept-Encoding header, the Vary header is set in both cases.
+func newCompressWriter(w http.ResponseWriter, r *http.Request, minSize int) *compressWriter {
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		return nil
+	}
+
+	if minSize <= 0 {
+		minSize = 1024
+	}
+
+	return &compressWriter{ResponseWriter: w, minSize: minSize}
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	cw.code = code
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+
+		if len(cw.buf) < cw.minSize {
+			return len(b), nil
+		}
+
+		if err := cw.decide(false); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if cw.gz != nil {
+		return cw.gz.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher. Flushed (streamed) responses are always
+// compressed (if the content type is compressible), as the final size is
+// unknown.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		_ = cw.decide(true)
+	}
+
+	if cw.gz != nil {
+		_ = cw.gz.Flush()
+	}
+
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// decide decides if the response should be compressed, writes the header,
+// and any buffered output. If streaming, the response is compressed
+// regardless of the size of the output so far.
+func (cw *compressWriter) decide(streaming bool) error {
+	cw.decided = true
+
+	h := cw.Header()
+
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		// Otherwise net/http would detect the content type of the
+		// compressed output.
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	if cw.code == 0 {
+		cw.code = http.StatusOK
+	}
+
+	if (streaming || len(cw.buf) >= cw.minSize) && h.Get("Content-Encoding") == "" &&
+		cw.code != http.StatusNoContent && cw.code != http.StatusNotModified &&
+		isCompressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+
+		cw.gz = gzipPool.Get().(*gzip.Writer)
+		cw.gz.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.code)
+
+	buf := cw.buf
+	cw.buf = nil
+
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.gz != nil {
+		_, err = cw.gz.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close writes any buffered output, and completes the compressed stream.
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		// Nothing was written, so the response is left as-is (e.g. so it
+		// can still be written by an error handler).
+		if cw.code == 0 && len(cw.buf) == 0 {
+			return
+		}
+		_ = cw.decide(false)
+	}
+
+	if cw.gz != nil {
+		_ = cw.gz.Close()
+		gzipPool.Put(cw.gz)
+		cw.gz = nil
+	}
+}
+
+// isCompressible checks if the content type should be compressed.
+func isCompressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip checks if the Accept-Encoding header allows gzip encoding.
+func acceptsGzip(header string) bool {
+	wildcard := false
+
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		switch coding {
+		case "gzip", "x-gzip":
+			return q > 0
+		case "*":
+			wildcard = q > 0
+		}
+	}
+
+	return wildcard
+}
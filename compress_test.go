@@ -0,0 +1,122 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("<p>hello</p>", 200)
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		body        string
+		gzip        bool
+	}{
+		{name: "large", accept: "gzip, deflate", body: large, gzip: true},
+		{name: "small", accept: "gzip", body: "<p>hi</p>"},
+		{name: "no-accept", accept: "", body: large},
+		{name: "q-zero", accept: "gzip;q=0, *", body: large},
+		{name: "wildcard", accept: "*", body: large, gzip: true},
+		{name: "image", accept: "gzip", contentType: "image/png", body: large},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Compress(0)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(http.StatusCreated)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", tt.accept)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			if rec.Code != http.StatusCreated {
+				t.Errorf("code = %d, want %d", rec.Code, http.StatusCreated)
+			}
+			if v := rec.Header().Get("Vary"); v != "Accept-Encoding" {
+				t.Errorf("Vary = %q", v)
+			}
+
+			body := rec.Body.String()
+			if tt.gzip {
+				if rec.Header().Get("Content-Encoding") != "gzip" {
+					t.Fatal("response not compressed")
+				}
+				if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+					t.Errorf("Content-Type = %q, want detected text/html", ct)
+				}
+
+				gz, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out, err := io.ReadAll(gz)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = string(out)
+			} else if rec.Header().Get("Content-Encoding") != "" {
+				t.Error("response compressed unexpectedly")
+			}
+
+			if body != tt.body {
+				t.Errorf("body mismatch: got %d bytes, want %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestCompressEmpty(t *testing.T) {
+	h := Compress(0)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.Len() != 0 {
+		t.Error("empty response was modified")
+	}
+}
+
+func TestCompressFlush(t *testing.T) {
+	h := Compress(0)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, "<p>partial</p>")
+		w.(http.Flusher).Flush()
+		_, _ = io.WriteString(w, "<p>rest</p>")
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	if !rec.Flushed || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("flushed response should be compressed")
+	}
+
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := io.ReadAll(gz); string(out) != "<p>partial</p><p>rest</p>" {
+		t.Errorf("body = %q", out)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestUpdateConfigConcurrent(t *testing.T) {
+	assets := NewAssetManifest(fstest.MapFS{"app.js": {Data: []byte("app")}})
+
+	ld := New("update-config", Config{
+		FS: fstest.MapFS{"index.html": {Data: []byte(`{% static "app.js" %} {{ asset_version("app.js") }}`)}},
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			ld.UpdateConfig(func(c *Config) {
+				c.Assets = nil
+				c.ThemeSelector = nil
+				c.BaseURL = ""
+				if i%2 == 0 {
+					c.Assets = assets
+					c.ThemeSelector = func(*http.Request) string { return "" }
+					c.BaseURL = "https://example.com"
+				}
+			})
+		}
+	}()
+
+	for i := 0; i < 20000; i++ {
+		if u := ld.StaticURL("app.js"); !strings.HasSuffix(u, "/app.js") && !strings.Contains(u, "/app.js?v=") {
+			t.Fatalf("StaticURL() = %q", u)
+		}
+		_ = ld.AssetVersion("app.js")
+		_ = ld.AbsoluteURL(r, "/")
+		_ = ld.theme(ld.conf(), r)
+
+		if i%100 == 0 {
+			w := httptest.NewRecorder()
+			ld.Render(w, r, "index.html", nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d", w.Code)
+			}
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+}
+
+func TestRenderUsesConfigSnapshot(t *testing.T) {
+	ld := New("config-snapshot", Config{
+		FS: fstest.MapFS{
+			"index.html": {Data: []byte(`{% static "app.js" %}|{% can "edit" %}edit{% endcan %}|{% absolute_url "/" %}`)},
+		},
+		StaticBaseURL: "/a",
+		BaseURL:       "https://a.example.com",
+		Authorizer:    AuthorizerFunc(func(*http.Request, string, interface{}) bool { return true }),
+	})
+
+	// The config is updated after the ctx is built, but before the template
+	// is executed.
+	ld.UpdateConfig(func(c *Config) {
+		c.BeforeRender = []BeforeRenderHook{func(*http.Request, string, map[string]interface{}) {
+			ld.UpdateConfig(func(c *Config) {
+				c.StaticBaseURL = "/b"
+				c.BaseURL = "https://b.example.com"
+				c.Authorizer = nil
+			})
+		}}
+	})
+
+	out, err := ld.RenderRequestBytes(httptest.NewRequest(http.MethodGet, "/", nil), "index.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "/a/app.js|edit|https://a.example.com/"; string(out) != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestUpdateConfigDefaults(t *testing.T) {
+	ld := New("config-defaults", Config{
+		FS:            fstest.MapFS{"index.html": {Data: []byte(`ok`)}},
+		ErrorTemplate: "error.html",
+		Locales:       []string{"en", "fr"},
+	})
+
+	store := ld.conf().CacheStore
+	if conf := ld.conf(); !conf.FallbackOnError || conf.DefaultLocale != "en" {
+		t.Fatalf("FallbackOnError = %v, DefaultLocale = %q", conf.FallbackOnError, conf.DefaultLocale)
+	}
+
+	// Defaults derived from other fields follow the update.
+	ld.UpdateConfig(func(c *Config) {
+		c.ErrorTemplate = ""
+		c.Locales = []string{"fr"}
+	})
+
+	conf := ld.conf()
+	if conf.FallbackOnError || conf.DefaultLocale != "fr" {
+		t.Errorf("FallbackOnError = %v, DefaultLocale = %q, want false, %q", conf.FallbackOnError, conf.DefaultLocale, "fr")
+	}
+	if conf.CacheStore != store {
+		t.Error("the default CacheStore was replaced")
+	}
+
+	// Explicitly enabled fields are kept.
+	ld.UpdateConfig(func(c *Config) { c.FallbackOnError = true })
+	ld.UpdateConfig(func(c *Config) { c.Debug = true })
+
+	if !ld.conf().FallbackOnError {
+		t.Error("FallbackOnError was reset")
+	}
+}
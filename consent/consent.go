@@ -0,0 +1,314 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+// Package consent provides cookie consent (e.g. GDPR) helpers: a signed
+// consent cookie, ctx values for templates, a handler to record consent
+// changes, and a "consent" tag which only outputs its contents (e.g.
+// analytics snippets) once consent for a category has been given:
+//
+//	{% consent "analytics" %}
+//		<script async src="https://analytics.example.com/script.js"></script>
+//	{% endconsent %}
+//
+// The ctx values are provided by Manager.Ctx(), which is typically returned
+// from (or merged into) Config.DefaultCtx:
+//
+//	{% if not consent_decided %}{% include "partials/consent-banner.html" %}{% endif %}
+//	{% if consent.analytics %}...{% endif %}
+package consent
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/lrstanley/pt"
+)
+
+const (
+	// CtxKey is the ctx key which contains the Consent of the request.
+	CtxKey = "consent"
+
+	// DecidedKey is the ctx key which is true if the client has made a
+	// consent decision (accepting or rejecting), which is useful for deciding
+	// if a consent banner should be shown.
+	DecidedKey = "consent_decided"
+)
+
+func init() { //nolint:gochecknoinits
+	if err := pt.RegisterTag("consent", tagConsentParser); err != nil {
+		panic(err)
+	}
+}
+
+// Consent is the set of categories (e.g. "analytics" or "marketing") which
+// the client has consented to.
+type Consent map[string]bool
+
+// Has returns true if the client has consented to the provided category.
+func (c Consent) Has(category string) bool {
+	return c[category]
+}
+
+// Config is the configuration for New().
+type Config struct {
+	// Secret is the key used to sign the consent cookie, and is required.
+	Secret []byte
+	// Categories are the consent categories (e.g. "analytics" and
+	// "marketing"), and are required. Categories not in this list are ignored
+	// when recording consent.
+	Categories []string
+	// CookieName is the name of the consent cookie. Defaults to "pt_consent".
+	CookieName string
+	// MaxAge is the duration the consent cookie is valid for, after which the
+	// client is asked again. Defaults to 180 days.
+	MaxAge time.Duration
+	// Secure sets the Secure attribute of the consent cookie, which should be
+	// enabled when serving over HTTPS.
+	Secure bool
+}
+
+// Manager reads and records the consent of clients.
+type Manager struct {
+	conf Config
+}
+
+// New returns a new consent manager.
+func New(conf Config) *Manager {
+	if len(conf.Secret) == 0 || len(conf.Categories) == 0 {
+		panic("consent: secret and categories are required")
+	}
+
+	if conf.CookieName == "" {
+		conf.CookieName = "pt_consent"
+	}
+
+	if conf.MaxAge == 0 {
+		conf.MaxAge = 180 * 24 * time.Hour
+	}
+
+	return &Manager{conf: conf}
+}
+
+// sign returns the signature of the encoded cookie payload.
+func (m *Manager) sign(encoded string) string {
+	mac := hmac.New(sha256.New, m.conf.Secret)
+	_, _ = mac.Write([]byte(encoded))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Get returns the consent of the request, and if the client has made a
+// consent decision. Missing, expired or tampered cookies are treated as if no
+// decision has been made, with no categories consented to.
+func (m *Manager) Get(r *http.Request) (consent Consent, decided bool) {
+	consent = Consent{}
+
+	cookie, err := r.Cookie(m.conf.CookieName)
+	if err != nil {
+		return consent, false
+	}
+
+	idx := strings.LastIndexByte(cookie.Value, '.')
+	if idx < 0 {
+		return consent, false
+	}
+
+	encoded, sig := cookie.Value[:idx], cookie.Value[idx+1:]
+	if !hmac.Equal([]byte(sig), []byte(m.sign(encoded))) {
+		return consent, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return consent, false
+	}
+
+	values, err := url.ParseQuery(string(payload))
+	if err != nil {
+		return consent, false
+	}
+
+	ts, err := strconv.ParseInt(values.Get("t"), 10, 64)
+	if err != nil || time.Since(time.Unix(ts, 0)) > m.conf.MaxAge {
+		return consent, false
+	}
+
+	for _, category := range strings.Split(values.Get("c"), ",") {
+		if m.known(category) {
+			consent[category] = true
+		}
+	}
+
+	return consent, true
+}
+
+// Ctx returns the ctx values for the request (see CtxKey and DecidedKey),
+// which should be merged into the ctx returned from Config.DefaultCtx.
+//
+// For example:
+//
+//	DefaultCtx: func(w http.ResponseWriter, r *http.Request) map[string]interface{} {
+//		return consentManager.Ctx(r)
+//	},
+func (m *Manager) Ctx(r *http.Request) map[string]interface{} {
+	consent, decided := m.Get(r)
+	return map[string]interface{}{CtxKey: consent, DecidedKey: decided}
+}
+
+// Set records the provided consent, by setting the consent cookie. Unknown
+// categories are ignored. An empty consent records that the client rejected
+// all categories.
+func (m *Manager) Set(w http.ResponseWriter, consent Consent) {
+	var categories []string
+	for category, ok := range consent {
+		if ok && m.known(category) {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+
+	values := url.Values{}
+	values.Set("c", strings.Join(categories, ","))
+	values.Set("t", strconv.FormatInt(time.Now().Unix(), 10))
+
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(values.Encode()))
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     m.conf.CookieName,
+		Value:    encoded + "." + m.sign(encoded),
+		Path:     "/",
+		MaxAge:   int(m.conf.MaxAge.Seconds()),
+		Secure:   m.conf.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Clear removes the consent cookie, so the client is asked again.
+func (m *Manager) Clear(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     m.conf.CookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   m.conf.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// known returns true if the category is one of Config.Categories.
+func (m *Manager) known(category string) bool {
+	for _, c := range m.conf.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler returns a http.HandlerFunc which records consent changes, submitted
+// as a POST form. The "accept" field can be "all" or "none", otherwise each
+// category is consented to if its field is provided (e.g. a checkbox named
+// "analytics"). The "clear" field removes the consent cookie instead. The
+// client is redirected to the "next" field (which must be a local path), or
+// receives a 204 No Content if not provided.
+//
+// For example:
+//
+//	<form method="post" action="/consent">
+//		<input type="hidden" name="next" value="{{ url.Path }}">
+//		<label><input type="checkbox" name="analytics"> Analytics</label>
+//		<button type="submit">Save</button>
+//		<button type="submit" name="accept" value="all">Accept all</button>
+//		<button type="submit" name="accept" value="none">Reject all</button>
+//	</form>
+func (m *Manager) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		if r.PostForm.Get("clear") != "" {
+			m.Clear(w)
+		} else {
+			consent := Consent{}
+
+			for _, category := range m.conf.Categories {
+				switch r.PostForm.Get("accept") {
+				case "all":
+					consent[category] = true
+				case "none":
+				default:
+					consent[category] = r.PostForm.Get(category) != ""
+				}
+			}
+
+			m.Set(w, consent)
+		}
+
+		next := r.PostForm.Get("next")
+		if strings.HasPrefix(next, "/") && !strings.HasPrefix(next, "//") && !strings.HasPrefix(next, "/\\") {
+			http.Redirect(w, r, next, http.StatusSeeOther)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type tagConsentNode struct {
+	category pongo2.IEvaluator
+	wrapper  *pongo2.NodeWrapper
+}
+
+func (node *tagConsentNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	category, err := node.category.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	if consent, _ := ctx.Public[CtxKey].(Consent); !consent.Has(category.String()) {
+		return nil
+	}
+
+	return node.wrapper.Execute(ctx, writer)
+}
+
+// tagConsentParser parses the "consent" tag, which only outputs its contents
+// if the client has consented to the provided category, read from the CtxKey
+// ctx key. For example:
+//
+//	{% consent "analytics" %}<script src="/js/analytics.js"></script>{% endconsent %}
+func tagConsentParser(doc *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	category, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed consent-tag arguments.", nil)
+	}
+
+	wrapper, _, err := doc.WrapUntilTag("endconsent")
+	if err != nil {
+		return nil, err
+	}
+
+	return &tagConsentNode{category: category, wrapper: wrapper}, nil
+}
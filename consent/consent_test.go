@@ -0,0 +1,149 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package consent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/lrstanley/pt"
+)
+
+func newTestManager() *Manager {
+	return New(Config{Secret: []byte("secret"), Categories: []string{"analytics", "marketing"}})
+}
+
+// roundTrip sets the consent on a response, and returns a request with the
+// resulting cookie.
+func roundTrip(m *Manager, consent Consent) *http.Request {
+	rec := httptest.NewRecorder()
+	m.Set(rec, consent)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+func TestManagerGet(t *testing.T) {
+	m := newTestManager()
+
+	consent, decided := m.Get(httptest.NewRequest(http.MethodGet, "/", nil))
+	if decided || len(consent) != 0 {
+		t.Errorf("no cookie: consent = %v, decided = %v", consent, decided)
+	}
+
+	consent, decided = m.Get(roundTrip(m, Consent{"analytics": true, "unknown": true}))
+	if !decided || !consent.Has("analytics") || consent.Has("marketing") || consent.Has("unknown") {
+		t.Errorf("consent = %v, decided = %v", consent, decided)
+	}
+
+	consent, decided = m.Get(roundTrip(m, Consent{}))
+	if !decided || len(consent) != 0 {
+		t.Errorf("rejected: consent = %v, decided = %v", consent, decided)
+	}
+}
+
+func TestManagerGetTampered(t *testing.T) {
+	m := newTestManager()
+	r := roundTrip(m, Consent{"analytics": true})
+
+	cookie, err := r.Cookie("pt_consent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := New(Config{Secret: []byte("other"), Categories: []string{"analytics"}})
+	if _, decided := other.Get(r); decided {
+		t.Error("cookie signed with another secret was accepted")
+	}
+
+	tampered := httptest.NewRequest(http.MethodGet, "/", nil)
+	tampered.AddCookie(&http.Cookie{Name: "pt_consent", Value: "x" + cookie.Value})
+	if _, decided := m.Get(tampered); decided {
+		t.Error("tampered cookie was accepted")
+	}
+}
+
+func TestManagerGetExpired(t *testing.T) {
+	m := New(Config{Secret: []byte("secret"), Categories: []string{"analytics"}, MaxAge: -time.Second})
+
+	if _, decided := m.Get(roundTrip(m, Consent{"analytics": true})); decided {
+		t.Error("expired cookie was accepted")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := newTestManager()
+
+	tests := []struct {
+		form     url.Values
+		code     int
+		location string
+		want     Consent
+	}{
+		{form: url.Values{"accept": {"all"}}, code: http.StatusNoContent, want: Consent{"analytics": true, "marketing": true}},
+		{form: url.Values{"accept": {"none"}, "next": {"/pricing"}}, code: http.StatusSeeOther, location: "/pricing", want: Consent{}},
+		{form: url.Values{"marketing": {"on"}, "next": {"//evil.example"}}, code: http.StatusNoContent, want: Consent{"marketing": true}},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/consent", strings.NewReader(tt.form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		m.Handler()(rec, r)
+
+		if rec.Code != tt.code || rec.Header().Get("Location") != tt.location {
+			t.Errorf("%v: code = %d, location = %q", tt.form, rec.Code, rec.Header().Get("Location"))
+		}
+
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, c := range rec.Result().Cookies() {
+			r.AddCookie(c)
+		}
+
+		consent, decided := m.Get(r)
+		if !decided || len(consent) != len(tt.want) {
+			t.Errorf("%v: consent = %v, want %v", tt.form, consent, tt.want)
+		}
+		for category := range tt.want {
+			if !consent.Has(category) {
+				t.Errorf("%v: missing %q", tt.form, category)
+			}
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler()(rec, httptest.NewRequest(http.MethodGet, "/consent", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET code = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestTagConsent(t *testing.T) {
+	m := newTestManager()
+
+	ld := pt.New("consent", pt.Config{
+		FS: fstest.MapFS{
+			"index.html": {Data: []byte(`{% consent "analytics" %}analytics{% endconsent %}{% consent "marketing" %}marketing{% endconsent %}`)},
+		},
+		DefaultCtx: func(_ http.ResponseWriter, r *http.Request) map[string]interface{} {
+			return m.Ctx(r)
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	ld.Render(rec, roundTrip(m, Consent{"analytics": true}), "index.html", nil)
+
+	if rec.Body.String() != "analytics" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "analytics")
+	}
+}
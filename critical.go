@@ -0,0 +1,102 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	reHeadEnd        = regexp.MustCompile(`(?i)</head\s*>`)
+	reStylesheetLink = regexp.MustCompile(`(?i)<link\b[^>]*\brel\s*=\s*["']?stylesheet["']?[^>]*>`)
+	reStylesheetRel  = regexp.MustCompile(`(?i)\brel\s*=\s*["']?stylesheet["']?`)
+	reStyleEnd       = regexp.MustCompile(`(?i)</style`)
+)
+
+type criticalCSSPreset struct {
+	pattern string
+	css     string
+}
+
+// SetCriticalCSS registers the critical CSS for templates whose path matches
+// pattern (see path.Match(), e.g. "blog/*"). css is the path of a stylesheet
+// within Config.StaticFS (e.g. a full stylesheet, or CSS extracted for the
+// above-the-fold content), which is inlined within a "<style>" tag at the end
+// of "<head>". All stylesheet "<link>" tags within "<head>" are changed to
+// load asynchronously, so they no longer block rendering. Later registrations
+// take priority over earlier ones. Only applies to Render(), RenderE() and
+// RenderStatus(), which buffer the output of matching templates. Panics if
+// the pattern is malformed.
+//
+// For example:
+//
+//	ld.SetCriticalCSS("*.html", "css/critical.css")
+func (ld *Loader) SetCriticalCSS(pattern, css string) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(fmt.Sprintf("invalid critical css pattern %q: %v", pattern, err))
+	}
+
+	ld.criticalMu.Lock()
+	ld.critical = append(ld.critical, criticalCSSPreset{pattern: pattern, css: css})
+	ld.criticalMu.Unlock()
+}
+
+// criticalCSS returns the critical CSS path for the template path, if any.
+func (ld *Loader) criticalCSS(tpath string) string {
+	ld.criticalMu.RLock()
+	defer ld.criticalMu.RUnlock()
+
+	for i := len(ld.critical) - 1; i >= 0; i-- {
+		if ok, _ := path.Match(ld.critical[i].pattern, tpath); ok {
+			return ld.critical[i].css
+		}
+	}
+	return ""
+}
+
+// inlineCriticalCSS inlines the critical CSS of the job within the "<head>" of
+// the output, and changes stylesheets to load asynchronously. The output is
+// returned unchanged if it has no "<head>", or the CSS can't be read.
+func (ld *Loader) inlineCriticalCSS(j *renderJob, out []byte) []byte {
+	loc := reHeadEnd.FindIndex(out)
+	if loc == nil {
+		return out
+	}
+
+	fsys := j.conf.staticFS()
+	if fsys == nil {
+		j.conf.logf(LevelWarn, "critical css: %s: no Config.StaticFS provided", j.criticalCSS)
+		return out
+	}
+
+	css, err := fs.ReadFile(fsys, strings.TrimPrefix(j.criticalCSS, "/"))
+	if err != nil {
+		j.conf.logf(LevelWarn, "critical css: %v", err)
+		return out
+	}
+
+	// Prevent the CSS from closing the style tag early.
+	css = reStyleEnd.ReplaceAll(css, []byte(`<\/style`))
+
+	head := reStylesheetLink.ReplaceAllFunc(out[:loc[0]], func(link []byte) []byte {
+		async := reStylesheetRel.ReplaceAll(link, []byte(`rel="preload" as="style" onload="this.onload=null;this.rel='stylesheet'"`))
+		return append(append(append(async, "<noscript>"...), link...), "</noscript>"...)
+	})
+
+	var buf bytes.Buffer
+	buf.Grow(len(out) + len(css) + 64)
+	buf.Write(head)
+	buf.WriteString("<style>")
+	buf.Write(css)
+	buf.WriteString("</style>")
+	buf.Write(out[loc[0]:])
+
+	return buf.Bytes()
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCriticalCSS(t *testing.T) {
+	ld := New("critical", Config{
+		FS: fstest.MapFS{
+			"blog/post.html": {Data: []byte(`<html><head><link rel="stylesheet" href="/main.css"></head><body></body></html>`)},
+			"index.html":     {Data: []byte(`<html><head><link rel="stylesheet" href="/main.css"></head><body></body></html>`)},
+		},
+		StaticFS: fstest.MapFS{
+			"critical.css": {Data: []byte(`body{margin:0}</style><script>`)},
+			"blog.css":     {Data: []byte(`h1{color:red}`)},
+		},
+	})
+	ld.SetCriticalCSS("*.html", "/critical.css")
+	ld.SetCriticalCSS("blog/*", "blog.css")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{
+			path: "index.html",
+			want: `<html><head><link rel="preload" as="style" onload="this.onload=null;this.rel='stylesheet'" href="/main.css">` +
+				`<noscript><link rel="stylesheet" href="/main.css"></noscript>` +
+				`<style>body{margin:0}<\/style><script></style></head><body></body></html>`,
+		},
+		{
+			path: "blog/post.html",
+			want: `<html><head><link rel="preload" as="style" onload="this.onload=null;this.rel='stylesheet'" href="/main.css">` +
+				`<noscript><link rel="stylesheet" href="/main.css"></noscript>` +
+				`<style>h1{color:red}</style></head><body></body></html>`,
+		},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), tt.path, nil)
+
+		if rec.Body.String() != tt.want {
+			t.Errorf("%s:\n got: %s\nwant: %s", tt.path, rec.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestCriticalCSSMissing(t *testing.T) {
+	const page = `<html><head><link rel="stylesheet" href="/main.css"></head></html>`
+
+	ld := New("critical-missing", Config{
+		FS:       fstest.MapFS{"index.html": {Data: []byte(page)}},
+		StaticFS: fstest.MapFS{},
+	})
+	ld.SetCriticalCSS("*", "missing.css")
+
+	rec := httptest.NewRecorder()
+	ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "index.html", nil)
+
+	if rec.Body.String() != page {
+		t.Errorf("body = %q, want the unmodified page", rec.Body.String())
+	}
+}
+
+func TestSetCriticalCSSInvalidPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a malformed pattern")
+		}
+	}()
+
+	New("critical-invalid", Config{FS: fstest.MapFS{}}).SetCriticalCSS("[", "a.css")
+}
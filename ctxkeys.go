@@ -0,0 +1,43 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// ctxKeyName returns the template ctx name of a request context key, see
+// Config.CtxKeys.
+func ctxKeyName(key interface{}) (string, bool) {
+	if s, ok := key.(fmt.Stringer); ok {
+		return s.String(), true
+	}
+
+	if v := reflect.ValueOf(key); v.Kind() == reflect.String {
+		return v.String(), true
+	}
+	return "", false
+}
+
+// applyCtxKeys copies the request context values of Config.CtxKeys into ctx,
+// unless ctx already contains the key.
+func applyCtxKeys(conf *Config, ctx map[string]interface{}, r *http.Request) {
+	for _, key := range conf.CtxKeys {
+		name, ok := ctxKeyName(key)
+		if !ok {
+			continue
+		}
+
+		if _, ok = ctx[name]; ok {
+			continue
+		}
+
+		if value := r.Context().Value(key); value != nil {
+			ctx[name] = value
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+type testCtxKey string
+
+type testStringerKey struct{}
+
+func (testStringerKey) String() string { return "tenant" }
+
+func TestCtxKeys(t *testing.T) {
+	ld := New("ctxkeys", Config{
+		FS:      fstest.MapFS{"index.html": {Data: []byte(`{{ user }}/{{ tenant }}/{{ missing|default:"none" }}`)}},
+		CtxKeys: []interface{}{testCtxKey("user"), testStringerKey{}, testCtxKey("missing"), 42},
+	})
+
+	ctx := context.WithValue(context.Background(), testCtxKey("user"), "alice")
+	ctx = context.WithValue(ctx, testStringerKey{}, "acme")
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	rec := httptest.NewRecorder()
+	ld.Render(rec, r, "index.html", nil)
+	if want := "alice/acme/none"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+
+	// The render ctx takes priority.
+	rec = httptest.NewRecorder()
+	ld.Render(rec, r, "index.html", M{"user": "bob"})
+	if want := "bob/acme/none"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
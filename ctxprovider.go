@@ -0,0 +1,92 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CtxProvider provides default ctx values for a request, see
+// Loader.AddCtxProvider(). Returning an error aborts the render.
+type CtxProvider func(w http.ResponseWriter, r *http.Request) (map[string]interface{}, error)
+
+type ctxProvider struct {
+	prefix string
+	fn     CtxProvider
+}
+
+// AddCtxProvider registers a default ctx provider, which is called for every
+// render of a request whose URL path starts with prefix (e.g. "/admin/"). An
+// empty prefix matches all requests. Providers are called in the order they
+// were registered (after Config.DefaultCtx and Config.DefaultCtxE), and later
+// providers take priority. If a provider returns an error, the render is
+// aborted, and the error is returned from RenderE() (or handled in the same
+// way as template errors by Render()).
+//
+// For example:
+//
+//	ld.AddCtxProvider("/admin/", func(w http.ResponseWriter, r *http.Request) (map[string]interface{}, error) {
+//		user, err := sessions.User(r)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return pt.M{"admin": user}, nil
+//	})
+func (ld *Loader) AddCtxProvider(prefix string, fn CtxProvider) {
+	ld.providersMu.Lock()
+	ld.providers = append(ld.providers, ctxProvider{prefix: prefix, fn: fn})
+	ld.providersMu.Unlock()
+}
+
+// defaultCtx merges the ctx from Config.DefaultCtx, Config.DefaultCtxE and
+// all matching ctx providers into ctx.
+func (ld *Loader) defaultCtx(conf *Config, w http.ResponseWriter, r *http.Request, ctx map[string]interface{}) error {
+	if conf.DefaultCtx != nil {
+		mergeCtx(conf, ctx, conf.DefaultCtx(w, r))
+	}
+
+	if conf.DefaultCtxE != nil {
+		pctx, err := conf.DefaultCtxE(w, r)
+		if err != nil {
+			return fmt.Errorf("default ctx: %w", err)
+		}
+		mergeCtx(conf, ctx, pctx)
+	}
+
+	ld.providersMu.RLock()
+	providers := ld.providers
+	ld.providersMu.RUnlock()
+
+	for _, p := range providers {
+		if !strings.HasPrefix(r.URL.Path, p.prefix) {
+			continue
+		}
+
+		pctx, err := p.fn(w, r)
+		if err != nil {
+			return fmt.Errorf("ctx provider %q: %w", p.prefix, err)
+		}
+		mergeCtx(conf, ctx, pctx)
+	}
+
+	return nil
+}
+
+// mergeCtx merges src into dst (see Config.DeepMergeCtx). dst must be owned
+// by the render, as it is modified. src (and the maps nested within it) are
+// never modified, as they may be shared across renders (e.g. a package-level
+// ctx passed to Render(), or maps returned from DefaultCtx).
+func mergeCtx(conf *Config, dst, src map[string]interface{}) {
+	if conf.DeepMergeCtx {
+		deepMerge(dst, src)
+		return
+	}
+
+	for key := range src {
+		dst[key] = src[key]
+	}
+}
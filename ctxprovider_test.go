@@ -0,0 +1,72 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderDoesNotModifyCtx(t *testing.T) {
+	fsys := fstest.MapFS{"index.html": {Data: []byte(`{{ name }} {{ meta.title }} {{ meta.lang }}`)}}
+
+	shared := M{"site": "example", "meta": M{"lang": "en"}}
+
+	for _, deep := range []bool{false, true} {
+		ld := New("ctx-isolation", Config{
+			FS:           fsys,
+			DeepMergeCtx: deep,
+			DefaultCtx: func(http.ResponseWriter, *http.Request) map[string]interface{} {
+				return shared
+			},
+			DefaultCtxE: func(http.ResponseWriter, *http.Request) (map[string]interface{}, error) {
+				return M{"extra": true}, nil
+			},
+		})
+
+		rctx := M{"name": "bob", "meta": M{"title": "Home"}}
+
+		rec := httptest.NewRecorder()
+		ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "index.html", rctx)
+
+		want := "bob Home "
+		if deep {
+			want = "bob Home en"
+		}
+		if got := rec.Body.String(); got != want {
+			t.Errorf("deep=%v: body = %q, want %q", deep, got, want)
+		}
+
+		if want := (M{"name": "bob", "meta": M{"title": "Home"}}); !reflect.DeepEqual(rctx, want) {
+			t.Errorf("deep=%v: render ctx modified: %v", deep, rctx)
+		}
+		if want := (M{"site": "example", "meta": M{"lang": "en"}}); !reflect.DeepEqual(shared, want) {
+			t.Errorf("deep=%v: default ctx modified: %v", deep, shared)
+		}
+	}
+}
+
+func TestRenderSharedCtxConcurrent(t *testing.T) {
+	ld := New("ctx-concurrent", Config{FS: fstest.MapFS{"index.html": {Data: []byte(`{{ name }}`)}}})
+	shared := M{"name": "bob"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ld.Render(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "index.html", shared)
+		}()
+	}
+	wg.Wait()
+
+	if len(shared) != 1 {
+		t.Errorf("shared ctx modified: %v", shared)
+	}
+}
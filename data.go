@@ -0,0 +1,163 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// DataFormat is the format of the document produced by a template rendered
+// with Loader.RenderData().
+type DataFormat string
+
+const (
+	// DataJSON is used for templates which produce JSON documents.
+	DataJSON DataFormat = "json"
+	// DataXML is used for templates which produce XML documents (e.g. feeds
+	// or sitemaps).
+	DataXML DataFormat = "xml"
+)
+
+// ContentType returns the Content-Type used for the format.
+func (f DataFormat) ContentType() string {
+	switch f {
+	case DataJSON:
+		return "application/json"
+	case DataXML:
+		return "application/xml"
+	default:
+		return "text/plain"
+	}
+}
+
+// validate checks if the provided document can be parsed as the format.
+func (f DataFormat) validate(data []byte) error {
+	switch f {
+	case DataJSON:
+		var v interface{}
+		return json.Unmarshal(data, &v)
+	case DataXML:
+		dec := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			_, err := dec.Token()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+}
+
+var reExtends = regexp.MustCompile(`{%-?\s*extends\s`)
+
+// rawLoader wraps a template loader, disabling HTML autoescaping for all
+// templates it loads. Templates which extend another template are left as-is,
+// as only the root template is executed.
+type rawLoader struct {
+	pongo2.TemplateLoader
+}
+
+func (l rawLoader) Get(path string) (io.Reader, error) {
+	rd, err := l.TemplateLoader.Get(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := rd.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	src, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	if reExtends.Match(src) {
+		return bytes.NewReader(src), nil
+	}
+
+	return io.MultiReader(
+		bytes.NewReader([]byte("{% autoescape off %}")),
+		bytes.NewReader(src),
+		bytes.NewReader([]byte("{% endautoescape %}")),
+	), nil
+}
+
+// RenderData is similar to Render(), however it is used for templates which
+// produce data documents (e.g. JSON manifests, or XML feeds), rather than HTML.
+// The Content-Type is set based on the format, and HTML autoescaping is
+// disabled (values must be escaped for the target format within the template,
+// for example, using the "json" filter).
+//
+// When Config.Debug is enabled, the output is validated to ensure it can be
+// parsed as the provided format.
+//
+// Errors are handled in the same way as Render(): missing templates call
+// Config.NotFoundHandler if provided, and other errors (including invalid
+// output) call Config.OnError if provided, otherwise panicking.
+func (ld *Loader) RenderData(w http.ResponseWriter, r *http.Request, path string, rctx map[string]interface{}, format DataFormat) {
+	if err := ld.renderData(w, r, path, rctx, format); err != nil {
+		ld.handleErr(w, r, err)
+	}
+}
+
+func (ld *Loader) renderData(w http.ResponseWriter, r *http.Request, path string, rctx map[string]interface{}, format DataFormat) error {
+	conf := ld.conf()
+
+	tpl, err := ld.load(conf, ld.dataFS, ld.dataCache, path)
+	if err != nil {
+		if !ld.notFound(nil, path) {
+			return err
+		}
+
+		if conf.NotFoundHandler != nil {
+			conf.NotFoundHandler(w, r)
+			return nil
+		}
+
+		return fmt.Errorf("%w: %s", ErrTemplateNotFound, path)
+	}
+
+	ctx, err := ld.buildCtx(conf, w, r, rctx)
+	if err != nil {
+		return err
+	}
+
+	if conf.Debug {
+		ld.validateSchema(conf, path, ctx)
+	}
+
+	out, err := tpl.ExecuteBytes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if conf.Debug {
+		if err = format.validate(out); err != nil {
+			return fmt.Errorf("template %q produced invalid %s: %w", path, format, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+
+	if _, err = w.Write(out); err != nil {
+		conf.logf(LevelError, "error: %v", err)
+	}
+
+	return nil
+}
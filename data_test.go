@@ -0,0 +1,84 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderData(t *testing.T) {
+	ld := New("data", Config{
+		FS: fstest.MapFS{"feed.json": {Data: []byte(`{"name": {{ name|json }}}`)}},
+	})
+
+	w := httptest.NewRecorder()
+	ld.RenderData(w, httptest.NewRequest(http.MethodGet, "/", nil), "feed.json", M{"name": "<a & b>"}, DataJSON)
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	// The output isn't HTML escaped by the template, only by the json filter.
+	if got, want := w.Body.String(), "{\"name\": \"\\u003ca \\u0026 b\\u003e\"\n}"; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestRenderDataErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"invalid.json": {Data: []byte(`{"name": {{ name }}}`)},
+		"broken.json":  {Data: []byte(`{{ fail() }}`)},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		is   error
+	}{
+		{name: "not found", path: "missing.json", is: ErrTemplateNotFound},
+		{name: "invalid output", path: "invalid.json"},
+		{name: "execution error", path: "broken.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got error
+			ld := New("data-errors", Config{
+				FS:      fsys,
+				Debug:   true,
+				OnError: func(_ http.ResponseWriter, _ *http.Request, err error) { got = err },
+			})
+
+			ld.RenderData(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), tt.path, M{
+				"name": "x",
+				"fail": func() (string, error) { return "", errors.New("failed") },
+			}, DataJSON)
+
+			if got == nil {
+				t.Fatal("OnError not called")
+			}
+			if tt.is != nil && !errors.Is(got, tt.is) {
+				t.Errorf("error = %v, want %v", got, tt.is)
+			}
+		})
+	}
+}
+
+func TestRenderDataNotFoundHandler(t *testing.T) {
+	ld := New("data-not-found", Config{
+		FS:              fstest.MapFS{},
+		NotFoundHandler: http.NotFound,
+	})
+
+	w := httptest.NewRecorder()
+	ld.RenderData(w, httptest.NewRequest(http.MethodGet, "/", nil), "missing.json", nil, DataJSON)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+)
+
+// DebugHandler returns a http.HandlerFunc which reports the loader statistics
+// (see Loader.Stats()) as JSON. This should not be exposed publicly.
+//
+// For example:
+//
+//	r.With(adminOnly).Get("/debug/templates", ld.DebugHandler())
+func (ld *Loader) DebugHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		JSON(w, r, ld.Stats())
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDebugHandler(t *testing.T) {
+	ld := New("debug", Config{
+		FS:          fstest.MapFS{"index.html": {Data: []byte(`index`)}},
+		CacheParsed: true,
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := ld.RenderBytes("index.html", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	ld.DebugHandler()(rec, httptest.NewRequest(http.MethodGet, "/debug/templates", nil))
+
+	var stats Stats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+
+	if stats.ParseCache.Hits != 1 || stats.ParseCache.Misses != 1 {
+		t.Errorf("parse cache = %+v, want 1 hit and 1 miss", stats.ParseCache)
+	}
+}
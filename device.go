@@ -0,0 +1,100 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"strings"
+)
+
+var (
+	botUAs = []string{
+		"bot", "crawler", "spider", "slurp", "curl/", "wget/", "facebookexternalhit",
+		"embedly", "quora link preview", "whatsapp", "lighthouse", "headlesschrome",
+		"python-requests", "go-http-client",
+	}
+	tabletUAs = []string{"ipad", "tablet", "kindle", "silk/", "playbook"}
+	mobileUAs = []string{
+		"mobile", "iphone", "ipod", "android", "blackberry", "opera mini",
+		"opera mobi", "iemobile", "windows phone", "webos",
+	}
+	legacyUAs = []string{"msie ", "trident/", "opera/9.", "netscape"}
+)
+
+// Device is a coarse classification of the client making a request, based on
+// the User-Agent header. It is not meant to be exhaustive, and should only be
+// used for special-casing (e.g. bots or legacy clients), not feature detection.
+type Device struct {
+	Mobile bool
+	Tablet bool
+	Bot    bool
+	Legacy bool
+}
+
+// Class returns the most specific class name of the device, which is one of
+// "bot", "tablet", "mobile", "legacy", or an empty string for all other
+// (desktop) clients.
+func (d *Device) Class() string {
+	switch {
+	case d.Bot:
+		return "bot"
+	case d.Tablet:
+		return "tablet"
+	case d.Mobile:
+		return "mobile"
+	case d.Legacy:
+		return "legacy"
+	}
+	return ""
+}
+
+func (d *Device) ctx() M {
+	return M{
+		"mobile": d.Mobile,
+		"tablet": d.Tablet,
+		"bot":    d.Bot,
+		"legacy": d.Legacy,
+		"class":  d.Class(),
+	}
+}
+
+// DetectDevice classifies the client of the request, based on its User-Agent.
+// Requests without a User-Agent are treated as bots.
+func DetectDevice(r *http.Request) *Device {
+	ua := strings.ToLower(r.UserAgent())
+
+	d := &Device{}
+	if ua == "" {
+		d.Bot = true
+		return d
+	}
+
+	d.Bot = containsAny(ua, botUAs)
+	d.Tablet = containsAny(ua, tabletUAs) || (strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"))
+	d.Mobile = !d.Tablet && containsAny(ua, mobileUAs)
+	d.Legacy = containsAny(ua, legacyUAs)
+	return d
+}
+
+// deviceCandidate returns the device specific variant of the provided template
+// path (e.g. "index.html" -> "index.mobile.html"), or an empty string if the
+// device has no specific class.
+func deviceCandidate(d *Device, name string) string {
+	class := d.Class()
+	if class == "" {
+		return ""
+	}
+
+	return variantPath(name, class)
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDetectDevice(t *testing.T) {
+	tests := []struct {
+		ua    string
+		class string
+	}{
+		{"", "bot"},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "bot"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148", "mobile"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) Chrome/120.0 Mobile Safari/537.36", "mobile"},
+		{"Mozilla/5.0 (Linux; Android 13; SM-X700) Chrome/120.0 Safari/537.36", "tablet"},
+		{"Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X) Mobile/15E148", "tablet"},
+		{"Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)", "legacy"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0", ""},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User-Agent", tt.ua)
+
+		if class := DetectDevice(r).Class(); class != tt.class {
+			t.Errorf("%q: class = %q, want %q", tt.ua, class, tt.class)
+		}
+	}
+}
+
+func TestDeviceTemplates(t *testing.T) {
+	ld := New("device", Config{
+		FS: fstest.MapFS{
+			"index.html":        {Data: []byte(`desktop {{ device.class }}`)},
+			"index.mobile.html": {Data: []byte(`mobile {{ device.mobile }}`)},
+		},
+		DetectDevice:    true,
+		DeviceTemplates: true,
+	})
+
+	tests := []struct {
+		ua   string
+		want string
+	}{
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148", "mobile True"},
+		{"Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X) Mobile/15E148", "desktop tablet"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0", "desktop "},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User-Agent", tt.ua)
+
+		rec := httptest.NewRecorder()
+		ld.Render(rec, r, "index.html", nil)
+
+		if rec.Body.String() != tt.want {
+			t.Errorf("%q: body = %q, want %q", tt.ua, rec.Body.String(), tt.want)
+		}
+	}
+}
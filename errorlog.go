@@ -0,0 +1,76 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LogLevel is the severity of a message written to the error sinks.
+type LogLevel int
+
+const (
+	// LevelDebug is used for diagnostics which are only produced when enabled
+	// (e.g. changed templates with Config.Watch).
+	LevelDebug LogLevel = iota
+	// LevelWarn is used for problems which don't affect the response (e.g.
+	// schema violations, or unescaped output with Config.AuditSafe).
+	LevelWarn
+	// LevelError is used for errors which affect the response (e.g. errors
+	// while writing to the client).
+	LevelError
+)
+
+// String returns the name of the level.
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// ErrorSink is a destination for errors and diagnostics, see
+// Config.ErrorSinks. Writer and Func can be used together.
+type ErrorSink struct {
+	// MinLevel is the minimum level of messages sent to the sink.
+	MinLevel LogLevel
+	// Writer is an optional io.Writer which messages are written to, one per
+	// line.
+	Writer io.Writer
+	// Func is an optional callback which is invoked for each message (without
+	// a trailing newline). This can be used to forward messages to structured
+	// loggers (e.g. log/slog) or error trackers.
+	Func func(level LogLevel, msg string)
+}
+
+// logf writes the formatted message to the ErrorLogger, and all error sinks
+// which accept the level.
+func (c *Config) logf(level LogLevel, format string, args ...interface{}) {
+	msg := strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")
+
+	fmt.Fprintln(c.ErrorLogger, msg)
+
+	for _, sink := range c.ErrorSinks {
+		if level < sink.MinLevel {
+			continue
+		}
+
+		if sink.Writer != nil {
+			fmt.Fprintln(sink.Writer, msg)
+		}
+
+		if sink.Func != nil {
+			sink.Func(level, msg)
+		}
+	}
+}
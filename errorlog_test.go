@@ -0,0 +1,55 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestErrorSinks(t *testing.T) {
+	var logger, warnings bytes.Buffer
+	var levels []LogLevel
+
+	ld := New("errorlog", Config{
+		FS:          fstest.MapFS{"index.html": {Data: []byte(`<head></head>`)}},
+		ErrorLogger: &logger,
+		ErrorSinks: []ErrorSink{
+			{MinLevel: LevelWarn, Writer: &warnings},
+			{MinLevel: LevelDebug, Func: func(level LogLevel, _ string) { levels = append(levels, level) }},
+		},
+	})
+
+	conf := ld.conf()
+	conf.logf(LevelDebug, "debug message\n")
+	conf.logf(LevelError, "error %d", 1)
+
+	// Critical CSS without a StaticFS logs a warning.
+	ld.SetCriticalCSS("*", "critical.css")
+	ld.Render(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "index.html", nil)
+
+	want := "debug message\nerror 1\ncritical css: critical.css: no Config.StaticFS provided\n"
+	if logger.String() != want {
+		t.Errorf("ErrorLogger = %q, want %q", logger.String(), want)
+	}
+	if want := "error 1\ncritical css: critical.css: no Config.StaticFS provided\n"; warnings.String() != want {
+		t.Errorf("warn sink = %q, want %q", warnings.String(), want)
+	}
+	if want := []LogLevel{LevelDebug, LevelError, LevelWarn}; !reflect.DeepEqual(levels, want) {
+		t.Errorf("func sink levels = %v, want %v", levels, want)
+	}
+}
+
+func TestLogLevelString(t *testing.T) {
+	for level, want := range map[LogLevel]string{LevelDebug: "debug", LevelWarn: "warn", LevelError: "error", 7: "level(7)"} {
+		if level.String() != want {
+			t.Errorf("%d.String() = %q, want %q", int(level), level.String(), want)
+		}
+	}
+}
@@ -5,10 +5,37 @@
 package pt
 
 import (
+	"errors"
+	"io/fs"
 	"log"
 	"net/http"
+	"os"
 )
 
+// ErrTemplateNotFound can be returned (or wrapped) by Config.Loader functions
+// to signal that the requested template doesn't exist, which causes the
+// Config.NotFoundHandler to be invoked. Errors wrapping fs.ErrNotExist are
+// treated the same.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// ErrRenderCanceled is returned (wrapping the context error) when a render is
+// aborted because the request context was canceled, e.g. the client
+// disconnected, or the request timed out.
+var ErrRenderCanceled = errors.New("render canceled")
+
+// ErrIncludeCycle is returned (wrapped) when templates include, extend or
+// import each other in a cycle, or exceed Config.MaxIncludeDepth.
+var ErrIncludeCycle = errors.New("template include cycle")
+
+// ErrNoLoader is returned by NewWithOptions() when neither a filesystem nor a
+// loader function is provided.
+var ErrNoLoader = errors.New("no loader provided")
+
+// isNotFound checks if the error signals a missing template.
+func isNotFound(err error) bool {
+	return err != nil && (errors.Is(err, ErrTemplateNotFound) || errors.Is(err, fs.ErrNotExist) || os.IsNotExist(err))
+}
+
 // Error logs the given error, as well as optionally returns the error back to
 // the connection.
 func Error(logger *log.Logger, w http.ResponseWriter, code int, err error, show bool) {
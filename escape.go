@@ -0,0 +1,63 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	reBlockStart = regexp.MustCompile(`{%-?\s*block\s+(\w+)\s*-?%}`)
+	reBlockEnd   = regexp.MustCompile(`{%-?\s*endblock(?:\s+\w+)?\s*-?%}`)
+)
+
+// noAutoescape checks if the template path should be rendered without
+// autoescaping, see Config.NoAutoescapePaths.
+func (c *Config) noAutoescape(tpath string) bool {
+	for _, pattern := range c.NoAutoescapePaths {
+		if strings.HasSuffix(pattern, "/") {
+			if strings.HasPrefix(tpath, pattern) {
+				return true
+			}
+			continue
+		}
+
+		if ok, _ := path.Match(pattern, tpath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// setAutoescape wraps the template source in "autoescape" tags, so the
+// template is escaped according to its own path, rather than the template
+// which includes it. As blocks are executed in the context of the template
+// which renders them (e.g. the parent template, when extending), the body of
+// each block is wrapped as well. Templates which extend another template
+// can't be wrapped as a whole, as "extends" must be at the root level.
+//
+// The inserted tags never contain newlines, so line numbers reported by
+// pongo2 (e.g. on debug error pages) and by Config.AuditSafe still match the
+// template source.
+func setAutoescape(src []byte, enabled bool) []byte {
+	start := "{% autoescape off %}"
+	if enabled {
+		start = "{% autoescape on %}"
+	}
+
+	src = reBlockStart.ReplaceAll(src, []byte("$0"+start))
+	src = reBlockEnd.ReplaceAll(src, []byte("{% endautoescape %}$0"))
+
+	if reExtends.Match(src) {
+		return src
+	}
+
+	out := make([]byte, 0, len(src)+len(start)+19)
+	out = append(out, start...)
+	out = append(out, src...)
+	return append(out, "{% endautoescape %}"...)
+}
@@ -0,0 +1,48 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestNoAutoescapePaths(t *testing.T) {
+	ld := New("escape", Config{
+		FS: fstest.MapFS{
+			"emails/text/welcome.txt":  {Data: []byte(`Hi {{ name }}, {% include "../../partials/sig.html" %}`)},
+			"emails/text/base.txt":     {Data: []byte(`[{% block body %}{% endblock %}]`)},
+			"emails/text/reset.txt":    {Data: []byte(`{% extends "base.txt" %}{% block body %}{{ name }}{% endblock %}`)},
+			"emails/html/welcome.html": {Data: []byte(`Hi {{ name }}, {% include "../../notes.txt" %}`)},
+			"notes.txt":                {Data: []byte(`{{ name }}`)},
+			"partials/sig.html":        {Data: []byte(`<b>{{ name }}</b>`)},
+			"page.html":                {Data: []byte(`{% extends "emails/text/base.txt" %}{% block body %}{{ name }}{% endblock %}`)},
+		},
+		NoAutoescapePaths: []string{"emails/text/", "*.txt"},
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		// The include is escaped according to its own path.
+		{"emails/text/welcome.txt", `Hi <A&B>, <b>&lt;A&amp;B&gt;</b>`},
+		{"emails/text/reset.txt", `[<A&B>]`},
+		{"emails/html/welcome.html", `Hi &lt;A&amp;B&gt;, <A&B>`},
+		// Blocks are escaped according to the template which defines them.
+		{"page.html", `[&lt;A&amp;B&gt;]`},
+	}
+
+	for _, tt := range tests {
+		out, err := ld.RenderBytes(tt.path, M{"name": "<A&B>"})
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		if string(out) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.path, out, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// notModified sets the ETag and Last-Modified headers for the rendered output
+// of the job, and responds with 304 Not Modified if the request headers match,
+// in which case true is returned, and nothing else should be written. See
+// Config.ConditionalGET.
+//
+// The ETag is weak, as it is a hash of the uncompressed output, which is the
+// same for every content encoding (see Config.Compress).
+func (ld *Loader) notModified(w http.ResponseWriter, r *http.Request, j *renderJob, body []byte) bool {
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	modified := ld.lastModified(j)
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+
+	// As with net/http, If-None-Match takes precedence over
+	// If-Modified-Since.
+	match := false
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		match = etagMatch(inm, etag)
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil {
+			match = !modified.Truncate(time.Second).After(t)
+		}
+	}
+
+	if !match {
+		return false
+	}
+
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// lastModified returns the modification time of the job template, or the
+// time the loader was created if unavailable (or older).
+func (ld *Loader) lastModified(j *renderJob) time.Time {
+	if j.conf.FS != nil {
+		if info, err := fs.Stat(j.conf.FS, j.path); err == nil && info.ModTime().After(ld.ts) {
+			return info.ModTime()
+		}
+	}
+	return ld.ts
+}
+
+// etagMatch checks if the If-None-Match header value matches etag, using the
+// weak comparison (as required for If-None-Match).
+func etagMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
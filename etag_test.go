@@ -0,0 +1,60 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestConditionalGETWeakETag(t *testing.T) {
+	ld := New("etag", Config{
+		FS:              fstest.MapFS{"index.html": {Data: []byte(strings.Repeat("content ", 512))}},
+		ConditionalGET:  true,
+		Compress:        true,
+		CompressMinSize: 1,
+	})
+
+	get := func(encoding, inm string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if encoding != "" {
+			r.Header.Set("Accept-Encoding", encoding)
+		}
+		if inm != "" {
+			r.Header.Set("If-None-Match", inm)
+		}
+
+		w := httptest.NewRecorder()
+		ld.Render(w, r, "index.html", nil)
+		return w
+	}
+
+	identity, gzipped := get("", ""), get("gzip", "")
+
+	if gzipped.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("response wasn't compressed")
+	}
+
+	etag := identity.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Errorf("ETag = %q, want a weak ETag", etag)
+	}
+	if gzipped.Header().Get("ETag") != etag {
+		t.Errorf("ETag differs between encodings: %q, %q", etag, gzipped.Header().Get("ETag"))
+	}
+
+	for _, inm := range []string{etag, strings.TrimPrefix(etag, "W/"), `"other", ` + etag} {
+		if w := get("gzip", inm); w.Code != http.StatusNotModified {
+			t.Errorf("If-None-Match %q: status = %d, want 304", inm, w.Code)
+		}
+	}
+
+	if w := get("", `"other"`); w.Code != http.StatusOK {
+		t.Errorf("non-matching If-None-Match: status = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"html"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var reFaviconFile = regexp.MustCompile(`^(favicon|apple-touch-icon)(?:-(\d+x\d+))?\.(ico|png|svg)$`)
+
+// FaviconOptions are the options for Favicons().
+type FaviconOptions struct {
+	// Dir is the directory within the filesystem which contains the icons.
+	// Defaults to the root of the filesystem.
+	Dir string
+	// MaxAge is the duration icons can be cached by clients. Defaults to 30
+	// days.
+	MaxAge time.Duration
+}
+
+// FaviconSet is the set of icons registered by Favicons().
+type FaviconSet struct {
+	links []string
+}
+
+// HTML returns the "<link>" tags for all registered icons. This is also
+// available within templates using the "favicons" tag (e.g.
+// "{% favicons %}"), when the set is provided via Config.Favicons.
+func (s *FaviconSet) HTML() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(s.links, "\n")
+}
+
+// Favicons registers handlers for all favicons and touch icons within fsys,
+// at the root of the router (as clients request "/favicon.ico" and
+// "/apple-touch-icon.png" directly). Icons are served with long cache headers.
+// Supported file names are:
+//
+//	favicon.ico
+//	favicon.svg
+//	favicon-<size>.png          (e.g. favicon-32x32.png)
+//	apple-touch-icon.png
+//	apple-touch-icon-<size>.png (e.g. apple-touch-icon-180x180.png)
+//
+// The returned set can be provided to Config.Favicons, to generate the
+// corresponding "<link>" tags using the "favicons" tag.
+func Favicons(router Router, fsys fs.FS, opts FaviconOptions) *FaviconSet {
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+
+	if opts.MaxAge == 0 {
+		opts.MaxAge = 30 * 24 * time.Hour
+	}
+
+	entries, err := fs.ReadDir(fsys, opts.Dir)
+	if err != nil {
+		panic(fmt.Sprintf("unable to read favicons: %v", err))
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	set := &FaviconSet{}
+	cacheControl := "public, max-age=" + strconv.Itoa(int(opts.MaxAge.Seconds()))
+
+	for _, entry := range entries {
+		m := reFaviconFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(opts.Dir, entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("unable to read favicon %q: %v", entry.Name(), err))
+		}
+
+		contentType := mime.TypeByExtension("." + m[3])
+		if m[3] == "ico" {
+			contentType = "image/x-icon"
+		}
+
+		router.Get("/"+entry.Name(), func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Cache-Control", cacheControl)
+			_, _ = w.Write(data)
+		})
+
+		set.links = append(set.links, faviconLink(entry.Name(), m[1], m[2], m[3], contentType))
+	}
+
+	return set
+}
+
+func faviconLink(name, kind, size, ext, contentType string) string {
+	attrs := []string{`rel="icon"`}
+	if kind == "apple-touch-icon" {
+		attrs[0] = `rel="apple-touch-icon"`
+	}
+
+	switch {
+	case size != "":
+		attrs = append(attrs, `sizes="`+size+`"`)
+	case ext == "ico":
+		attrs = append(attrs, `sizes="any"`)
+	}
+
+	if kind == "favicon" && ext != "ico" {
+		attrs = append(attrs, `type="`+html.EscapeString(contentType)+`"`)
+	}
+
+	return "<link " + strings.Join(attrs, " ") + ` href="/` + html.EscapeString(name) + `">`
+}
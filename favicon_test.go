@@ -0,0 +1,66 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+// testRouter is a Router which records the registered handlers.
+type testRouter map[string]http.HandlerFunc
+
+func (tr testRouter) Get(pattern string, h http.HandlerFunc) { tr[pattern] = h }
+
+func TestFavicons(t *testing.T) {
+	router := testRouter{}
+
+	set := Favicons(router, fstest.MapFS{
+		"icons/favicon.ico":                  {Data: []byte("ico")},
+		"icons/favicon.svg":                  {Data: []byte("<svg></svg>")},
+		"icons/favicon-32x32.png":            {Data: []byte("png")},
+		"icons/apple-touch-icon-180x180.png": {Data: []byte("touch")},
+		"icons/logo.png":                     {Data: []byte("ignored")},
+	}, FaviconOptions{Dir: "icons"})
+
+	if len(router) != 4 {
+		t.Errorf("registered %d handlers, want 4", len(router))
+	}
+
+	h, ok := router["/favicon.ico"]
+	if !ok {
+		t.Fatal("/favicon.ico not registered")
+	}
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/favicon.ico", nil))
+	if rec.Body.String() != "ico" || rec.Header().Get("Content-Type") != "image/x-icon" ||
+		rec.Header().Get("Cache-Control") != "public, max-age=2592000" {
+		t.Errorf("unexpected response: %q %v", rec.Body.String(), rec.Header())
+	}
+
+	want := `<link rel="apple-touch-icon" sizes="180x180" href="/apple-touch-icon-180x180.png">` + "\n" +
+		`<link rel="icon" sizes="32x32" type="image/png" href="/favicon-32x32.png">` + "\n" +
+		`<link rel="icon" sizes="any" href="/favicon.ico">` + "\n" +
+		`<link rel="icon" type="image/svg+xml" href="/favicon.svg">`
+	if set.HTML() != want {
+		t.Errorf("HTML() =\n%s\nwant:\n%s", set.HTML(), want)
+	}
+
+	ld := New("favicons", Config{
+		FS:       fstest.MapFS{"index.html": {Data: []byte(`{% favicons %}`)}},
+		Favicons: set,
+	})
+
+	if out, err := ld.RenderBytes("index.html", nil); err != nil || string(out) != want {
+		t.Errorf("favicons tag = %q, %v", out, err)
+	}
+
+	if (*FaviconSet)(nil).HTML() != "" {
+		t.Error("nil set should return no links")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// FeatureSource evaluates feature flags for a request, see Config.Features.
+// Implementations can wrap a feature flag service, evaluating flags based on
+// the user or session of the request.
+type FeatureSource interface {
+	// Flags returns the state of all flags for the request, keyed by name.
+	// Flags which aren't returned are considered disabled.
+	Flags(r *http.Request) map[string]bool
+}
+
+// FeatureMap is a FeatureSource with static flags, which are the same for all
+// requests.
+type FeatureMap map[string]bool
+
+// Flags implements FeatureSource.
+func (f FeatureMap) Flags(*http.Request) map[string]bool {
+	return f
+}
+
+// FeatureFunc is an adapter to allow the use of ordinary functions as a
+// FeatureSource.
+type FeatureFunc func(r *http.Request) map[string]bool
+
+// Flags implements FeatureSource.
+func (f FeatureFunc) Flags(r *http.Request) map[string]bool {
+	return f(r)
+}
+
+type tagFeatureNode struct {
+	name        pongo2.IEvaluator
+	wrapper     *pongo2.NodeWrapper
+	elseWrapper *pongo2.NodeWrapper
+}
+
+func (node *tagFeatureNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	name, err := node.name.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	if flags, _ := ctx.Public["flags"].(map[string]bool); flags[name.String()] {
+		return node.wrapper.Execute(ctx, writer)
+	}
+
+	if node.elseWrapper != nil {
+		return node.elseWrapper.Execute(ctx, writer)
+	}
+	return nil
+}
+
+// tagFeatureParser parses the "feature" tag, which only outputs its contents
+// if the provided feature flag is enabled for the request (see
+// Config.Features), with an optional "else" branch. For example:
+//
+//	{% feature "new_nav" %}
+//		{% include "partials/nav-v2.html" %}
+//	{% else %}
+//		{% include "partials/nav.html" %}
+//	{% endfeature %}
+func tagFeatureParser(doc *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	name, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed feature-tag arguments.", nil)
+	}
+
+	node := &tagFeatureNode{name: name}
+
+	wrapper, endargs, err := doc.WrapUntilTag("else", "endfeature")
+	if err != nil {
+		return nil, err
+	}
+	node.wrapper = wrapper
+
+	if endargs.Count() > 0 {
+		return nil, endargs.Error("Arguments not allowed here.", nil)
+	}
+
+	if wrapper.Endtag == "else" {
+		node.elseWrapper, endargs, err = doc.WrapUntilTag("endfeature")
+		if err != nil {
+			return nil, err
+		}
+
+		if endargs.Count() > 0 {
+			return nil, endargs.Error("Arguments not allowed here.", nil)
+		}
+	}
+
+	return node, nil
+}
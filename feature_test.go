@@ -0,0 +1,55 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFeatureTag(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html": {Data: []byte(`{% feature "new_nav" %}new{% else %}old{% endfeature %}|{% feature "beta" %}beta{% endfeature %}|{{ flags.new_nav }}`)},
+	}
+
+	tests := []struct {
+		name     string
+		features FeatureSource
+		want     string
+	}{
+		{"none", nil, "old||"},
+		{"map", FeatureMap{"new_nav": true}, "new||True"},
+		{"func", FeatureFunc(func(r *http.Request) map[string]bool {
+			return map[string]bool{"beta": r.URL.Query().Get("beta") != "", "new_nav": false}
+		}), "old|beta|False"},
+	}
+
+	for _, tt := range tests {
+		ld := New("feature-"+tt.name, Config{FS: fsys, Features: tt.features})
+
+		rec := httptest.NewRecorder()
+		ld.Render(rec, httptest.NewRequest(http.MethodGet, "/?beta=1", nil), "index.html", nil)
+
+		if rec.Body.String() != tt.want {
+			t.Errorf("%s: body = %q, want %q", tt.name, rec.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestFeatureTagMalformed(t *testing.T) {
+	for _, tpl := range []string{
+		`{% feature "a" "b" %}{% endfeature %}`,
+		`{% feature "a" %}{% endfeature "a" %}`,
+		`{% feature "a" %}`,
+	} {
+		ld := New("feature-malformed", Config{FS: fstest.MapFS{"index.html": {Data: []byte(tpl)}}})
+
+		if _, err := ld.RenderBytes("index.html", nil); err == nil {
+			t.Errorf("%s: expected a parse error", tpl)
+		}
+	}
+}
@@ -6,35 +6,137 @@ package pt
 
 import (
 	"bytes"
-	"encoding/json"
+	"html"
 	"strings"
 
 	"github.com/flosch/pongo2/v6"
 )
 
 func init() { //nolint:gochecknoinits
-	err := pongo2.RegisterFilter("json", filterJSON)
-	if err != nil {
-		panic(err)
+	filters := map[string]pongo2.FilterFunction{
+		"json":          filterJSON,
+		"diff":          filterDiff,
+		"ordinal":       filterOrdinal,
+		"apnumber":      filterAPNumber,
+		"naturalday":    filterNaturalDay,
+		"country_name":  filterCountryName,
+		"language_name": filterLanguageName,
+		"flag":          filterFlag,
+		"lighten":       filterLighten,
+		"darken":        filterDarken,
+		"contrast_text": filterContrastText,
+	}
+
+	for name, fn := range filters {
+		if err := RegisterFilter(name, fn); err != nil {
+			panic(err)
+		}
 	}
 }
 
+// filterJSON encodes the input as JSON. The optional parameter is either a
+// comma-separated list of options, or (for backwards compatibility) the
+// indentation to use. Supported options are:
+//
+//	pretty   -> indent with 4 spaces.
+//	sorted   -> sort all object keys, including those from structs.
+//	noescape -> don't escape "<", ">" and "&" (escaped by default, so the
+//	            output is safe to embed within "<script>" tags).
+//
+// For example:
+//
+//	<script>var data = {{ obj|json|safe }};</script>
+//	<pre>{{ obj|json:"pretty,sorted" }}</pre>
 func filterJSON(in, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
 	var b bytes.Buffer
-	enc := json.NewEncoder(&b)
 
-	// This doesn't need to be done, as pongo2 by default will escape vars.
-	// enc.SetEscapeHTML(true)
+	opts := JSONOptions{EscapeHTML: true}
 
-	if args := strings.ToLower(param.String()); args == "pretty" {
-		enc.SetIndent("", "    ")
-	} else if args != "" {
-		enc.SetIndent("", args)
+	if args := param.String(); args != "" && !parseJSONFilterOptions(args, &opts) {
+		opts.Indent = args
 	}
 
-	if err := enc.Encode(in.Interface()); err != nil {
+	if err := DefaultJSONEncoder.Encode(&b, in.Interface(), opts); err != nil {
 		return nil, &pongo2.Error{Sender: "filter:json", OrigError: err}
 	}
 
 	return pongo2.AsValue(b.String()), nil
 }
+
+// parseJSONFilterOptions parses the comma-separated options of the json
+// filter, returning false if any of the options are unknown.
+func parseJSONFilterOptions(args string, opts *JSONOptions) bool {
+	parsed := *opts
+
+	for _, arg := range strings.Split(args, ",") {
+		switch strings.ToLower(strings.TrimSpace(arg)) {
+		case "pretty":
+			parsed.Indent = "    "
+		case "sorted":
+			parsed.SortKeys = true
+		case "noescape":
+			parsed.EscapeHTML = false
+		case "escape":
+			parsed.EscapeHTML = true
+		default:
+			return false
+		}
+	}
+
+	*opts = parsed
+	return true
+}
+
+// filterDiff renders a unified diff as HTML, wrapping each line in a span
+// with a class based on the type of line, within a "<pre>" block. The optional
+// parameter is the class prefix, which defaults to "diff". For example:
+//
+//	{{ entry.changes|diff }}
+//
+// Renders:
+//
+//	<pre class="diff">
+//	<span class="diff-file">--- a/config.yaml</span>
+//	<span class="diff-file">+++ b/config.yaml</span>
+//	<span class="diff-hunk">@@ -1,2 +1,2 @@</span>
+//	<span class="diff-ctx"> name: example</span>
+//	<span class="diff-del">-port: 80</span>
+//	<span class="diff-add">+port: 8080</span>
+//	</pre>
+func filterDiff(in, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	prefix := "diff"
+	if p := param.String(); p != "" {
+		prefix = html.EscapeString(p)
+	}
+
+	var b strings.Builder
+
+	b.WriteString(`<pre class="` + prefix + `">`)
+
+	for _, line := range strings.Split(strings.TrimRight(in.String(), "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		var class string
+
+		switch {
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "diff "):
+			class = "file"
+		case strings.HasPrefix(line, "@@"):
+			class = "hunk"
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+		default:
+			class = "ctx"
+		}
+
+		b.WriteString("\n" + `<span class="` + prefix + "-" + class + `">`)
+		b.WriteString(html.EscapeString(line))
+		b.WriteString("</span>")
+	}
+
+	b.WriteString("\n</pre>")
+
+	return pongo2.AsSafeValue(b.String()), nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+// Package forms provides a set of form field macros (input, select, checkbox,
+// errors and csrf), so forms render consistently across templates. The macros
+// are made available to a loader by wrapping its Config.FS with FS(), or its
+// Config.Loader with Loader(), and imported from "pt/forms.html":
+//
+//	{% import "pt/forms.html" input, select, checkbox, errors, csrf %}
+//	<form method="post">
+//		{{ csrf() }}
+//		{{ input("email", form.email, "Email", "email", true) }}
+//		{{ select("plan", plans, form.plan, "Plan") }}
+//		{{ checkbox("terms", form.terms, "Accept the terms") }}
+//	</form>
+//
+// Field errors are read from the ErrorsKey ctx key (see Errors), and the CSRF
+// token from the CSRFKey ctx key.
+package forms
+
+import (
+	"embed"
+	"io/fs"
+	"strings"
+)
+
+const (
+	// File is the path the macros are imported from. As pongo2 resolves
+	// imports relative to the importing template, the macros are served for
+	// any path ending with File.
+	File = "pt/forms.html"
+
+	// ErrorsKey is the ctx key the macros read field errors from, which should
+	// be an Errors value (or any map of field names to a list of messages).
+	ErrorsKey = "form_errors"
+
+	// CSRFKey is the ctx key the csrf macro reads the CSRF token from.
+	CSRFKey = "csrf_token"
+)
+
+//go:embed forms.html
+var source embed.FS
+
+// Errors are the validation errors of a form, keyed by field name.
+type Errors map[string][]string
+
+// Add adds an error message for the provided field.
+func (e Errors) Add(field, msg string) {
+	e[field] = append(e[field], msg)
+}
+
+// Has returns true if the provided field has any errors.
+func (e Errors) Has(field string) bool {
+	return len(e[field]) > 0
+}
+
+// Empty returns true if there are no errors for any field.
+func (e Errors) Empty() bool {
+	for _, msgs := range e {
+		if len(msgs) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Option is a single option of the select macro.
+type Option struct {
+	Value string
+	Label string
+}
+
+// isFile checks if the template path refers to the macros file.
+func isFile(name string) bool {
+	name = strings.ReplaceAll(name, "\\", "/")
+	return name == File || strings.HasSuffix(name, "/"+File)
+}
+
+type overlayFS struct {
+	fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if isFile(name) {
+		return source.Open("forms.html")
+	}
+	return o.FS.Open(name)
+}
+
+// FS wraps fsys (for use with Config.FS), so the macros can be imported from
+// File. All other paths are opened from fsys.
+func FS(fsys fs.FS) fs.FS {
+	return overlayFS{fsys}
+}
+
+// Loader wraps fn (for use with Config.Loader), so the macros can be imported
+// from File. All other paths are loaded with fn.
+func Loader(fn func(path string) ([]byte, error)) func(path string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		if isFile(path) {
+			return source.ReadFile("forms.html")
+		}
+		return fn(path)
+	}
+}
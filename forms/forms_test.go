@@ -0,0 +1,101 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package forms
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lrstanley/pt"
+)
+
+const testPage = `{% import "pt/forms.html" input, select, checkbox, csrf %}` +
+	`{{ csrf() }}{{ input("email", email, "Email", "email", true) }}` +
+	`{{ select("plan", plans, "pro", "Plan") }}{{ checkbox("terms", true, "Terms") }}`
+
+func renderPage(t *testing.T, ld *pt.Loader, ctx pt.M) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	if err := ld.RenderE(rec, httptest.NewRequest(http.MethodGet, "/", nil), "users/signup.html", ctx); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Body.String()
+}
+
+func TestMacros(t *testing.T) {
+	ld := pt.New("forms", pt.Config{
+		FS: FS(fstest.MapFS{"users/signup.html": {Data: []byte(testPage)}}),
+	})
+
+	errs := Errors{}
+	errs.Add("email", "is <invalid>")
+
+	out := renderPage(t, ld, pt.M{
+		"email":   `a"b@example.com`,
+		"plans":   []Option{{Value: "free", Label: "Free"}, {Value: "pro", Label: "Pro"}},
+		ErrorsKey: errs,
+		CSRFKey:   "token123",
+	})
+
+	for _, want := range []string{
+		`<input type="hidden" name="csrf_token" value="token123">`,
+		`<label for="email">Email</label>`,
+		`<input type="email" id="email" name="email" value="a&quot;b@example.com" required aria-invalid="true" aria-describedby="email-errors">`,
+		`<li>is &lt;invalid&gt;</li>`,
+		`<option value="pro" selected>Pro</option>`,
+		`<option value="free">Free</option>`,
+		`<input type="checkbox" id="terms" name="terms" value="on" checked>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %s\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, `id="plan-errors"`) {
+		t.Error("errors rendered for a field without errors")
+	}
+}
+
+func TestLoader(t *testing.T) {
+	ld := pt.New("forms-loader", pt.Config{
+		Loader: Loader(func(path string) ([]byte, error) {
+			if path == "users/signup.html" {
+				return []byte(`{% import "pt/forms.html" csrf %}{{ csrf("token") }}`), nil
+			}
+			return nil, pt.ErrTemplateNotFound
+		}),
+	})
+
+	if out := renderPage(t, ld, pt.M{CSRFKey: "abc"}); out != `<input type="hidden" name="token" value="abc">` {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	errs := Errors{}
+	if !errs.Empty() || errs.Has("email") {
+		t.Error("new Errors should be empty")
+	}
+
+	errs.Add("email", "required")
+	if errs.Empty() || !errs.Has("email") || errs.Has("name") {
+		t.Errorf("unexpected state: %v", errs)
+	}
+}
+
+func TestErrorsMacro(t *testing.T) {
+	ld := pt.New("forms-errors", pt.Config{
+		FS: FS(fstest.MapFS{"users/signup.html": {Data: []byte(`{% import "pt/forms.html" errors %}{{ errors("email") }}`)}}),
+	})
+
+	out := renderPage(t, ld, pt.M{ErrorsKey: Errors{"email": {"required"}}})
+	if want := "<ul class=\"form-errors\" id=\"email-errors\">\n  <li>required</li>\n</ul>"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
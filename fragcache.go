@@ -0,0 +1,467 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// CacheStore is the store used for rendered page and fragment caches, see
+// Config.CacheStore.
+type CacheStore interface {
+	// Get returns the value for key, and false if it doesn't exist (or has
+	// expired).
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores the value for key, which should expire after ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the value for key, if it exists.
+	Delete(ctx context.Context, key string) error
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// DefaultMemoryCacheEntries is the maximum number of entries of the
+// MemoryCacheStore returned by NewMemoryCacheStore().
+const DefaultMemoryCacheEntries = 10000
+
+// MemoryCacheStore is an in-memory CacheStore, which is the default when
+// Config.CacheStore isn't provided. The number of entries is bounded, with
+// the least recently used entries evicted first. As entries aren't bounded
+// by size, large pages or fragments (or a high number of vary combinations)
+// should use a store with its own memory limits instead (e.g. redisstore).
+type MemoryCacheStore struct {
+	mu          sync.Mutex
+	maxEntries  int
+	lru         *list.List // of *memoryCacheEntry, most recently used first.
+	entries     map[string]*list.Element
+	lastCleanup time.Time
+}
+
+// NewMemoryCacheStore returns a new in-memory CacheStore, holding at most
+// DefaultMemoryCacheEntries entries.
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return NewMemoryCacheStoreSize(DefaultMemoryCacheEntries)
+}
+
+// NewMemoryCacheStoreSize returns a new in-memory CacheStore, holding at most
+// maxEntries entries. Panics if maxEntries isn't positive.
+func NewMemoryCacheStoreSize(maxEntries int) *MemoryCacheStore {
+	if maxEntries <= 0 {
+		panic("memory cache store requires a positive max entries")
+	}
+
+	return &MemoryCacheStore{
+		maxEntries:  maxEntries,
+		lru:         list.New(),
+		entries:     make(map[string]*list.Element),
+		lastCleanup: time.Now(),
+	}
+}
+
+// Get implements CacheStore.
+func (s *MemoryCacheStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := el.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expires) {
+		return nil, false, nil
+	}
+
+	s.lru.MoveToFront(el)
+	return entry.value, true, nil
+}
+
+// Set implements CacheStore.
+func (s *MemoryCacheStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[key]; ok {
+		el.Value = &memoryCacheEntry{key: key, value: value, expires: now.Add(ttl)}
+		s.lru.MoveToFront(el)
+	} else {
+		s.entries[key] = s.lru.PushFront(&memoryCacheEntry{key: key, value: value, expires: now.Add(ttl)})
+	}
+
+	// Remove expired entries, at most once per minute.
+	if now.Sub(s.lastCleanup) >= time.Minute {
+		s.lastCleanup = now
+
+		for el := s.lru.Front(); el != nil; {
+			next := el.Next()
+			if now.After(el.Value.(*memoryCacheEntry).expires) {
+				s.remove(el)
+			}
+			el = next
+		}
+	}
+
+	for s.lru.Len() > s.maxEntries {
+		s.remove(s.lru.Back())
+	}
+
+	return nil
+}
+
+// Delete implements CacheStore.
+func (s *MemoryCacheStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// remove removes the entry. s.mu must be held.
+func (s *MemoryCacheStore) remove(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.entries, el.Value.(*memoryCacheEntry).key)
+}
+
+// flightGroup coalesces concurrent calls with the same key, so only one of
+// them executes (similar to golang.org/x/sync/singleflight).
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	wg  sync.WaitGroup
+	val interface{}
+	err error
+}
+
+// do executes fn, unless a call for the same key is already in progress, in
+// which case it waits for that call, and returns its result.
+func (g *flightGroup) do(key string, fn func() (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err
+	}
+
+	// If fn panics, waiting callers receive an error rather than an empty
+	// result.
+	c := &flightCall{err: errors.New("coalesced call panicked")}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.val, c.err = fn()
+	return c.val, c.err
+}
+
+// inflight checks if a call for the key is in progress.
+func (g *flightGroup) inflight(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	_, ok := g.calls[key]
+	return ok
+}
+
+// Cached returns the cached value for key from the Config.CacheStore, or
+// calls fn to render it, caching the result for ttl. Concurrent calls for the
+// same key are coalesced, so only one render happens when an entry expires
+// under load, and the other callers wait for its result. If Config.CacheStale
+// is provided, expired entries are served to other callers while the entry
+// is being re-rendered, rather than waiting. Errors from the store are logged,
+// and treated as a cache miss.
+func (ld *Loader) Cached(ctx context.Context, key string, ttl time.Duration, fn func() ([]byte, error)) ([]byte, error) {
+	return ld.cached(ctx, ld.conf(), key, ttl, fn)
+}
+
+// cached is Cached(), using the provided config.
+func (ld *Loader) cached(ctx context.Context, conf *Config, key string, ttl time.Duration, fn func() ([]byte, error)) ([]byte, error) {
+	raw, ok, err := conf.CacheStore.Get(ctx, key)
+	if err != nil {
+		conf.logf(LevelWarn, "cache: get %q: %v", key, err)
+	}
+
+	if ok && len(raw) >= 8 {
+		fresh := time.Unix(0, int64(binary.BigEndian.Uint64(raw[:8]))) //nolint:gosec
+
+		if time.Now().Before(fresh) || ld.flights.inflight(key) {
+			return raw[8:], nil
+		}
+	}
+
+	out, err := ld.flights.do(key, func() (interface{}, error) {
+		// The result is shared with all callers waiting for the key, so
+		// storing it mustn't fail if this caller's request is canceled.
+		ctx := withoutCancel(ctx)
+
+		out, err := fn()
+		if err != nil {
+			return nil, err
+		}
+
+		// Entries are prefixed with the time until which they are fresh, and
+		// are kept for an additional CacheStale, so they can be served while
+		// being re-rendered.
+		entry := make([]byte, 8, 8+len(out))
+		binary.BigEndian.PutUint64(entry, uint64(time.Now().Add(ttl).UnixNano())) //nolint:gosec
+		entry = append(entry, out...)
+
+		if err = conf.CacheStore.Set(ctx, key, entry, ttl+conf.CacheStale); err != nil {
+			conf.logf(LevelWarn, "cache: set %q: %v", key, err)
+		}
+
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.([]byte), nil
+}
+
+// RenderCached is the same as Render(), however the output (and the status
+// set by the "status" tag) is cached under key for ttl (see Loader.Cached()).
+// The ctx is still built for every request, so expensive data should be
+// loaded within the template (or the handler should use Loader.Cached()
+// directly). Headers set by the "header" tag are only sent with the response
+// which rendered the page.
+func (ld *Loader) RenderCached(w http.ResponseWriter, r *http.Request, key string, ttl time.Duration, path string, rctx map[string]interface{}) {
+	conf := ld.conf()
+
+	j, err := ld.prepare(w, r, conf, path, rctx)
+	if err != nil {
+		ld.handleErr(w, r, err)
+		return
+	}
+
+	if j == nil {
+		return
+	}
+
+	out, err := ld.cached(r.Context(), conf, "page:"+key, ttl, func() ([]byte, error) {
+		// The render is shared with all requests waiting for the key, so it
+		// mustn't be aborted if the client of this request disconnects.
+		shared := *j
+		shared.r = j.r.WithContext(withoutCancel(j.r.Context()))
+
+		// The output is prefixed with the status code.
+		var buf bytes.Buffer
+		buf.Write([]byte{0, 0})
+
+		if err := ld.execute(&buf, &shared); err != nil {
+			return nil, err
+		}
+
+		out := buf.Bytes()
+
+		status := http.StatusOK
+		if j.state.status != 0 {
+			status = j.state.status
+		}
+		binary.BigEndian.PutUint16(out, uint16(status)) //nolint:gosec
+
+		return out, nil
+	})
+	if err != nil {
+		ld.handleErr(w, r, err)
+		return
+	}
+
+	if len(out) < 2 {
+		ld.handleErr(w, r, fmt.Errorf("cache: malformed page entry %q", key))
+		return
+	}
+
+	w.WriteHeader(int(binary.BigEndian.Uint16(out)))
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if _, err = w.Write(out[2:]); err != nil {
+		j.conf.logf(LevelError, "error: %v", err)
+	}
+}
+
+// fragmentKey returns the cache key for a fragment. If there are vary values,
+// they are hashed (or signed, with Config.CacheKeySecret) along with the key,
+// with each value length-prefixed so that values can't be crafted to collide
+// with another combination.
+func fragmentKey(conf *Config, key string, vary []string) string {
+	if len(vary) == 0 {
+		return "fragment:" + key
+	}
+
+	var h hash.Hash
+	if len(conf.CacheKeySecret) > 0 {
+		h = hmac.New(sha256.New, conf.CacheKeySecret)
+	} else {
+		h = sha256.New()
+	}
+
+	var size [8]byte
+	for _, v := range append([]string{key}, vary...) {
+		binary.BigEndian.PutUint64(size[:], uint64(len(v)))
+		_, _ = h.Write(size[:])
+		_, _ = h.Write([]byte(v))
+	}
+
+	return "fragment:" + key + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+type tagCacheNode struct {
+	key     pongo2.IEvaluator
+	ttl     pongo2.IEvaluator
+	vary    []pongo2.IEvaluator
+	wrapper *pongo2.NodeWrapper
+}
+
+func (node *tagCacheNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	state := stateFromCtx(ctx)
+	if state == nil {
+		return node.wrapper.Execute(ctx, writer)
+	}
+
+	key, perr := node.key.Evaluate(ctx)
+	if perr != nil {
+		return perr
+	}
+
+	ttl, perr := node.ttl.Evaluate(ctx)
+	if perr != nil {
+		return perr
+	}
+
+	conf := state.conf
+
+	vary := make([]string, 0, len(conf.CacheVary)+len(node.vary))
+	for _, name := range conf.CacheVary {
+		// Missing keys are still included, so they can't be confused with
+		// the first value being shifted into their place.
+		if v, ok := ctx.Public[name]; ok {
+			vary = append(vary, fmt.Sprint(v))
+		} else {
+			vary = append(vary, "")
+		}
+	}
+
+	for _, e := range node.vary {
+		v, perr := e.Evaluate(ctx)
+		if perr != nil {
+			return perr
+		}
+		vary = append(vary, v.String())
+	}
+
+	rctx := context.Background()
+	if state.r != nil {
+		rctx = state.r.Context()
+	}
+
+	out, err := state.ld.cached(rctx, conf, fragmentKey(conf, key.String(), vary), time.Duration(ttl.Integer())*time.Second, func() ([]byte, error) {
+		var buf bytes.Buffer
+
+		if err := node.wrapper.Execute(ctx, &buf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		var pongoErr *pongo2.Error
+		if errors.As(err, &pongoErr) {
+			return pongoErr
+		}
+		return ctx.OrigError(err, nil)
+	}
+
+	_, _ = writer.Write(out)
+	return nil
+}
+
+// tagCacheParser parses the "cache" tag, which caches the rendered output of
+// the wrapped section under the provided key, for the provided number of
+// seconds. See Loader.Cached(). For example:
+//
+//	{% cache "sidebar" 300 %}{% include "partials/sidebar.html" %}{% endcache %}
+//
+// Fragments which depend on the user (or similar) must declare the values
+// they vary by, in addition to Config.CacheVary, so they are cached
+// separately:
+//
+//	{% cache "account-menu" 300 vary user.id locale %}...{% endcache %}
+func tagCacheParser(doc *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	key, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	node := &tagCacheNode{key: key, ttl: ttl}
+
+	if arguments.Match(pongo2.TokenIdentifier, "vary") != nil {
+		if arguments.Remaining() == 0 {
+			return nil, arguments.Error("The cache-tag vary argument requires at least one value.", nil)
+		}
+
+		for arguments.Remaining() > 0 {
+			e, err := arguments.ParseExpression()
+			if err != nil {
+				return nil, err
+			}
+			node.vary = append(node.vary, e)
+		}
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed cache-tag arguments.", nil)
+	}
+
+	node.wrapper, _, err = doc.WrapUntilTag("endcache")
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRenderCachedLeaderCanceled(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	ld := New("cached-canceled", Config{
+		FS: fstest.MapFS{"page.html": {Data: []byte(`{{ wait() }}{% for i in items %}{{ i }}{% endfor %}`)}},
+	})
+
+	rctx := M{
+		"items": []int{1, 2, 3},
+		"wait": func() string {
+			close(started)
+			<-release
+			return "page:"
+		},
+	}
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leader := httptest.NewRecorder()
+	leaderDone := make(chan struct{})
+
+	go func() {
+		defer close(leaderDone)
+		ld.RenderCached(leader, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(leaderCtx), "page", time.Minute, "page.html", rctx)
+	}()
+
+	<-started
+
+	waiter := httptest.NewRecorder()
+	waiterDone := make(chan struct{})
+
+	go func() {
+		defer close(waiterDone)
+		ld.RenderCached(waiter, httptest.NewRequest(http.MethodGet, "/", nil), "page", time.Minute, "page.html", rctx)
+	}()
+
+	// Give the waiter time to join the in-progress render, then disconnect the
+	// leader before the render completes.
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	close(release)
+
+	<-leaderDone
+	<-waiterDone
+
+	if want := "page:123"; waiter.Body.String() != want {
+		t.Errorf("waiter body = %q, want %q", waiter.Body.String(), want)
+	}
+}
+
+func TestRenderCachedStatusAndHead(t *testing.T) {
+	ld := New("cached-status", Config{
+		FS: fstest.MapFS{"missing.html": {Data: []byte(`{% status 404 %}not found`)}},
+	})
+
+	for i, method := range []string{http.MethodGet, http.MethodGet, http.MethodHead} {
+		rec := httptest.NewRecorder()
+		ld.RenderCached(rec, httptest.NewRequest(method, "/", nil), "missing", time.Minute, "missing.html", nil)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%d %s: status = %d, want 404", i, method, rec.Code)
+		}
+
+		want := "not found"
+		if method == http.MethodHead {
+			want = ""
+		}
+		if rec.Body.String() != want {
+			t.Errorf("%d %s: body = %q, want %q", i, method, rec.Body.String(), want)
+		}
+	}
+}
+
+func TestMemoryCacheStoreBounded(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryCacheStoreSize(2)
+
+	_ = s.Set(ctx, "a", []byte("a"), time.Minute)
+	_ = s.Set(ctx, "b", []byte("b"), time.Minute)
+
+	// Use "a", so "b" is the least recently used.
+	if _, ok, _ := s.Get(ctx, "a"); !ok {
+		t.Fatal("a missing")
+	}
+
+	_ = s.Set(ctx, "c", []byte("c"), time.Minute)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok, _ := s.Get(ctx, key); ok != want {
+			t.Errorf("Get(%q) ok = %v, want %v", key, ok, want)
+		}
+	}
+
+	_ = s.Set(ctx, "d", []byte("d"), -time.Second)
+	if _, ok, _ := s.Get(ctx, "d"); ok {
+		t.Error("expired entry returned")
+	}
+}
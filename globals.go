@@ -0,0 +1,48 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+// SetGlobal sets a ctx value which is available to every template rendered by
+// the loader (including RenderBytes()), such as the site name or build
+// version. Globals have a lower priority than the ctx provided to Render() and
+// Config.DefaultCtx, see Render(). Setting a nil value removes the global.
+//
+// For example:
+//
+//	ld.SetGlobal("site_name", "Example")
+//	ld.SetGlobal("version", version)
+func (ld *Loader) SetGlobal(key string, value interface{}) {
+	ld.globalsMu.Lock()
+	defer ld.globalsMu.Unlock()
+
+	if value == nil {
+		delete(ld.globals, key)
+		return
+	}
+
+	if ld.globals == nil {
+		ld.globals = make(map[string]interface{})
+	}
+	ld.globals[key] = value
+}
+
+// SetGlobals sets multiple global ctx values at once, see SetGlobal().
+func (ld *Loader) SetGlobals(globals map[string]interface{}) {
+	for key, value := range globals {
+		ld.SetGlobal(key, value)
+	}
+}
+
+// applyGlobals adds the globals to ctx, unless ctx already contains the key.
+func (ld *Loader) applyGlobals(ctx map[string]interface{}) {
+	ld.globalsMu.RLock()
+	defer ld.globalsMu.RUnlock()
+
+	for key, value := range ld.globals {
+		if _, ok := ctx[key]; !ok {
+			ctx[key] = value
+		}
+	}
+}
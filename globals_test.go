@@ -0,0 +1,35 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGlobals(t *testing.T) {
+	ld := New("globals", Config{
+		FS: fstest.MapFS{"index.html": {Data: []byte(`{{ site_name }}/{{ version }}/{{ user|default:"-" }}`)}},
+		DefaultCtx: func(http.ResponseWriter, *http.Request) map[string]interface{} {
+			return M{"version": "default"}
+		},
+	})
+
+	ld.SetGlobals(M{"site_name": "Example", "version": "1.0", "user": "global"})
+	ld.SetGlobal("user", nil)
+
+	if out, err := ld.RenderBytes("index.html", nil); err != nil || string(out) != "Example/1.0/-" {
+		t.Errorf("RenderBytes() = %q, %v", out, err)
+	}
+
+	// Config.DefaultCtx and the render ctx take priority over globals.
+	rec := httptest.NewRecorder()
+	ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "index.html", M{"site_name": "Override"})
+	if want := "Override/default/-"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
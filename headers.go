@@ -0,0 +1,146 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// HeadersKey is a ctx key which can be provided to Render() (or returned from
+// Config.DefaultCtx) with a http.Header value, to set headers for that render
+// only. These take priority over presets registered with Loader.SetHeaders().
+//
+// For example:
+//
+//	ld.Render(w, r, "account.html", pt.M{
+//		pt.HeadersKey: http.Header{"Cache-Control": {"private, no-store"}},
+//	})
+const HeadersKey = "_headers"
+
+// ContentTypeKey is a ctx key which can be provided to Render() with a string
+// value, to override the Content-Type (see Config.ContentType) for that render
+// only. This allows rendering non-HTML templates (e.g. XML, plain text or
+// iCalendar files) through the same loader.
+//
+// For example:
+//
+//	ld.Render(w, r, "feed.xml", pt.M{
+//		pt.ContentTypeKey: "application/rss+xml; charset=utf-8",
+//		"posts":           posts,
+//	})
+const ContentTypeKey = "_content_type"
+
+type headerPreset struct {
+	pattern string
+	header  http.Header
+}
+
+// SetHeaders registers a header preset, which is applied to every render of a
+// template whose path matches pattern (see path.Match(), e.g. "admin/*"). This
+// ensures headers which apply to a group of templates aren't forgotten within
+// individual handlers. Presets are applied in the order they were registered,
+// so later presets replace the values of earlier ones. Panics if the pattern
+// is malformed.
+//
+// For example:
+//
+//	ld.SetHeaders("admin/*", http.Header{
+//		"Cache-Control": {"private"},
+//		"X-Robots-Tag":  {"noindex"},
+//	})
+func (ld *Loader) SetHeaders(pattern string, header http.Header) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(fmt.Sprintf("invalid header preset pattern %q: %v", pattern, err))
+	}
+
+	ld.headersMu.Lock()
+	ld.headers = append(ld.headers, headerPreset{pattern: pattern, header: header.Clone()})
+	ld.headersMu.Unlock()
+}
+
+// applyHeaders sets the headers from all presets matching the template path,
+// followed by the headers from the HeadersKey and ContentTypeKey ctx keys.
+func (ld *Loader) applyHeaders(w http.ResponseWriter, tpath string, ctx map[string]interface{}) {
+	ld.headersMu.RLock()
+	for _, preset := range ld.headers {
+		if ok, _ := path.Match(preset.pattern, tpath); ok {
+			setHeaders(w.Header(), preset.header)
+		}
+	}
+	ld.headersMu.RUnlock()
+
+	if header, ok := ctx[HeadersKey].(http.Header); ok {
+		setHeaders(w.Header(), header)
+	}
+
+	if contentType, ok := ctx[ContentTypeKey].(string); ok && contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+}
+
+// setHeaders replaces the values of all headers in dst which are within src.
+func setHeaders(dst, src http.Header) {
+	for key, values := range src {
+		dst[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+}
+
+type tagHeaderNode struct {
+	name  pongo2.IEvaluator
+	value pongo2.IEvaluator
+}
+
+func (node *tagHeaderNode) Execute(ctx *pongo2.ExecutionContext, _ pongo2.TemplateWriter) *pongo2.Error {
+	name, err := node.name.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	value, err := node.value.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	state := stateFromCtx(ctx)
+	if state == nil {
+		return ctx.Error("header tag used outside of a pt.Loader render", nil)
+	}
+
+	// Renders outside of a request (e.g. RenderBytes()) have no headers.
+	if state.w != nil {
+		state.w.Header().Set(name.String(), value.String())
+	}
+	return nil
+}
+
+// tagHeaderParser parses the "header" tag, which sets a response header for
+// the page, replacing any existing values. Headers are sent once the template
+// has finished executing, so this has no effect once output has been flushed
+// to the client (e.g. with Config.StreamOutput, or after a "flush" tag). The
+// tag produces no output. For example:
+//
+//	{% header "X-Robots-Tag" "noindex" %}
+//	{% header "Cache-Control" "public, max-age=300" %}
+func tagHeaderParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	name, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed header-tag arguments.", nil)
+	}
+
+	return &tagHeaderNode{name: name, value: value}, nil
+}
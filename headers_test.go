@@ -0,0 +1,56 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSetHeaders(t *testing.T) {
+	ld := New("headers", Config{
+		FS: fstest.MapFS{
+			"admin/index.html": {Data: []byte(`{% header "x-frame-options" "DENY" %}admin`)},
+			"feed.xml":         {Data: []byte(`<rss></rss>`)},
+		},
+	})
+
+	preset := http.Header{"Cache-Control": {"private"}, "X-Robots-Tag": {"noindex"}}
+	ld.SetHeaders("admin/*", preset)
+	ld.SetHeaders("admin/*", http.Header{"cache-control": {"private, no-store"}})
+	preset.Set("X-Robots-Tag", "changed")
+
+	rec := httptest.NewRecorder()
+	ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "admin/index.html", M{
+		HeadersKey: http.Header{"X-Robots-Tag": {"none"}},
+	})
+
+	for key, want := range map[string]string{
+		"Cache-Control":   "private, no-store",
+		"X-Robots-Tag":    "none",
+		"X-Frame-Options": "DENY",
+	} {
+		if got := rec.Header().Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "feed.xml", M{ContentTypeKey: "application/rss+xml"})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/rss+xml" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if rec.Header().Get("Cache-Control") != "" {
+		t.Error("preset applied to a template which doesn't match")
+	}
+
+	// Renders outside of a request ignore the tag.
+	if out, err := ld.RenderBytes("admin/index.html", nil); err != nil || string(out) != "admin" {
+		t.Errorf("RenderBytes() = %q, %v", out, err)
+	}
+}
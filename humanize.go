@@ -0,0 +1,111 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+var apNumbers = [...]string{"one", "two", "three", "four", "five", "six", "seven", "eight", "nine"}
+
+// filterOrdinal converts an integer to its ordinal string. For example:
+//
+//	{{ 3|ordinal }} -> "3rd"
+//	{{ 11|ordinal }} -> "11th"
+func filterOrdinal(in, _ *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	if !in.IsNumber() && !in.IsString() {
+		return in, nil
+	}
+
+	// Non-numeric strings would otherwise be treated as 0.
+	if in.IsString() {
+		if _, err := strconv.Atoi(in.String()); err != nil {
+			return in, nil
+		}
+	}
+
+	n := in.Integer()
+
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+
+	suffix := "th"
+	switch {
+	case abs%100 >= 11 && abs%100 <= 13:
+	case abs%10 == 1:
+		suffix = "st"
+	case abs%10 == 2:
+		suffix = "nd"
+	case abs%10 == 3:
+		suffix = "rd"
+	}
+
+	return pongo2.AsValue(strconv.Itoa(n) + suffix), nil
+}
+
+// filterAPNumber converts integers from 1 to 9 to their written form, per
+// Associated Press style. Other numbers are returned as-is. For example:
+//
+//	{{ 3|apnumber }} -> "three"
+//	{{ 12|apnumber }} -> 12
+func filterAPNumber(in, _ *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	if !in.IsNumber() && !in.IsString() {
+		return in, nil
+	}
+
+	if n := in.Integer(); n >= 1 && n <= 9 {
+		return pongo2.AsValue(apNumbers[n-1]), nil
+	}
+	return in, nil
+}
+
+// filterNaturalDay returns "today", "yesterday" or "tomorrow" for dates
+// relative to the current date (in the location of the date), and otherwise
+// formats the date using the optional Go time layout, which defaults to
+// "Jan 2, 2006". For example:
+//
+//	{{ post.created|naturalday }} -> "yesterday"
+//	{{ post.created|naturalday:"2006-01-02" }} -> "2024-01-02"
+func filterNaturalDay(in, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	var t time.Time
+
+	switch v := in.Interface().(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return in, nil
+		}
+		t = *v
+	default:
+		return in, nil
+	}
+
+	now := time.Now().In(t.Location())
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, t.Location())
+
+	switch {
+	case day.Equal(today):
+		return pongo2.AsValue("today"), nil
+	case day.Equal(today.AddDate(0, 0, -1)):
+		return pongo2.AsValue("yesterday"), nil
+	case day.Equal(today.AddDate(0, 0, 1)):
+		return pongo2.AsValue("tomorrow"), nil
+	}
+
+	layout := "Jan 2, 2006"
+	if p := param.String(); p != "" {
+		layout = p
+	}
+
+	return pongo2.AsValue(t.Format(layout)), nil
+}
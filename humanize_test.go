@@ -0,0 +1,51 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestHumanizeFilters(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		tpl  string
+		want string
+	}{
+		{`{{ 1|ordinal }} {{ 2|ordinal }} {{ 3|ordinal }} {{ 4|ordinal }}`, "1st 2nd 3rd 4th"},
+		{`{{ 11|ordinal }} {{ 12|ordinal }} {{ 113|ordinal }} {{ 21|ordinal }}`, "11th 12th 113th 21st"},
+		{`{{ neg|ordinal }} {{ "22"|ordinal }} {{ "abc"|ordinal }}`, "-2nd 22nd abc"},
+		{`{{ 1|apnumber }} {{ 9|apnumber }} {{ 10|apnumber }} {{ "3"|apnumber }}`, "one nine 10 three"},
+		{`{{ now|naturalday }}`, "today"},
+		{`{{ yesterday|naturalday }}`, "yesterday"},
+		{`{{ tomorrow|naturalday }}`, "tomorrow"},
+		{`{{ old|naturalday }} {{ old|naturalday:"2006-01-02" }}`, "Jan 2, 2006 2006-01-02"},
+		{`{{ "not a time"|naturalday }}`, "not a time"},
+	}
+
+	ctx := M{
+		"now":       now,
+		"yesterday": now.AddDate(0, 0, -1),
+		"tomorrow":  &[]time.Time{now.AddDate(0, 0, 1)}[0],
+		"neg":       -2,
+		"old":       time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+	}
+
+	for _, tt := range tests {
+		ld := New("humanize", Config{FS: fstest.MapFS{"index.html": {Data: []byte(tt.tpl)}}})
+
+		out, err := ld.RenderBytes("index.html", ctx)
+		if err != nil {
+			t.Errorf("%s: %v", tt.tpl, err)
+			continue
+		}
+		if string(out) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.tpl, out, tt.want)
+		}
+	}
+}
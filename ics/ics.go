@@ -0,0 +1,217 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+// Package ics provides a minimal iCalendar (RFC 5545) builder and responder,
+// useful for "add to calendar" endpoints.
+package ics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ContentType is the Content-Type used when responding with a calendar.
+const ContentType = "text/calendar; charset=utf-8"
+
+const (
+	formatUTC  = "20060102T150405Z"
+	formatDate = "20060102"
+
+	// maxLineOctets is the maximum line length (excluding CRLF) before lines
+	// must be folded.
+	maxLineOctets = 75
+)
+
+// Calendar is an iCalendar object, containing one or more events.
+type Calendar struct {
+	// ProdID is the identifier of the product which created the calendar.
+	// Defaults to "-//lrstanley//pt//EN".
+	ProdID string
+	// Name is the optional display name of the calendar.
+	Name string
+	// Method is the optional iTIP method (e.g. "PUBLISH" or "REQUEST").
+	Method string
+	Events []*Event
+}
+
+// Event is a single calendar event (VEVENT).
+type Event struct {
+	// UID is the globally unique identifier of the event, and is required.
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Organizer   string
+	// Start and End are the start and end of the event. Times are always
+	// written in UTC form, as a TZID would require a matching VTIMEZONE
+	// definition, which calendar clients can't be relied on to provide.
+	Start time.Time
+	End   time.Time
+	// AllDay writes Start and End as dates, rather than date-times. End is
+	// exclusive, so a single day event should end on the following day.
+	AllDay bool
+	// Created is when the event was created. Defaults to the current time, and
+	// is used for DTSTAMP.
+	Created time.Time
+	// Status is the optional event status (e.g. "CONFIRMED" or "CANCELLED").
+	Status string
+}
+
+// WriteTo writes the calendar to w in iCalendar format.
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	prodID := c.ProdID
+	if prodID == "" {
+		prodID = "-//lrstanley//pt//EN"
+	}
+
+	writeLine(&buf, "BEGIN", "VCALENDAR")
+	writeLine(&buf, "VERSION", "2.0")
+	writeLine(&buf, "PRODID", escape(prodID))
+	writeLine(&buf, "CALSCALE", "GREGORIAN")
+
+	if c.Method != "" {
+		writeLine(&buf, "METHOD", c.Method)
+	}
+
+	if c.Name != "" {
+		writeLine(&buf, "X-WR-CALNAME", escape(c.Name))
+	}
+
+	for _, e := range c.Events {
+		if err := e.write(&buf); err != nil {
+			return 0, err
+		}
+	}
+
+	writeLine(&buf, "END", "VCALENDAR")
+
+	return buf.WriteTo(w)
+}
+
+func (e *Event) write(buf *bytes.Buffer) error {
+	if e.UID == "" {
+		return fmt.Errorf("ics: event %q is missing a UID", e.Summary)
+	}
+
+	if e.Start.IsZero() {
+		return fmt.Errorf("ics: event %q is missing a start time", e.UID)
+	}
+
+	created := e.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+
+	writeLine(buf, "BEGIN", "VEVENT")
+	writeLine(buf, "UID", escape(e.UID))
+	writeLine(buf, "DTSTAMP", created.UTC().Format(formatUTC))
+	writeTime(buf, "DTSTART", e.Start, e.AllDay)
+
+	if !e.End.IsZero() {
+		writeTime(buf, "DTEND", e.End, e.AllDay)
+	}
+
+	optional := []struct{ name, value string }{
+		{"SUMMARY", escape(e.Summary)},
+		{"DESCRIPTION", escape(e.Description)},
+		{"LOCATION", escape(e.Location)},
+		{"URL", e.URL},
+		{"STATUS", e.Status},
+	}
+
+	for _, prop := range optional {
+		if prop.value != "" {
+			writeLine(buf, prop.name, prop.value)
+		}
+	}
+
+	if e.Organizer != "" {
+		writeLine(buf, "ORGANIZER", "mailto:"+e.Organizer)
+	}
+
+	writeLine(buf, "END", "VEVENT")
+	return nil
+}
+
+// writeTime writes a date, or a date-time in UTC form.
+func writeTime(buf *bytes.Buffer, name string, t time.Time, allDay bool) {
+	if allDay {
+		writeLine(buf, name+";VALUE=DATE", t.Format(formatDate))
+		return
+	}
+
+	writeLine(buf, name, t.UTC().Format(formatUTC))
+}
+
+// writeLine writes a single content line, folding it at 75 octets, without
+// splitting multi-byte characters.
+func writeLine(buf *bytes.Buffer, name, value string) {
+	line := name + ":" + value
+	limit := maxLineOctets
+
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+
+		// Continuation lines include the leading space.
+		limit = maxLineOctets - 1
+	}
+
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// escape escapes TEXT property values.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// Respond writes the calendar to the client with the text/calendar
+// Content-Type. If filename is provided, the calendar is sent as an
+// attachment (e.g. "event.ics").
+func Respond(w http.ResponseWriter, r *http.Request, cal *Calendar, filename string) {
+	var buf bytes.Buffer
+
+	if _, err := cal.WriteTo(&buf); err != nil {
+		panic(err)
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+
+	if filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	_, _ = buf.WriteTo(w)
+}
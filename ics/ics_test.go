@@ -0,0 +1,97 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package ics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventTimesUTC(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	cal := &Calendar{Events: []*Event{{
+		UID:     "1@example.com",
+		Start:   time.Date(2024, 7, 1, 10, 0, 0, 0, berlin),
+		End:     time.Date(2024, 7, 1, 11, 30, 0, 0, berlin),
+		Created: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}}
+
+	var buf bytes.Buffer
+	if _, err = cal.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "TZID") {
+		t.Errorf("output contains TZID without a VTIMEZONE:\n%s", out)
+	}
+
+	for _, want := range []string{"DTSTART:20240701T080000Z\r\n", "DTEND:20240701T093000Z\r\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestEventAllDay(t *testing.T) {
+	cal := &Calendar{Events: []*Event{{
+		UID:    "1@example.com",
+		Start:  time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		End:    time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC),
+		AllDay: true,
+	}}}
+
+	var buf bytes.Buffer
+	if _, err := cal.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"DTSTART;VALUE=DATE:20240701\r\n", "DTEND;VALUE=DATE:20240702\r\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRespondFilename(t *testing.T) {
+	cal := &Calendar{Events: []*Event{{UID: "1@example.com", Start: time.Now()}}}
+
+	tests := map[string]string{
+		"event.ics":         `attachment; filename=event.ics`,
+		`my "event".ics`:    `attachment; filename="my \"event\".ics"`,
+		"événement été.ics": `attachment; filename*=utf-8''%C3%A9v%C3%A9nement%20%C3%A9t%C3%A9.ics`,
+	}
+
+	for filename, want := range tests {
+		w := httptest.NewRecorder()
+		Respond(w, httptest.NewRequest(http.MethodGet, "/", nil), cal, filename)
+
+		if got := w.Header().Get("Content-Disposition"); got != want {
+			t.Errorf("Content-Disposition for %q = %s, want %s", filename, got, want)
+		}
+	}
+}
+
+func TestWriteLineFolding(t *testing.T) {
+	var buf bytes.Buffer
+	writeLine(&buf, "DESCRIPTION", strings.Repeat("é", 100))
+
+	for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line %d is %d octets", i, len(line))
+		}
+		if i > 0 && !strings.HasPrefix(line, " ") {
+			t.Errorf("continuation line %d doesn't start with a space", i)
+		}
+	}
+}
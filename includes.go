@@ -0,0 +1,75 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkIncludes walks the templates statically referenced by path (includes,
+// extends and imports), returning an error naming the chain of templates if
+// there is a cycle, or the chain is deeper than Config.MaxIncludeDepth. pongo2
+// parses referenced templates recursively, so a cycle would otherwise
+// overflow the stack, crashing the process.
+//
+// Templates which can't be read are skipped, so pongo2 can report the error
+// when parsing.
+func (ld *Loader) checkIncludes(conf *Config, path string) error {
+	var refs []string
+	if strings.HasSuffix(path, layoutSuffix) {
+		path = strings.TrimSuffix(path, layoutSuffix)
+		refs = append(refs, conf.DefaultLayout)
+	}
+
+	// The depth at which each template has been checked, so shared templates
+	// are only walked again if they are reached with a shorter chain.
+	checked := make(map[string]int)
+	chain := []string{path}
+
+	var walk func(path string, refs []string) error
+	walk = func(path string, refs []string) error {
+		src, err := ld.source(path)
+		if err != nil {
+			return nil
+		}
+
+		_, srcRefs := auditSource(path, src, true)
+		for _, ref := range srcRefs {
+			refs = append(refs, ld.loader.Abs(path, ref))
+		}
+
+		for _, ref := range refs {
+			for _, p := range chain {
+				if p == ref {
+					return fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(append(chain, ref), " -> "))
+				}
+			}
+
+			if depth, ok := checked[ref]; ok && depth <= len(chain) {
+				continue
+			}
+
+			if len(chain) >= conf.MaxIncludeDepth {
+				return fmt.Errorf(
+					"%w: maximum depth of %d exceeded: %s",
+					ErrIncludeCycle, conf.MaxIncludeDepth, strings.Join(append(chain, ref), " -> "),
+				)
+			}
+
+			chain = append(chain, ref)
+			err = walk(ref, nil)
+			chain = chain[:len(chain)-1]
+
+			if err != nil {
+				return err
+			}
+			checked[ref] = len(chain)
+		}
+		return nil
+	}
+
+	return walk(path, refs)
+}
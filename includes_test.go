@@ -0,0 +1,62 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIncludeCycle(t *testing.T) {
+	ld := New("includes", Config{
+		FS: fstest.MapFS{
+			"a.html":       {Data: []byte(`{% include "b.html" %}`)},
+			"b.html":       {Data: []byte(`{% extends "c.html" %}`)},
+			"c.html":       {Data: []byte(`{% include "a.html" %}`)},
+			"self.html":    {Data: []byte(`{% include "self.html" %}`)},
+			"diamond.html": {Data: []byte(`{% include "left.html" %}{% include "right.html" %}`)},
+			"left.html":    {Data: []byte(`{% include "shared.html" %}`)},
+			"right.html":   {Data: []byte(`{% include "shared.html" %}`)},
+			"shared.html":  {Data: []byte(`shared`)},
+		},
+	})
+
+	_, err := ld.RenderBytes("a.html", nil)
+	if !errors.Is(err, ErrIncludeCycle) {
+		t.Fatalf("RenderBytes(a.html) = %v, want ErrIncludeCycle", err)
+	}
+	if !strings.Contains(err.Error(), "a.html -> b.html -> c.html -> a.html") {
+		t.Errorf("error doesn't contain the chain: %v", err)
+	}
+
+	if _, err = ld.RenderBytes("self.html", nil); !errors.Is(err, ErrIncludeCycle) {
+		t.Errorf("RenderBytes(self.html) = %v, want ErrIncludeCycle", err)
+	}
+
+	if out, err := ld.RenderBytes("diamond.html", nil); err != nil || string(out) != "sharedshared" {
+		t.Errorf("RenderBytes(diamond.html) = %q, %v", out, err)
+	}
+}
+
+func TestMaxIncludeDepth(t *testing.T) {
+	ld := New("include-depth", Config{
+		FS: fstest.MapFS{
+			"1.html": {Data: []byte(`{% include "2.html" %}`)},
+			"2.html": {Data: []byte(`{% include "3.html" %}`)},
+			"3.html": {Data: []byte(`{% include "4.html" %}`)},
+			"4.html": {Data: []byte(`deep`)},
+		},
+		MaxIncludeDepth: 3,
+	})
+
+	if _, err := ld.RenderBytes("1.html", nil); !errors.Is(err, ErrIncludeCycle) {
+		t.Errorf("RenderBytes(1.html) = %v, want ErrIncludeCycle", err)
+	}
+	if out, err := ld.RenderBytes("2.html", nil); err != nil || string(out) != "deep" {
+		t.Errorf("RenderBytes(2.html) = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"encoding/base64"
+	"html"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// staticFS returns the filesystem static assets are read from, see
+// Config.StaticFS.
+func (c *Config) staticFS() fs.FS {
+	if c.StaticFS != nil {
+		return c.StaticFS
+	}
+
+	if c.Assets != nil {
+		return c.Assets.fs
+	}
+	return nil
+}
+
+// InlineAsset returns the static asset as a base64 data URI, for embedding
+// small files (e.g. icons) directly within the page. Assets are read from
+// Config.StaticFS (or the filesystem of Config.Assets), and must be at most
+// Config.InlineAssetMaxSize bytes. Returns false (and logs a warning) if the
+// asset can't be inlined, see the "inline_asset" tag.
+func (ld *Loader) InlineAsset(name string) (string, bool) {
+	return inlineAsset(ld.conf(), name)
+}
+
+// inlineAsset is Loader.InlineAsset(), using the provided config.
+func inlineAsset(conf *Config, name string) (string, bool) {
+	fsys := conf.staticFS()
+	if fsys == nil {
+		conf.logf(LevelWarn, "inline asset: %s: no Config.StaticFS provided", name)
+		return "", false
+	}
+
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		conf.logf(LevelWarn, "inline asset: %v", err)
+		return "", false
+	}
+
+	if len(data) > conf.InlineAssetMaxSize {
+		conf.logf(
+			LevelWarn, "inline asset: %s: size of %d bytes exceeds maximum of %d bytes",
+			name, len(data), conf.InlineAssetMaxSize,
+		)
+		return "", false
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data), true
+}
+
+type tagInlineAssetNode struct {
+	path pongo2.IEvaluator
+}
+
+func (node *tagInlineAssetNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	path, err := node.path.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	state := stateFromCtx(ctx)
+	if state == nil {
+		return ctx.Error("inline_asset tag used outside of a pt.Loader render", nil)
+	}
+
+	if uri, ok := inlineAsset(state.conf, path.String()); ok {
+		_, _ = writer.WriteString(uri)
+		return nil
+	}
+
+	_, _ = writer.WriteString(html.EscapeString(staticURL(state.conf, path.String())))
+	return nil
+}
+
+// tagInlineAssetParser parses the "inline_asset" tag, which outputs a static
+// asset as a data URI (see Loader.InlineAsset()), reducing the number of
+// requests for small assets, and allowing images within emails without
+// remote content. Assets which can't be inlined (e.g. they're too large) fall
+// back to their URL, as with the "static" tag. For example:
+//
+//	<img src="{% inline_asset "img/logo.png" %}" alt="logo">
+func tagInlineAssetParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	path, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed inline_asset-tag arguments.", nil)
+	}
+
+	return &tagInlineAssetNode{path: path}, nil
+}
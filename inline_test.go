@@ -0,0 +1,52 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+)
+
+func TestInlineAsset(t *testing.T) {
+	var logs bytes.Buffer
+
+	ld := New("inline", Config{
+		FS: fstest.MapFS{"index.html": {Data: []byte(`{% inline_asset path %}`)}},
+		StaticFS: fstest.MapFS{
+			"img/dot.svg":   {Data: []byte(`<svg/>`)},
+			"img/large.png": {Data: bytes.Repeat([]byte{0}, 32)},
+			"LICENSE":       {Data: []byte("plain text")},
+		},
+		StaticBaseURL:      "/static",
+		InlineAssetMaxSize: 16,
+		ErrorLogger:        &logs,
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/img/dot.svg", "data:image/svg+xml;base64,PHN2Zy8+"},
+		{"LICENSE", "data:text/plain; charset=utf-8;base64,cGxhaW4gdGV4dA=="},
+		{"img/large.png", "/static/img/large.png"},
+		{`missing.png"><x`, "/static/missing.png&#34;&gt;&lt;x"},
+	}
+
+	for _, tt := range tests {
+		out, err := ld.RenderBytes("index.html", M{"path": tt.path})
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		if string(out) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.path, out, tt.want)
+		}
+	}
+
+	if !bytes.Contains(logs.Bytes(), []byte("exceeds maximum of 16 bytes")) {
+		t.Errorf("expected a warning for the large asset, got %q", logs.String())
+	}
+}
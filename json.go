@@ -0,0 +1,277 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// JSONOptions are the options passed to a JSONEncoder.
+type JSONOptions struct {
+	// Indent is the indentation used for each level. Output is compact if
+	// empty.
+	Indent string
+	// EscapeHTML escapes "<", ">" and "&" within strings, which is required
+	// when embedding JSON within HTML (e.g. "<script>" tags).
+	EscapeHTML bool
+	// SortKeys sorts the keys of all objects, including those generated from
+	// structs (map keys are always sorted by encoding/json).
+	SortKeys bool
+}
+
+// JSONEncoder is the encoder backend used by JSON() and the "json" filter.
+// This allows replacing encoding/json with a faster (compatible)
+// implementation.
+type JSONEncoder interface {
+	Encode(w io.Writer, v interface{}, opts JSONOptions) error
+}
+
+// DefaultJSONEncoder is the encoder backend used by JSON() and the "json"
+// filter, which uses encoding/json by default.
+var DefaultJSONEncoder JSONEncoder = stdJSONEncoder{}
+
+type stdJSONEncoder struct{}
+
+func (stdJSONEncoder) Encode(w io.Writer, v interface{}, opts JSONOptions) error {
+	if opts.SortKeys {
+		sorted, err := sortJSONKeys(v)
+		if err != nil {
+			return err
+		}
+		v = sorted
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(opts.EscapeHTML)
+
+	if opts.Indent != "" {
+		enc.SetIndent("", opts.Indent)
+	}
+
+	return enc.Encode(v)
+}
+
+// sortJSONKeys round-trips v through encoding/json into generic maps, which
+// encoding/json always encodes with sorted keys.
+func sortJSONKeys(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var out interface{}
+	err = dec.Decode(&out)
+	return out, err
+}
+
+// appendFlatJSON appends the JSON encoding of v to dst (with a trailing
+// newline, as with json.Encoder), if v is a small, flat value: a map or slice
+// of strings, bools, integers and nil values. This avoids the reflection and
+// buffering of encoding/json for common payloads, like health and status
+// responses. Returns false if v isn't supported, in which case dst is
+// returned unchanged. Output matches encoding/json.
+func appendFlatJSON(dst []byte, v interface{}, escapeHTML bool) ([]byte, bool) {
+	start := len(dst)
+
+	var values []interface{}
+
+	switch v := v.(type) {
+	case map[string]string:
+		if v == nil {
+			return dst, false
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		dst = append(dst, '{')
+		for i, key := range keys {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, key, escapeHTML)
+			dst = append(dst, ':')
+			dst = appendJSONString(dst, v[key], escapeHTML)
+		}
+		return append(dst, '}', '\n'), true
+	case M:
+		return appendFlatJSONMap(dst, v, escapeHTML)
+	case map[string]interface{}:
+		return appendFlatJSONMap(dst, v, escapeHTML)
+	case []string:
+		if v == nil {
+			return dst, false
+		}
+
+		dst = append(dst, '[')
+		for i, s := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, s, escapeHTML)
+		}
+		return append(dst, ']', '\n'), true
+	case []interface{}:
+		values = v
+	default:
+		return dst, false
+	}
+
+	if values == nil {
+		return dst, false
+	}
+
+	dst = append(dst, '[')
+	for i, e := range values {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+
+		var ok bool
+		if dst, ok = appendJSONScalar(dst, e, escapeHTML); !ok {
+			return dst[:start], false
+		}
+	}
+	return append(dst, ']', '\n'), true
+}
+
+func appendFlatJSONMap(dst []byte, v map[string]interface{}, escapeHTML bool) ([]byte, bool) {
+	if v == nil {
+		return dst, false
+	}
+
+	start := len(dst)
+
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	dst = append(dst, '{')
+	for i, key := range keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, key, escapeHTML)
+		dst = append(dst, ':')
+
+		var ok bool
+		if dst, ok = appendJSONScalar(dst, v[key], escapeHTML); !ok {
+			return dst[:start], false
+		}
+	}
+	return append(dst, '}', '\n'), true
+}
+
+// appendJSONScalar appends the JSON encoding of a primitive value, returning
+// false if the value isn't supported.
+func appendJSONScalar(dst []byte, v interface{}, escapeHTML bool) ([]byte, bool) {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, "null"...), true
+	case string:
+		return appendJSONString(dst, v, escapeHTML), true
+	case bool:
+		return strconv.AppendBool(dst, v), true
+	case int:
+		return strconv.AppendInt(dst, int64(v), 10), true
+	case int8:
+		return strconv.AppendInt(dst, int64(v), 10), true
+	case int16:
+		return strconv.AppendInt(dst, int64(v), 10), true
+	case int32:
+		return strconv.AppendInt(dst, int64(v), 10), true
+	case int64:
+		return strconv.AppendInt(dst, v, 10), true
+	case uint:
+		return strconv.AppendUint(dst, uint64(v), 10), true
+	case uint8:
+		return strconv.AppendUint(dst, uint64(v), 10), true
+	case uint16:
+		return strconv.AppendUint(dst, uint64(v), 10), true
+	case uint32:
+		return strconv.AppendUint(dst, uint64(v), 10), true
+	case uint64:
+		return strconv.AppendUint(dst, v, 10), true
+	default:
+		return dst, false
+	}
+}
+
+const hexChars = "0123456789abcdef"
+
+// appendJSONString appends the quoted JSON string, escaped in the same way as
+// encoding/json.
+func appendJSONString(dst []byte, s string, escapeHTML bool) []byte {
+	dst = append(dst, '"')
+
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && (!escapeHTML || (b != '<' && b != '>' && b != '&')) {
+				i++
+				continue
+			}
+
+			dst = append(dst, s[start:i]...)
+
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexChars[b>>4], hexChars[b&0xF])
+			}
+
+			i++
+			start = i
+			continue
+		}
+
+		c, size := utf8.DecodeRuneInString(s[i:])
+
+		if c == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+
+		if c == '\u2028' || c == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexChars[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+
+		i += size
+	}
+
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// stdJSON returns the output of encoding/json for v. encoding/json before Go
+// 1.22 escapes "\b" and "\f" as "\u0008" and "\u000c", which are equivalent.
+func stdJSON(t testing.TB, v interface{}, escapeHTML bool) string {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if err := enc.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.NewReplacer(`\u0008`, `\b`, `\u000c`, `\f`).Replace(buf.String())
+}
+
+func TestAppendFlatJSONMatchesEncodingJSON(t *testing.T) {
+	var control strings.Builder
+	for b := 0; b < 0x20; b++ {
+		control.WriteByte(byte(b))
+	}
+
+	strs := []string{
+		"",
+		"plain",
+		control.String(),
+		"\x7f",
+		`quote " backslash \ slash /`,
+		"<script>alert('x') && 1</script>",
+		"line\u2028separator\u2029paragraph",
+		"invalid \xff utf-8 \xe2\x82",
+		"unicode é 日本 🎉",
+	}
+
+	values := []interface{}{
+		map[string]string{},
+		[]string{},
+		[]interface{}{},
+		M{},
+		strs,
+		[]interface{}{nil, true, false, 0, -1, int8(-8), int16(16), int32(-32), int64(1 << 62), uint(1), uint8(8), uint16(16), uint32(32), uint64(1 << 63)},
+	}
+
+	for _, s := range strs {
+		values = append(values,
+			map[string]string{s: s, "k": s},
+			M{s: s, "n": 1, "b": true, "nil": nil},
+			map[string]interface{}{s: s},
+			[]interface{}{s, s},
+		)
+	}
+
+	for _, v := range values {
+		for _, escapeHTML := range []bool{true, false} {
+			out, ok := appendFlatJSON(nil, v, escapeHTML)
+			if !ok {
+				t.Errorf("appendFlatJSON(%#v) not supported", v)
+				continue
+			}
+
+			if want := stdJSON(t, v, escapeHTML); string(out) != want {
+				t.Errorf("appendFlatJSON(%#v, %v):\n got: %s\nwant: %s", v, escapeHTML, out, want)
+			}
+		}
+	}
+}
+
+func TestAppendFlatJSONUnsupported(t *testing.T) {
+	values := []interface{}{
+		nil,
+		map[string]string(nil),
+		[]string(nil),
+		[]interface{}(nil),
+		M(nil),
+		1.5,
+		[]interface{}{1.5},
+		M{"nested": M{}},
+		struct{}{},
+	}
+
+	for _, v := range values {
+		dst := []byte("prefix")
+		if out, ok := appendFlatJSON(dst, v, true); ok || string(out) != "prefix" {
+			t.Errorf("appendFlatJSON(%#v) = %q, %v, want unchanged, false", v, out, ok)
+		}
+	}
+}
+
+func TestJSONFastPath(t *testing.T) {
+	v := M{"status": "ok", "html": "<b>"}
+
+	w := httptest.NewRecorder()
+	JSON(w, httptest.NewRequest(http.MethodGet, "/", nil), v)
+
+	if want := stdJSON(t, v, true); w.Body.String() != want {
+		t.Errorf("JSON() = %s, want %s", w.Body.String(), want)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+var benchJSONValue = M{
+	"status":  "ok",
+	"version": "v1.2.3",
+	"uptime":  123456,
+	"healthy": true,
+	"region":  "us-east-1",
+	"error":   nil,
+}
+
+func BenchmarkJSONFlat(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = appendFlatJSON(make([]byte, 0, 128), benchJSONValue, true)
+	}
+}
+
+func BenchmarkJSONEncodingJSON(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := &bytes.Buffer{}
+		_ = stdJSONEncoder{}.Encode(buf, benchJSONValue, JSONOptions{EscapeHTML: true})
+	}
+}
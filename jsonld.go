@@ -0,0 +1,246 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// schemaContext is the JSON-LD context of schema.org entities.
+const schemaContext = "https://schema.org"
+
+// ldObject is a JSON-LD object, which omits empty values.
+type ldObject map[string]interface{}
+
+func newLDObject(typ string) ldObject {
+	return ldObject{"@type": typ}
+}
+
+func (o ldObject) set(key string, value interface{}) ldObject {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return o
+		}
+	case []string:
+		if len(v) == 0 {
+			return o
+		}
+	case time.Time:
+		if v.IsZero() {
+			return o
+		}
+		value = v.Format(time.RFC3339)
+	default:
+		if rv := reflect.ValueOf(value); !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+			return o
+		}
+	}
+
+	o[key] = value
+	return o
+}
+
+// Organization is the schema.org Organization entity.
+type Organization struct {
+	Name   string
+	URL    string
+	Logo   string
+	SameAs []string // e.g. social profile URLs.
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o Organization) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newLDObject("Organization").
+		set("name", o.Name).
+		set("url", o.URL).
+		set("logo", o.Logo).
+		set("sameAs", o.SameAs))
+}
+
+// Person is the schema.org Person entity.
+type Person struct {
+	Name string
+	URL  string
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p Person) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newLDObject("Person").set("name", p.Name).set("url", p.URL))
+}
+
+// Article is the schema.org Article entity.
+type Article struct {
+	Headline      string
+	Description   string
+	URL           string
+	Image         []string
+	DatePublished time.Time
+	DateModified  time.Time
+	Authors       []*Person
+	Publisher     *Organization
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a Article) MarshalJSON() ([]byte, error) {
+	o := newLDObject("Article").
+		set("headline", a.Headline).
+		set("description", a.Description).
+		set("mainEntityOfPage", a.URL).
+		set("image", a.Image).
+		set("datePublished", a.DatePublished).
+		set("dateModified", a.DateModified).
+		set("publisher", a.Publisher)
+
+	if len(a.Authors) > 0 {
+		o.set("author", a.Authors)
+	}
+	return json.Marshal(o)
+}
+
+// Offer is the schema.org Offer entity, used by Product.
+type Offer struct {
+	Price         string
+	PriceCurrency string // ISO 4217 code, e.g. "USD".
+	Availability  string // e.g. "https://schema.org/InStock".
+	URL           string
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o Offer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newLDObject("Offer").
+		set("price", o.Price).
+		set("priceCurrency", o.PriceCurrency).
+		set("availability", o.Availability).
+		set("url", o.URL))
+}
+
+// Product is the schema.org Product entity.
+type Product struct {
+	Name        string
+	Description string
+	Image       []string
+	SKU         string
+	Brand       string
+	Offers      *Offer
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p Product) MarshalJSON() ([]byte, error) {
+	o := newLDObject("Product").
+		set("name", p.Name).
+		set("description", p.Description).
+		set("image", p.Image).
+		set("sku", p.SKU).
+		set("offers", p.Offers)
+
+	if p.Brand != "" {
+		o.set("brand", newLDObject("Brand").set("name", p.Brand))
+	}
+	return json.Marshal(o)
+}
+
+// Breadcrumb is a single item within a BreadcrumbList.
+type Breadcrumb struct {
+	Name string
+	URL  string
+}
+
+// BreadcrumbList is the schema.org BreadcrumbList entity, with items in order
+// (e.g. starting with the home page).
+type BreadcrumbList []Breadcrumb
+
+// MarshalJSON implements json.Marshaler.
+func (b BreadcrumbList) MarshalJSON() ([]byte, error) {
+	items := make([]ldObject, len(b))
+	for i, crumb := range b {
+		items[i] = newLDObject("ListItem").
+			set("position", i+1).
+			set("name", crumb.Name).
+			set("item", crumb.URL)
+	}
+
+	return json.Marshal(newLDObject("BreadcrumbList").set("itemListElement", items))
+}
+
+// JSONLD returns the JSON-LD encoding of v (e.g. an *Article, or any value
+// which encodes to a JSON object), adding the schema.org "@context" if not
+// already provided. Slices of entities are encoded as a "@graph". The output
+// is HTML escaped, so it can be embedded within a "<script>" tag.
+func JSONLD(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out interface{}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		out = map[string]interface{}{"@context": schemaContext, "@graph": json.RawMessage(data)}
+	case bytes.HasPrefix(data, []byte("{")):
+		var obj map[string]json.RawMessage
+		if err = json.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+
+		if _, ok := obj["@context"]; !ok {
+			obj["@context"] = json.RawMessage(`"` + schemaContext + `"`)
+		}
+		out = obj
+	default:
+		out = json.RawMessage(data)
+	}
+
+	var b bytes.Buffer
+	if err = DefaultJSONEncoder.Encode(&b, out, JSONOptions{EscapeHTML: true}); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSpace(b.Bytes()), nil
+}
+
+type tagJSONLDNode struct {
+	value pongo2.IEvaluator
+}
+
+func (node *tagJSONLDNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	value, err := node.value.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	out, eerr := JSONLD(value.Interface())
+	if eerr != nil {
+		return ctx.OrigError(eerr, nil)
+	}
+
+	_, _ = writer.WriteString(`<script type="application/ld+json">`)
+	_, _ = writer.Write(out)
+	_, _ = writer.WriteString(`</script>`)
+	return nil
+}
+
+// tagJSONLDParser parses the "jsonld" tag, which outputs a JSON-LD structured
+// data block for the provided value (see JSONLD()). For example:
+//
+//	{% jsonld article %}
+func tagJSONLDParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	value, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed jsonld-tag arguments.", nil)
+	}
+
+	return &tagJSONLDNode{value: value}, nil
+}
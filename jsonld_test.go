@@ -0,0 +1,74 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestJSONLD(t *testing.T) {
+	published := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			name: "article",
+			v: &Article{
+				Headline:      "Hello </script>",
+				DatePublished: published,
+				Authors:       []*Person{{Name: "Jane"}},
+			},
+			want: `{"@context":"https://schema.org","@type":"Article","author":[{"@type":"Person","name":"Jane"}],` +
+				`"datePublished":"2024-01-02T03:04:05Z","headline":"Hello \u003c/script\u003e"}`,
+		},
+		{
+			name: "product",
+			v:    Product{Name: "Widget", Brand: "Acme", Offers: &Offer{Price: "9.99", PriceCurrency: "USD"}},
+			want: `{"@context":"https://schema.org","@type":"Product","brand":{"@type":"Brand","name":"Acme"},` +
+				`"name":"Widget","offers":{"@type":"Offer","price":"9.99","priceCurrency":"USD"}}`,
+		},
+		{
+			name: "graph",
+			v:    []interface{}{Organization{Name: "Acme"}, BreadcrumbList{{Name: "Home", URL: "/"}}},
+			want: `{"@context":"https://schema.org","@graph":[{"@type":"Organization","name":"Acme"},` +
+				`{"@type":"BreadcrumbList","itemListElement":[{"@type":"ListItem","item":"/","name":"Home","position":1}]}]}`,
+		},
+		{
+			name: "context",
+			v:    M{"@context": "https://example.com", "@type": "Thing"},
+			want: `{"@context":"https://example.com","@type":"Thing"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := JSONLD(tt.v)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if string(out) != tt.want {
+			t.Errorf("%s:\n got: %s\nwant: %s", tt.name, out, tt.want)
+		}
+	}
+}
+
+func TestTagJSONLD(t *testing.T) {
+	ld := New("jsonld", Config{FS: fstest.MapFS{"index.html": {Data: []byte(`{% jsonld person %}`)}}})
+
+	out, err := ld.RenderBytes("index.html", M{"person": Person{Name: "Jane", URL: "https://example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<script type="application/ld+json">{"@context":"https://schema.org","@type":"Person","name":"Jane","url":"https://example.com"}</script>`
+	if string(out) != want {
+		t.Errorf("output = %s, want %s", out, want)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// layoutSuffix is appended to template paths which should be wrapped in
+// Config.DefaultLayout. Only the root template of a render is loaded with the
+// suffix, so includes, imports and the layout itself are left as-is. As
+// pongo2 resolves relative paths using the directory of the template, the
+// suffix doesn't affect path resolution.
+const layoutSuffix = "#layout"
+
+// layoutPath returns the path used to load the root template of a render,
+// taking into account Config.DefaultLayout.
+func layoutPath(conf *Config, path string) string {
+	if conf.DefaultLayout == "" || path == conf.DefaultLayout {
+		return path
+	}
+	return path + layoutSuffix
+}
+
+// layoutLoader wraps a template loader, wrapping templates loaded with the
+// layoutSuffix in Config.DefaultLayout, unless they already extend another
+// template. It also applies the autoescaping policy of
+// Config.NoAutoescapePaths, and instruments raw-HTML sinks when
+// Config.AuditSafe is enabled. Like setAutoescape(), the layout tags are
+// inserted without newlines, so template line numbers are preserved.
+type layoutLoader struct {
+	pongo2.TemplateLoader
+	ld *Loader
+}
+
+func (l layoutLoader) Get(path string) (io.Reader, error) {
+	conf := l.ld.conf()
+
+	wrap := strings.HasSuffix(path, layoutSuffix)
+	path = strings.TrimSuffix(path, layoutSuffix)
+	escapePolicy := len(conf.NoAutoescapePaths) > 0
+
+	if !wrap && !escapePolicy && !conf.AuditSafe {
+		return l.TemplateLoader.Get(path)
+	}
+
+	rd, err := l.TemplateLoader.Get(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := rd.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	src, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.AuditSafe {
+		src = instrumentSinks(path, src, !conf.noAutoescape(path))
+	}
+
+	if escapePolicy {
+		src = setAutoescape(src, !conf.noAutoescape(path))
+	}
+
+	if !wrap || conf.DefaultLayout == "" || reExtends.Match(src) {
+		return bytes.NewReader(src), nil
+	}
+
+	// Extends are resolved relative to the directory of the template.
+	layout, err := filepath.Rel(filepath.Dir(path), conf.DefaultLayout)
+	if err != nil {
+		layout = conf.DefaultLayout
+	}
+
+	extends := "{% extends " + strconv.Quote(filepath.ToSlash(layout)) + " %}"
+
+	// Templates which define the layout block themselves only need to extend
+	// the layout.
+	if hasBlock(src, conf.DefaultLayoutBlock) {
+		return io.MultiReader(strings.NewReader(extends), bytes.NewReader(src)), nil
+	}
+
+	return io.MultiReader(
+		strings.NewReader(extends+"{% block "+conf.DefaultLayoutBlock+" %}"),
+		bytes.NewReader(src),
+		strings.NewReader("{% endblock %}"),
+	), nil
+}
+
+// hasBlock checks if the template source defines the provided block.
+func hasBlock(src []byte, name string) bool {
+	for _, m := range reBlockStart.FindAllSubmatch(src, -1) {
+		if string(m[1]) == name {
+			return true
+		}
+	}
+	return false
+}
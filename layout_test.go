@@ -0,0 +1,69 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDefaultLayout(t *testing.T) {
+	ld := New("layout", Config{
+		FS: fstest.MapFS{
+			"layouts/base.html":  {Data: []byte(`<title>{% block title %}Site{% endblock %}</title><main>{% block content %}{% endblock %}</main>`)},
+			"layouts/plain.html": {Data: []byte(`[{% block content %}{% endblock %}]`)},
+			"index.html":         {Data: []byte(`home {{ name }}`)},
+			"docs/intro.html":    {Data: []byte(`{% block title %}Intro{% endblock %}{% block content %}intro{% endblock %}`)},
+			"print.html":         {Data: []byte(`{% extends "layouts/plain.html" %}{% block content %}print{% endblock %}`)},
+			"partial.html":       {Data: []byte(`{% include "nav.html" %}`)},
+			"nav.html":           {Data: []byte(`nav`)},
+		},
+		DefaultLayout: "layouts/base.html",
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"index.html", `<title>Site</title><main>home Jane</main>`},
+		{"docs/intro.html", `<title>Intro</title><main>intro</main>`},
+		{"print.html", `[print]`},
+		{"partial.html", `<title>Site</title><main>nav</main>`},
+		{"layouts/base.html", `<title>Site</title><main></main>`},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), tt.path, M{"name": "Jane"})
+
+		if rec.Body.String() != tt.want {
+			t.Errorf("%s = %q, want %q", tt.path, rec.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestDefaultLayoutBlock(t *testing.T) {
+	ld := New("layout-block", Config{
+		FS: fstest.MapFS{
+			"base.html":  {Data: []byte(`<body>{% block main %}{% endblock %}</body>`)},
+			"index.html": {Data: []byte(`home`)},
+		},
+		DefaultLayout:      "base.html",
+		DefaultLayoutBlock: "main",
+	})
+
+	rec := httptest.NewRecorder()
+	ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "index.html", nil)
+	if want := "<body>home</body>"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+
+	// Renders outside of a request aren't wrapped.
+	if out, err := ld.RenderBytes("index.html", nil); err != nil || string(out) != "home" {
+		t.Errorf("RenderBytes() = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var reBodyEnd = regexp.MustCompile(`(?i)</body\s*>`)
+
+// liveReloadEnabled returns true if the live reload script should be injected
+// into the response.
+func liveReloadEnabled(conf *Config, w http.ResponseWriter) bool {
+	return conf.LiveReload && conf.Watch && strings.HasPrefix(w.Header().Get("Content-Type"), "text/html")
+}
+
+// injectLiveReload injects the live reload script at the end of "<body>". The
+// output is returned unchanged if it has no "<body>".
+func injectLiveReload(conf *Config, out []byte) []byte {
+	loc := reBodyEnd.FindIndex(out)
+	if loc == nil {
+		return out
+	}
+
+	script := `<script>new EventSource(` + strconv.Quote(conf.LiveReloadPath) +
+		`).addEventListener("reload",function(){location.reload()})</script>`
+
+	var buf bytes.Buffer
+	buf.Grow(len(out) + len(script))
+	buf.Write(out[:loc[0]])
+	buf.WriteString(script)
+	buf.Write(out[loc[0]:])
+
+	return buf.Bytes()
+}
+
+// notifyReload notifies all connected live reload clients that templates have
+// changed.
+func (ld *Loader) notifyReload() {
+	ld.reloadMu.Lock()
+	defer ld.reloadMu.Unlock()
+
+	for ch := range ld.reloadSubs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// LiveReloadHandler returns a http.HandlerFunc which streams server-sent
+// events to browsers, telling them to reload when templates change. It should
+// be mounted at Config.LiveReloadPath, and is only useful when
+// Config.LiveReload is enabled, which injects the script which connects to it.
+//
+// For example:
+//
+//	router.Get("/_pt/livereload", ld.LiveReloadHandler())
+func (ld *Loader) LiveReloadHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok || ld.watchStop == nil {
+			http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+			return
+		}
+
+		ch := make(chan struct{}, 1)
+
+		ld.reloadMu.Lock()
+		if ld.reloadSubs == nil {
+			ld.reloadSubs = make(map[chan struct{}]struct{})
+		}
+		ld.reloadSubs[ch] = struct{}{}
+		ld.reloadMu.Unlock()
+
+		defer func() {
+			ld.reloadMu.Lock()
+			delete(ld.reloadSubs, ch)
+			ld.reloadMu.Unlock()
+		}()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("retry: 1000\n\n"))
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ld.watchStop:
+				return
+			case <-ch:
+			}
+
+			if _, err := w.Write([]byte("event: reload\ndata: {}\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLiveReloadInjection(t *testing.T) {
+	ld := New("livereload", Config{
+		FS: fstest.MapFS{
+			"index.html":   {Data: []byte(`<html><body>page</BODY></html>`)},
+			"partial.html": {Data: []byte(`fragment`)},
+		},
+		Watch:         true,
+		LiveReload:    true,
+		WatchInterval: time.Hour,
+	})
+	defer ld.Close()
+
+	rec := httptest.NewRecorder()
+	ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "index.html", nil)
+
+	want := `<html><body>page<script>new EventSource("/_pt/livereload").addEventListener("reload",function(){location.reload()})</script></BODY></html>`
+	if rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+
+	rec = httptest.NewRecorder()
+	ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "partial.html", nil)
+	if rec.Body.String() != "fragment" {
+		t.Errorf("body = %q, want the unmodified fragment", rec.Body.String())
+	}
+}
+
+func TestLiveReloadHandler(t *testing.T) {
+	ld := New("livereload-handler", Config{
+		FS:            fstest.MapFS{"index.html": {Data: []byte(`page`)}},
+		Watch:         true,
+		LiveReload:    true,
+		WatchInterval: time.Hour,
+	})
+
+	srv := httptest.NewServer(ld.LiveReloadHandler())
+	defer srv.Close()
+	defer ld.Close() // Ends the stream, before the server is closed.
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	rd := bufio.NewReader(resp.Body)
+	if line, _ := rd.ReadString('\n'); line != "retry: 1000\n" {
+		t.Fatalf("first line = %q", line)
+	}
+
+	// The subscription is registered before the initial event is flushed.
+	ld.notifyReload()
+
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(line, "event: ") {
+			if line != "event: reload\n" {
+				t.Errorf("event = %q, want reload", line)
+			}
+			break
+		}
+	}
+}
+
+func TestLiveReloadHandlerWithoutWatch(t *testing.T) {
+	ld := New("livereload-disabled", Config{FS: fstest.MapFS{}})
+
+	rec := httptest.NewRecorder()
+	ld.LiveReloadHandler()(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("code = %d, want %d", rec.Code, http.StatusNotImplemented)
+	}
+}
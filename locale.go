@@ -0,0 +1,66 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// filterCountryName converts an ISO 3166-1 alpha-2 country code to its
+// English display name. Unknown codes are returned as-is. For example:
+//
+//	{{ "DE"|country_name }} -> "Germany"
+func filterCountryName(in, _ *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	if name, ok := countryNames[strings.ToUpper(strings.TrimSpace(in.String()))]; ok {
+		return pongo2.AsValue(name), nil
+	}
+	return in, nil
+}
+
+// filterLanguageName converts an ISO 639-1 language code, or a language tag
+// with a region (e.g. "en-US"), to its English display name. Unknown codes
+// are returned as-is. For example:
+//
+//	{{ "de"|language_name }} -> "German"
+//	{{ "pt-BR"|language_name }} -> "Portuguese (Brazil)"
+func filterLanguageName(in, _ *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	tag := strings.Split(strings.ReplaceAll(strings.TrimSpace(in.String()), "_", "-"), "-")
+
+	name, ok := languageNames[strings.ToLower(tag[0])]
+	if !ok {
+		return in, nil
+	}
+
+	if len(tag) > 1 {
+		if country, ok := countryNames[strings.ToUpper(tag[len(tag)-1])]; ok {
+			name += " (" + country + ")"
+		}
+	}
+
+	return pongo2.AsValue(name), nil
+}
+
+// filterFlag converts an ISO 3166-1 alpha-2 country code to its emoji flag.
+// Invalid codes result in an empty string. For example:
+//
+//	{{ "DE"|flag }} -> "🇩🇪"
+func filterFlag(in, _ *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	code := strings.ToUpper(strings.TrimSpace(in.String()))
+
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return pongo2.AsValue(""), nil
+	}
+
+	// Flags are made up of two regional indicator symbols, which start at
+	// U+1F1E6 for "A".
+	const regionalIndicatorA = 0x1F1E6
+
+	return pongo2.AsValue(string([]rune{
+		regionalIndicatorA + rune(code[0]-'A'),
+		regionalIndicatorA + rune(code[1]-'A'),
+	})), nil
+}
@@ -0,0 +1,36 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLocaleFilters(t *testing.T) {
+	tests := []struct {
+		tpl  string
+		want string
+	}{
+		{`{{ "DE"|country_name }} {{ " de "|country_name }} {{ "XX"|country_name }}`, "Germany Germany XX"},
+		{`{{ "de"|language_name }} {{ "pt-BR"|language_name }} {{ "zh_Hant_TW"|language_name }}`, "German Portuguese (Brazil) Chinese (Taiwan)"},
+		{`{{ "sr-Latn"|language_name }} {{ "xx-US"|language_name }}`, "Serbian xx-US"},
+		{`{{ "DE"|flag }}{{ "pt"|flag }}`, "\U0001F1E9\U0001F1EA\U0001F1F5\U0001F1F9"},
+		{`[{{ "D1"|flag }}{{ "DEU"|flag }}]`, "[]"},
+	}
+
+	for _, tt := range tests {
+		ld := New("locale", Config{FS: fstest.MapFS{"index.html": {Data: []byte(tt.tpl)}}})
+
+		out, err := ld.RenderBytes("index.html", nil)
+		if err != nil {
+			t.Errorf("%s: %v", tt.tpl, err)
+			continue
+		}
+		if string(out) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.tpl, out, tt.want)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+type localeKey struct{}
+
+// Locale returns the locale of the request, as set by LocalePrefix(), or
+// Config.DefaultLocale if the request didn't pass through it.
+func (ld *Loader) Locale(r *http.Request) string {
+	return requestLocale(ld.conf(), r)
+}
+
+// requestLocale is Loader.Locale(), using the provided config.
+func requestLocale(conf *Config, r *http.Request) string {
+	if r != nil {
+		if locale, ok := r.Context().Value(localeKey{}).(string); ok {
+			return locale
+		}
+	}
+	return conf.DefaultLocale
+}
+
+// supportedLocale returns the canonical form of the locale, if it is one of
+// Config.Locales.
+func supportedLocale(conf *Config, locale string) (string, bool) {
+	for _, l := range conf.Locales {
+		if strings.EqualFold(l, locale) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// LocalePath returns the path with the locale prefix added (e.g. "/pricing"
+// becomes "/de/pricing"). The path is returned unchanged for the default
+// locale, and for locales which aren't in Config.Locales.
+func (ld *Loader) LocalePath(locale, path string) string {
+	return localePath(ld.conf(), locale, path)
+}
+
+// localePath is Loader.LocalePath(), using the provided config.
+func localePath(conf *Config, locale, path string) string {
+	locale, ok := supportedLocale(conf, locale)
+	if !ok || locale == conf.DefaultLocale {
+		return path
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return "/" + locale + path
+}
+
+// LocalePrefix is a middleware which strips the locale prefix (one of
+// Config.Locales) from the request path, so routes only need to be registered
+// once, and stores the locale for Loader.Locale() and the "locale" ctx key.
+// Requests without a prefix use Config.DefaultLocale. Requests prefixed with
+// the default locale are permanently redirected to the unprefixed path, so
+// each page has a single URL.
+//
+// For example, with Locales set to []string{"en", "de"}:
+//
+//	/pricing    -> /pricing (locale "en")
+//	/de/pricing -> /pricing (locale "de")
+//	/en/pricing -> 301 to /pricing
+//
+//	r.Use(ld.LocalePrefix)
+func (ld *Loader) LocalePrefix(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conf := ld.conf()
+
+		if len(conf.Locales) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		segment := strings.TrimPrefix(r.URL.Path, "/")
+		if i := strings.IndexByte(segment, '/'); i >= 0 {
+			segment = segment[:i]
+		}
+
+		locale, ok := supportedLocale(conf, segment)
+		if !ok {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), localeKey{}, conf.DefaultLocale)))
+			return
+		}
+
+		rest := r.URL.Path[len(segment)+1:]
+		if rest == "" {
+			rest = "/"
+		}
+
+		if locale == conf.DefaultLocale {
+			// The target is cleaned, so paths like "/en//evil.example" can't
+			// redirect to another host. Backslashes are treated as slashes by
+			// browsers, so those are rejected.
+			target := path.Clean("/" + rest)
+			if strings.HasPrefix(target, "/\\") {
+				http.NotFound(w, r)
+				return
+			}
+			if strings.HasSuffix(rest, "/") && target != "/" {
+				target += "/"
+			}
+
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+
+		r2 := r.Clone(context.WithValue(r.Context(), localeKey{}, locale))
+		r2.URL.Path = rest
+		r2.URL.RawPath = ""
+
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// routePath returns the pattern of the named route, registered with
+// Loader.Routes().
+func (ld *Loader) routePath(name string) (string, bool) {
+	ld.routesMu.RLock()
+	defer ld.routesMu.RUnlock()
+
+	pattern, ok := ld.routes[name]
+	return pattern, ok
+}
+
+// fillPattern replaces the "{param}" placeholders of a route pattern with the
+// provided (escaped) values, in order.
+func fillPattern(pattern string, args []string) (string, error) {
+	var b strings.Builder
+
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			break
+		}
+
+		if len(args) == 0 {
+			return "", fmt.Errorf("missing value for %s", pattern[start:start+end+1])
+		}
+
+		b.WriteString(pattern[:start])
+		b.WriteString(url.PathEscape(args[0]))
+		pattern, args = pattern[start+end+1:], args[1:]
+	}
+
+	if len(args) > 0 {
+		return "", fmt.Errorf("too many values (%d unused)", len(args))
+	}
+
+	b.WriteString(pattern)
+	return b.String(), nil
+}
+
+type tagURLNode struct {
+	target pongo2.IEvaluator
+	args   []pongo2.IEvaluator
+	locale pongo2.IEvaluator
+}
+
+func (node *tagURLNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	state := stateFromCtx(ctx)
+	if state == nil {
+		return ctx.Error("url tag used outside of a pt.Loader render", nil)
+	}
+
+	target, err := node.target.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	path := target.String()
+	if !strings.HasPrefix(path, "/") {
+		pattern, ok := state.ld.routePath(path)
+		if !ok {
+			return ctx.Error(fmt.Sprintf("url tag: unknown route %q", path), nil)
+		}
+		path = pattern
+	}
+
+	args := make([]string, len(node.args))
+	for i, arg := range node.args {
+		v, err := arg.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		args[i] = v.String()
+	}
+
+	path, ferr := fillPattern(path, args)
+	if ferr != nil {
+		return ctx.Error(fmt.Sprintf("url tag: %s: %v", target.String(), ferr), nil)
+	}
+
+	locale := requestLocale(state.conf, state.r)
+	if node.locale != nil {
+		v, err := node.locale.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		locale = v.String()
+	}
+
+	_, _ = writer.WriteString(html.EscapeString(localePath(state.conf, locale, path)))
+	return nil
+}
+
+// tagURLParser parses the "url" tag, which outputs the path of a named route
+// (see Loader.Routes()) or a path starting with "/", prefixed with the locale
+// of the request (see Loader.LocalePrefix()). Route params (e.g. "{slug}")
+// are filled in order from the remaining arguments, and the locale can be
+// overridden (e.g. for language switchers). For example:
+//
+//	<a href="{% url "post" post.slug %}">...</a>
+//	<a href="{% url "/pricing" %}">...</a>
+//	<a href="{% url url.Path locale="de" %}">Deutsch</a>
+func tagURLParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	target, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	node := &tagURLNode{target: target}
+
+	for arguments.Remaining() > 0 {
+		if arguments.Peek(pongo2.TokenIdentifier, "locale") != nil && arguments.PeekN(1, pongo2.TokenSymbol, "=") != nil {
+			arguments.ConsumeN(2)
+
+			if node.locale, err = arguments.ParseExpression(); err != nil {
+				return nil, err
+			}
+
+			if arguments.Remaining() > 0 {
+				return nil, arguments.Error("Malformed url-tag arguments, locale must be last.", nil)
+			}
+			break
+		}
+
+		arg, err := arguments.ParseExpression()
+		if err != nil {
+			return nil, err
+		}
+		node.args = append(node.args, arg)
+	}
+
+	return node, nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLocalePrefix(t *testing.T) {
+	ld := New("locale-prefix", Config{FS: fstest.MapFS{}, Locales: []string{"en", "de"}})
+
+	var gotPath, gotLocale string
+	h := ld.LocalePrefix(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		gotPath, gotLocale = r.URL.Path, ld.Locale(r)
+	}))
+
+	tests := []struct {
+		target   string
+		path     string
+		locale   string
+		location string
+	}{
+		{target: "/pricing", path: "/pricing", locale: "en"},
+		{target: "/de/pricing", path: "/pricing", locale: "de"},
+		{target: "/DE", path: "/", locale: "de"},
+		{target: "/design", path: "/design", locale: "en"},
+		{target: "/en/pricing?plan=pro", location: "/pricing?plan=pro"},
+		{target: "/en/blog/", location: "/blog/"},
+		{target: "/en//evil.example/path", location: "/evil.example/path"},
+		{target: "/en/./..//evil.example", location: "/evil.example"},
+	}
+
+	for _, tt := range tests {
+		gotPath, gotLocale = "", ""
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if tt.location != "" {
+			if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != tt.location {
+				t.Errorf("%s: code = %d, location = %q, want redirect to %q", tt.target, rec.Code, rec.Header().Get("Location"), tt.location)
+			}
+			continue
+		}
+
+		if gotPath != tt.path || gotLocale != tt.locale {
+			t.Errorf("%s: path = %q, locale = %q, want %q, %q", tt.target, gotPath, gotLocale, tt.path, tt.locale)
+		}
+	}
+
+	// Browsers treat "/\" as "//", so it must not be redirected to.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/en/%5Cevil.example", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("backslash: code = %d, location = %q, want 404", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestLocalePath(t *testing.T) {
+	ld := New("locale-path", Config{FS: fstest.MapFS{}, Locales: []string{"en", "de"}})
+
+	for _, tt := range []struct{ locale, path, want string }{
+		{"de", "/pricing", "/de/pricing"},
+		{"DE", "pricing", "/de/pricing"},
+		{"en", "/pricing", "/pricing"},
+		{"fr", "/pricing", "/pricing"},
+	} {
+		if got := ld.LocalePath(tt.locale, tt.path); got != tt.want {
+			t.Errorf("LocalePath(%q, %q) = %q, want %q", tt.locale, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestTagURL(t *testing.T) {
+	ld := New("url-tag", Config{
+		FS: fstest.MapFS{
+			"index.html":   {Data: []byte(`{% url "post" slug %}|{% url "/pricing" %}|{% url url.Path locale="en" %}`)},
+			"unknown.html": {Data: []byte(`{% url "missing" %}`)},
+			"args.html":    {Data: []byte(`{% url "post" "a" "b" %}`)},
+		},
+		Locales: []string{"en", "de"},
+	})
+	ld.Routes(Route{Name: "post", Pattern: "/blog/{slug}", Template: "index.html"})
+
+	var body string
+	h := ld.LocalePrefix(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		ld.Render(rec, r, "index.html", M{"slug": "a b&c"})
+		body = rec.Body.String()
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/de/about", nil))
+
+	if want := "/de/blog/a%20b&amp;c|/de/pricing|/about"; body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+
+	for _, path := range []string{"unknown.html", "args.html"} {
+		if err := ld.RenderE(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), path, nil); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import "github.com/flosch/pongo2/v6"
+
+// asMap returns v as a map[string]interface{}, if it is a nested ctx map.
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case M:
+		return m, true
+	case pongo2.Context:
+		return m, true
+	default:
+		return nil, false
+	}
+}
+
+// deepMerge merges src into dst. Nested maps which exist in both are merged
+// recursively into a copy, so maps referenced by dst (e.g. shared maps
+// returned from DefaultCtx) are never modified. All other values in src
+// replace those in dst.
+func deepMerge(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcOK := asMap(value)
+		dstMap, dstOK := asMap(dst[key])
+
+		if !srcOK || !dstOK {
+			dst[key] = value
+			continue
+		}
+
+		merged := make(map[string]interface{}, len(dstMap)+len(srcMap))
+		for k, v := range dstMap {
+			merged[k] = v
+		}
+
+		deepMerge(merged, srcMap)
+		dst[key] = merged
+	}
+}
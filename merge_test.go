@@ -0,0 +1,62 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDeepMerge(t *testing.T) {
+	shared := M{"title": "Site", "og": map[string]interface{}{"type": "website", "site": "Example"}}
+	dst := map[string]interface{}{"meta": shared, "user": "alice"}
+
+	deepMerge(dst, map[string]interface{}{
+		"meta": M{"title": "Post", "og": M{"type": "article"}},
+		"user": M{"name": "bob"},
+	})
+
+	want := map[string]interface{}{
+		"meta": map[string]interface{}{
+			"title": "Post",
+			"og":    map[string]interface{}{"type": "article", "site": "Example"},
+		},
+		"user": M{"name": "bob"},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("deepMerge() = %#v, want %#v", dst, want)
+	}
+
+	if shared["title"] != "Site" || shared["og"].(map[string]interface{})["type"] != "website" {
+		t.Errorf("shared dst map was modified: %#v", shared)
+	}
+}
+
+func TestDeepMergeCtx(t *testing.T) {
+	fsys := fstest.MapFS{"index.html": {Data: []byte(`{{ meta.title }}/{{ meta.site|default:"-" }}`)}}
+	defaults := func(http.ResponseWriter, *http.Request) map[string]interface{} {
+		return M{"meta": M{"title": "Site", "site": "Example"}}
+	}
+
+	for _, tt := range []struct {
+		deep bool
+		want string
+	}{
+		{false, "Post/-"},
+		{true, "Post/Example"},
+	} {
+		ld := New("deep-merge", Config{FS: fsys, DefaultCtx: defaults, DeepMergeCtx: tt.deep})
+
+		rec := httptest.NewRecorder()
+		ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "index.html", M{"meta": M{"title": "Post"}})
+
+		if rec.Body.String() != tt.want {
+			t.Errorf("DeepMergeCtx=%v: body = %q, want %q", tt.deep, rec.Body.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RenderPart is a single template (or block within a template) rendered by
+// Loader.RenderMulti().
+type RenderPart struct {
+	// Path is the template path to render.
+	Path string
+	// Block is the optional name of a block within the template. If provided,
+	// only that block is rendered.
+	Block string
+	// Ctx is the context for this part, which is merged with the default
+	// context in the same way as Render().
+	Ctx map[string]interface{}
+}
+
+// RenderMulti renders multiple templates (or blocks) into a single response,
+// flushing the response after each part (if supported by the
+// http.ResponseWriter). This is useful for HTMX out-of-band swaps, or for
+// sending the start of a page before slower content is rendered.
+//
+// All templates are loaded before any output is written, so if any of them
+// can't be found, the NotFoundHandler is invoked as with Render().
+func (ld *Loader) RenderMulti(w http.ResponseWriter, r *http.Request, parts []RenderPart) {
+	conf := ld.conf()
+	flusher, _ := w.(http.Flusher)
+
+	jobs := make([]*renderJob, len(parts))
+	for i, part := range parts {
+		j, err := ld.prepare(w, r, conf, part.Path, part.Ctx)
+		if err != nil {
+			ld.handleErr(w, r, err)
+			return
+		}
+
+		if j == nil {
+			return
+		}
+		jobs[i] = j
+	}
+
+	for i, part := range parts {
+		j := jobs[i]
+
+		var err error
+
+		if part.Block == "" {
+			err = ld.execute(w, j)
+		} else {
+			err = ld.executeBlock(w, j, part.Block)
+		}
+
+		if err != nil {
+			ld.handleErr(w, r, err)
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// RenderBlock renders a single block of the provided template (e.g. the
+// "content" block of a page), which is useful for HTMX-style partial updates
+// without splitting templates into partials. The ctx is built in the same way
+// as Render(). The block must be defined by the template itself, as pongo2
+// doesn't execute blocks which are only defined by a parent template. Panics
+// (or calls Config.OnError) if the template doesn't contain the block.
+//
+// For example:
+//
+//	if r.Header.Get("HX-Request") != "" {
+//		ld.RenderBlock(w, r, "posts.html", "content", ctx)
+//		return
+//	}
+func (ld *Loader) RenderBlock(w http.ResponseWriter, r *http.Request, path, block string, rctx map[string]interface{}) {
+	j, err := ld.prepare(w, r, ld.conf(), path, rctx)
+	if err != nil {
+		ld.handleErr(w, r, err)
+		return
+	}
+
+	if j == nil {
+		return
+	}
+
+	if err = ld.executeBlock(w, j, block); err != nil {
+		ld.handleErr(w, r, err)
+	}
+}
+
+// executeBlock executes a single block of the prepared template, writing the
+// result to w. Only template execution errors are returned, see execErr().
+func (ld *Loader) executeBlock(w io.Writer, j *renderJob, block string) (err error) {
+	defer func() {
+		if err == nil && sampleUsage(j.conf) {
+			ld.recordUsage(j.path, block)
+		}
+	}()
+
+	blocks, err := j.tpl.ExecuteBlocks(j.ctx, []string{block})
+	if err != nil {
+		return ld.execErr(j.conf, err)
+	}
+
+	out, found := blocks[block]
+	if !found {
+		return fmt.Errorf("block %q not found in template %q", block, j.path)
+	}
+
+	_, err = io.WriteString(w, out)
+	return ld.execErr(j.conf, err)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var multiFS = fstest.MapFS{
+	"page.html":  {Data: []byte(`<h1>{{ title }}</h1>{% block content %}content {{ title }}{% endblock %}`)},
+	"toast.html": {Data: []byte(`<div id="toast" hx-swap-oob="true">{{ msg }}</div>`)},
+}
+
+func TestRenderMulti(t *testing.T) {
+	ld := New("multi", Config{FS: multiFS})
+
+	rec := httptest.NewRecorder()
+	ld.RenderMulti(rec, httptest.NewRequest(http.MethodGet, "/", nil), []RenderPart{
+		{Path: "page.html", Block: "content", Ctx: M{"title": "Posts"}},
+		{Path: "toast.html", Ctx: M{"msg": "Saved"}},
+	})
+
+	if want := `content Posts<div id="toast" hx-swap-oob="true">Saved</div>`; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+	if !rec.Flushed {
+		t.Error("response not flushed between parts")
+	}
+}
+
+func TestRenderMultiNotFound(t *testing.T) {
+	ld := New("multi-not-found", Config{
+		FS: multiFS,
+		NotFoundHandler: func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	ld.RenderMulti(rec, httptest.NewRequest(http.MethodGet, "/", nil), []RenderPart{
+		{Path: "toast.html"},
+		{Path: "missing.html"},
+	})
+
+	if rec.Code != http.StatusNotFound || rec.Body.Len() != 0 {
+		t.Errorf("code = %d, body = %q, want 404 with no output", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRenderBlock(t *testing.T) {
+	var renderErr error
+	ld := New("block", Config{
+		FS:      multiFS,
+		OnError: func(_ http.ResponseWriter, _ *http.Request, err error) { renderErr = err },
+	})
+
+	rec := httptest.NewRecorder()
+	ld.RenderBlock(rec, httptest.NewRequest(http.MethodGet, "/", nil), "page.html", "content", M{"title": "Posts"})
+	if rec.Body.String() != "content Posts" {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+
+	ld.RenderBlock(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "page.html", "sidebar", nil)
+	if renderErr == nil || !strings.Contains(renderErr.Error(), `block "sidebar" not found`) {
+		t.Errorf("error = %v, want block not found", renderErr)
+	}
+}
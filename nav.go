@@ -0,0 +1,149 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// MenuItem is a single item within a navigation menu, see Loader.SetMenu().
+type MenuItem struct {
+	// Title is the display title of the item.
+	Title string `json:"title"`
+	// URL is the path (or full URL) of the item. Items with a path are marked
+	// as active when the request path matches, or is within it (e.g.
+	// "/docs/install" is within "/docs").
+	URL string `json:"url"`
+	// Permission is an optional action which the request must be allowed to
+	// perform (see Config.Authorizer) for the item to be shown. Items with a
+	// permission are hidden when no Authorizer is provided.
+	Permission string `json:"permission,omitempty"`
+	// Children are the optional nested items.
+	Children []*MenuItem `json:"children,omitempty"`
+}
+
+// NavItem is a menu item for a specific request, as exposed within the "nav"
+// ctx key.
+type NavItem struct {
+	Title string
+	URL   string
+	// Current is true if the item URL matches the request path.
+	Current bool
+	// Active is true if the item is current, or the request path is within
+	// the item URL (e.g. one of its children is current).
+	Active   bool
+	Children []*NavItem
+}
+
+// SetMenu registers a navigation menu, which is exposed within the "nav" ctx
+// key of every render (e.g. "{{ nav.main }}"), with the items the request is
+// allowed to see, and the active items flagged based on the request path.
+// Setting a nil menu removes it.
+//
+// For example:
+//
+//	ld.SetMenu("main", []*pt.MenuItem{
+//		{Title: "Home", URL: "/"},
+//		{Title: "Docs", URL: "/docs", Children: []*pt.MenuItem{
+//			{Title: "Install", URL: "/docs/install"},
+//		}},
+//		{Title: "Admin", URL: "/admin", Permission: "admin:view"},
+//	})
+//
+// Which can be used like:
+//
+//	{% for item in nav.main %}
+//		<a href="{{ item.URL }}"{% if item.Active %} class="active"{% endif %}>{{ item.Title }}</a>
+//	{% endfor %}
+func (ld *Loader) SetMenu(name string, items []*MenuItem) {
+	ld.menusMu.Lock()
+	defer ld.menusMu.Unlock()
+
+	if items == nil {
+		delete(ld.menus, name)
+		return
+	}
+
+	if ld.menus == nil {
+		ld.menus = make(map[string][]*MenuItem)
+	}
+	ld.menus[name] = items
+}
+
+// LoadMenu registers a navigation menu (see SetMenu()) from a JSON file
+// within the loaders templates, containing a list of menu items. For example:
+//
+//	[
+//		{"title": "Home", "url": "/"},
+//		{"title": "Docs", "url": "/docs", "children": [
+//			{"title": "Install", "url": "/docs/install"}
+//		]}
+//	]
+func (ld *Loader) LoadMenu(name, path string) error {
+	src, err := ld.source(path)
+	if err != nil {
+		return err
+	}
+
+	var items []*MenuItem
+	if err = json.Unmarshal(src, &items); err != nil {
+		return fmt.Errorf("invalid menu %q: %w", path, err)
+	}
+
+	if items == nil {
+		items = []*MenuItem{}
+	}
+
+	ld.SetMenu(name, items)
+	return nil
+}
+
+// nav returns the menus for the request, or nil if no menus are registered.
+func (ld *Loader) nav(r *http.Request) map[string][]*NavItem {
+	ld.menusMu.RLock()
+	defer ld.menusMu.RUnlock()
+
+	if len(ld.menus) == 0 {
+		return nil
+	}
+
+	nav := make(map[string][]*NavItem, len(ld.menus))
+	for name, items := range ld.menus {
+		nav[name] = ld.navItems(r, items)
+	}
+	return nav
+}
+
+// navItems returns the items the request is allowed to see, with the active
+// items flagged.
+func (ld *Loader) navItems(r *http.Request, items []*MenuItem) []*NavItem {
+	out := make([]*NavItem, 0, len(items))
+
+	for _, item := range items {
+		if item.Permission != "" && !ld.Can(r, item.Permission, nil) {
+			continue
+		}
+
+		nav := &NavItem{
+			Title:    item.Title,
+			URL:      item.URL,
+			Current:  item.URL == r.URL.Path,
+			Children: ld.navItems(r, item.Children),
+		}
+
+		nav.Active = nav.Current || (item.URL != "/" && strings.HasPrefix(r.URL.Path, strings.TrimSuffix(item.URL, "/")+"/"))
+
+		for _, child := range nav.Children {
+			nav.Active = nav.Active || child.Active
+		}
+
+		out = append(out, nav)
+	}
+
+	return out
+}
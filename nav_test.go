@@ -0,0 +1,73 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+const navTemplate = `{% for item in nav.main %}{{ item.Title }}{% if item.Current %}*{% elif item.Active %}+{% endif %}` +
+	`{% for child in item.Children %}({{ child.Title }}{% if child.Current %}*{% endif %}){% endfor %} {% endfor %}`
+
+func TestNav(t *testing.T) {
+	ld := New("nav", Config{
+		FS: fstest.MapFS{
+			"index.html": {Data: []byte(navTemplate)},
+			"menu.json": {Data: []byte(`[
+				{"title": "Home", "url": "/"},
+				{"title": "Docs", "url": "/docs/", "children": [{"title": "Install", "url": "/docs/install"}]},
+				{"title": "Admin", "url": "/admin", "permission": "admin:view"}
+			]`)},
+		},
+		Authorizer: AuthorizerFunc(func(r *http.Request, action string, _ interface{}) bool {
+			return r.URL.Query().Get("admin") != ""
+		}),
+	})
+
+	if err := ld.LoadMenu("main", "menu.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/", "Home* Docs(Install) "},
+		{"/docs/install", "Home Docs+(Install*) "},
+		{"/docsearch", "Home Docs(Install) "},
+		{"/admin/users?admin=1", "Home Docs(Install) Admin+ "},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		ld.Render(rec, httptest.NewRequest(http.MethodGet, tt.target, nil), "index.html", nil)
+
+		if rec.Body.String() != tt.want {
+			t.Errorf("%s: body = %q, want %q", tt.target, rec.Body.String(), tt.want)
+		}
+	}
+
+	ld.SetMenu("main", nil)
+
+	rec := httptest.NewRecorder()
+	ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "index.html", nil)
+	if rec.Body.String() != "" {
+		t.Errorf("removed menu rendered: %q", rec.Body.String())
+	}
+}
+
+func TestLoadMenuErrors(t *testing.T) {
+	ld := New("nav-errors", Config{FS: fstest.MapFS{"menu.json": {Data: []byte(`{"title": "Home"}`)}}})
+
+	if err := ld.LoadMenu("main", "menu.json"); err == nil {
+		t.Error("expected an error for an invalid menu")
+	}
+	if err := ld.LoadMenu("main", "missing.json"); err == nil {
+		t.Error("expected an error for a missing menu")
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+// Package ogimage renders dynamic social-share (Open Graph) images from SVG
+// templates, using signed query parameters so images can't be generated for
+// arbitrary input.
+package ogimage
+
+import (
+	"container/list"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lrstanley/pt"
+)
+
+// SignatureKey is the query parameter which contains the signature.
+const SignatureKey = "sig"
+
+// Rasterizer converts a SVG document into a PNG image with the provided
+// dimensions. Implementations can wrap a headless browser, or a library like
+// resvg.
+type Rasterizer interface {
+	Rasterize(ctx context.Context, svg []byte, width, height int) ([]byte, error)
+}
+
+// Config is the configuration for New().
+type Config struct {
+	// Loader is the loader used to render the template.
+	Loader *pt.Loader
+	// Template is the SVG template to render. All query parameters (other than
+	// the signature) are available within the "params" ctx key (e.g.
+	// "{{ params.title }}"). As images are cached by their parameters, the
+	// template is rendered with Loader.RenderBytes(), so it doesn't depend on
+	// the request (e.g. Config.DefaultCtx or themes), and isn't wrapped in
+	// Config.DefaultLayout.
+	Template string
+	// Secret is the key used to sign parameters, and is required.
+	Secret []byte
+	// Rasterizer is used to convert the SVG into a PNG. If not provided, the
+	// SVG is served as-is, which is not supported by most social platforms.
+	Rasterizer Rasterizer
+	// Width and Height are the image dimensions. Defaults to 1200x630.
+	Width  int
+	Height int
+	// CacheSize is the maximum number of images cached in memory. Defaults to
+	// 256. Set to -1 to disable caching.
+	CacheSize int
+	// MaxAge is the duration clients can cache images. Defaults to 7 days.
+	MaxAge time.Duration
+	// Logger is an optional logger, which errors are written to.
+	Logger *log.Logger
+}
+
+// Generator renders and caches Open Graph images.
+type Generator struct {
+	conf Config
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+type cacheItem struct {
+	key  string
+	data []byte
+}
+
+// New returns a new image generator.
+func New(conf Config) *Generator {
+	if conf.Loader == nil || conf.Template == "" || len(conf.Secret) == 0 {
+		panic("ogimage: loader, template and secret are required")
+	}
+
+	if conf.Width == 0 || conf.Height == 0 {
+		conf.Width, conf.Height = 1200, 630
+	}
+
+	if conf.CacheSize == 0 {
+		conf.CacheSize = 256
+	}
+
+	if conf.MaxAge == 0 {
+		conf.MaxAge = 7 * 24 * time.Hour
+	}
+
+	return &Generator{conf: conf, lru: list.New(), items: make(map[string]*list.Element)}
+}
+
+// sign returns the signature of the encoded parameters.
+func (g *Generator) sign(encoded string) string {
+	mac := hmac.New(sha256.New, g.conf.Secret)
+	_, _ = mac.Write([]byte(encoded))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// URL returns the signed URL for the provided parameters, where path is the
+// path the generator is mounted on. Use pt.Loader.AbsoluteURL() to convert
+// the result into a full URL, as required by og:image.
+func (g *Generator) URL(path string, params map[string]string) string {
+	values := make(url.Values, len(params))
+	for k, v := range params {
+		values.Set(k, v)
+	}
+
+	encoded := values.Encode()
+	values.Set(SignatureKey, g.sign(encoded))
+
+	return path + "?" + values.Encode()
+}
+
+// ServeHTTP implements http.Handler, verifying the signature of the request,
+// and rendering the image.
+func (g *Generator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	sig := values.Get(SignatureKey)
+	values.Del(SignatureKey)
+	encoded := values.Encode()
+
+	if !hmac.Equal([]byte(sig), []byte(g.sign(encoded))) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	contentType := "image/png"
+	if g.conf.Rasterizer == nil {
+		contentType = "image/svg+xml"
+	}
+
+	data, ok := g.get(encoded)
+	if !ok {
+		var err error
+
+		data, err = g.render(r, values)
+		if err != nil {
+			pt.Error(g.conf.Logger, w, http.StatusInternalServerError, err, false)
+			return
+		}
+
+		g.add(encoded, data)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(g.conf.MaxAge.Seconds())))
+	_, _ = w.Write(data)
+}
+
+func (g *Generator) render(r *http.Request, values url.Values) ([]byte, error) {
+	params := make(map[string]string, len(values))
+	for k := range values {
+		params[k] = values.Get(k)
+	}
+
+	svg, err := g.conf.Loader.RenderBytes(g.conf.Template, pt.M{
+		"params": params,
+		"width":  g.conf.Width,
+		"height": g.conf.Height,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ogimage: %w", err)
+	}
+
+	if g.conf.Rasterizer == nil {
+		return svg, nil
+	}
+
+	return g.conf.Rasterizer.Rasterize(r.Context(), svg, g.conf.Width, g.conf.Height)
+}
+
+func (g *Generator) get(key string) ([]byte, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if el, ok := g.items[key]; ok {
+		g.lru.MoveToFront(el)
+		return el.Value.(*cacheItem).data, true
+	}
+	return nil, false
+}
+
+func (g *Generator) add(key string, data []byte) {
+	if g.conf.CacheSize < 0 {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.items[key]; ok {
+		return
+	}
+
+	g.items[key] = g.lru.PushFront(&cacheItem{key: key, data: data})
+
+	for g.lru.Len() > g.conf.CacheSize {
+		oldest := g.lru.Back()
+		g.lru.Remove(oldest)
+		delete(g.items, oldest.Value.(*cacheItem).key)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package ogimage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lrstanley/pt"
+)
+
+func newTestGenerator(t *testing.T, fsys fstest.MapFS) *Generator {
+	t.Helper()
+
+	return newTestGeneratorConfig(t, pt.Config{FS: fsys})
+}
+
+func newTestGeneratorConfig(t *testing.T, conf pt.Config) *Generator {
+	t.Helper()
+
+	conf.Compress = true
+	conf.CompressMinSize = 1
+
+	return New(Config{
+		Loader:   pt.New("ogimage-"+t.Name(), conf),
+		Template: "og.svg",
+		Secret:   []byte("secret"),
+	})
+}
+
+func serve(g *Generator, method, target string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestGeneratorCachesFullImage(t *testing.T) {
+	g := newTestGenerator(t, fstest.MapFS{"og.svg": {Data: []byte(`<svg>{{ params.title }}</svg>`)}})
+	target := g.URL("/og", map[string]string{"title": "hello"})
+
+	// The first request (which populates the cache) is a HEAD request, from a
+	// client which accepts gzip.
+	serve(g, http.MethodHead, target)
+
+	rec := serve(g, http.MethodGet, target)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if want := "<svg>hello</svg>"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+	if enc := rec.Header().Get("Content-Encoding"); enc != "" {
+		t.Errorf("Content-Encoding = %q", enc)
+	}
+}
+
+func TestGeneratorSkipsDefaultLayout(t *testing.T) {
+	g := newTestGeneratorConfig(t, pt.Config{
+		FS: fstest.MapFS{
+			"layout.html": {Data: []byte(`<html>{% block content %}{% endblock %}</html>`)},
+			"og.svg":      {Data: []byte(`<svg>{{ params.title }}</svg>`)},
+		},
+		DefaultLayout: "layout.html",
+	})
+
+	rec := serve(g, http.MethodGet, g.URL("/og", map[string]string{"title": "hello"}))
+	if want := "<svg>hello</svg>"; rec.Code != http.StatusOK || rec.Body.String() != want {
+		t.Errorf("status = %d, body = %q, want %q", rec.Code, rec.Body.String(), want)
+	}
+}
+
+func TestGeneratorDoesNotCacheErrors(t *testing.T) {
+	fsys := fstest.MapFS{}
+	g := newTestGenerator(t, fsys)
+	target := g.URL("/og", map[string]string{"title": "hello"})
+
+	if rec := serve(g, http.MethodGet, target); rec.Code != http.StatusInternalServerError {
+		t.Errorf("missing template: status = %d, want 500", rec.Code)
+	}
+
+	fsys["og.svg"] = &fstest.MapFile{Data: []byte(`<svg>{{ params.title }}</svg>`)}
+
+	rec := serve(g, http.MethodGet, target)
+	if rec.Code != http.StatusOK || rec.Body.String() != "<svg>hello</svg>" {
+		t.Errorf("after fix: status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGeneratorRejectsBadSignature(t *testing.T) {
+	g := newTestGenerator(t, fstest.MapFS{"og.svg": {Data: []byte(`<svg></svg>`)}})
+
+	if rec := serve(g, http.MethodGet, "/og?title=x&sig=bad"); rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", rec.Code)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+)
+
+// Option configures a loader created with NewWithOptions().
+type Option func(c *Config) error
+
+// WithConfig uses conf as the base configuration, which options provided
+// after it modify. It should be the first option, as it replaces all
+// previously applied options.
+func WithConfig(conf Config) Option {
+	return func(c *Config) error {
+		*c = conf
+		return nil
+	}
+}
+
+// WithFS loads templates from the provided filesystem, see Config.FS. If
+// fallbacks are provided, templates missing from fsys are loaded from them,
+// in order (see ChainFS()).
+func WithFS(fsys fs.FS, fallbacks ...fs.FS) Option {
+	return func(c *Config) error {
+		if fsys == nil {
+			return errors.New("nil filesystem provided")
+		}
+
+		for _, fallback := range fallbacks {
+			if fallback == nil {
+				return errors.New("nil fallback filesystem provided")
+			}
+		}
+
+		if len(fallbacks) > 0 {
+			fsys = ChainFS(append([]fs.FS{fsys}, fallbacks...)...)
+		}
+
+		c.FS = fsys
+		return nil
+	}
+}
+
+// WithLoaderFunc loads templates using the provided function, see
+// Config.Loader.
+func WithLoaderFunc(fn func(path string) ([]byte, error)) Option {
+	return func(c *Config) error {
+		if fn == nil {
+			return errors.New("nil loader function provided")
+		}
+
+		c.Loader = fn
+		return nil
+	}
+}
+
+// WithCache enables or disables caching of parsed templates, see
+// Config.CacheParsed.
+func WithCache(enabled bool) Option {
+	return func(c *Config) error {
+		c.CacheParsed = enabled
+		return nil
+	}
+}
+
+// WithErrorLogger writes errors to the provided writer, see
+// Config.ErrorLogger.
+func WithErrorLogger(w io.Writer) Option {
+	return func(c *Config) error {
+		c.ErrorLogger = w
+		return nil
+	}
+}
+
+// WithDebug enables debug mode, see Config.Debug.
+func WithDebug(enabled bool) Option {
+	return func(c *Config) error {
+		c.Debug = enabled
+		return nil
+	}
+}
+
+// NewWithOptions returns a new loader, configured with the provided options.
+// Unlike New(), the configuration is validated (see Config.Validate()), and
+// the templates it references (e.g. Config.ErrorTemplate) must exist, so
+// mistakes are returned as an error at startup, rather than panicking or
+// failing at request time.
+//
+// For example:
+//
+//	ld, err := pt.NewWithOptions("web",
+//		pt.WithFS(templates),
+//		pt.WithCache(!debug),
+//		pt.WithErrorLogger(os.Stderr),
+//	)
+func NewWithOptions(set string, opts ...Option) (*Loader, error) {
+	var conf Config
+
+	for _, opt := range opts {
+		if err := opt(&conf); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	ld := New(set, conf)
+
+	if err := ld.validateTemplates(); err != nil {
+		ld.Close()
+		return nil, err
+	}
+
+	return ld, nil
+}
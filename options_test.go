@@ -0,0 +1,77 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	var logs bytes.Buffer
+
+	ld, err := NewWithOptions("options",
+		WithFS(fstest.MapFS{"index.html": {Data: []byte(`{% include "nav.html" %}`)}}, fstest.MapFS{"nav.html": {Data: []byte(`nav`)}}),
+		WithCache(true),
+		WithDebug(true),
+		WithErrorLogger(&logs),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ld.Close()
+
+	conf := ld.conf()
+	if !conf.CacheParsed || !conf.Debug || conf.ErrorLogger != &logs {
+		t.Errorf("options not applied: %+v", conf)
+	}
+
+	if out, err := ld.RenderBytes("index.html", nil); err != nil || string(out) != "nav" {
+		t.Errorf("RenderBytes() = %q, %v", out, err)
+	}
+}
+
+func TestNewWithOptionsLoaderFunc(t *testing.T) {
+	ld, err := NewWithOptions("options-loader",
+		WithConfig(Config{CacheParsed: true}),
+		WithLoaderFunc(func(string) ([]byte, error) { return []byte("loaded"), nil }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ld.Close()
+
+	if out, err := ld.RenderBytes("any.html", nil); err != nil || string(out) != "loaded" {
+		t.Errorf("RenderBytes() = %q, %v", out, err)
+	}
+}
+
+func TestNewWithOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"nil fs", []Option{WithFS(nil)}},
+		{"nil fallback", []Option{WithFS(fstest.MapFS{}, nil)}},
+		{"nil loader", []Option{WithLoaderFunc(nil)}},
+		{"no source", nil},
+		{"missing layout", []Option{WithConfig(Config{DefaultLayout: "base.html"}), WithFS(fstest.MapFS{})}},
+	}
+
+	for _, tt := range tests {
+		ld, err := NewWithOptions("options-"+tt.name, tt.opts...)
+		if err == nil {
+			ld.Close()
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	_, err := NewWithOptions("options-invalid", WithConfig(Config{DefaultLayout: "base.html"}), WithFS(fstest.MapFS{}))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("missing template error = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// RegisterPages walks dir within the Config.FS of the provided loader, and
+// registers a route for each template, based on its path. This is useful for
+// content-heavy sites, where most pages don't need their own handler.
+// Files and directories starting with "_" are skipped (e.g. partials and
+// layouts), as are files without one of Config.TemplateExts, and "index"
+// templates map to the directory itself. Segments wrapped in brackets are
+// treated as url params, which are provided to the template via the "params"
+// ctx key. For example, with dir set to "pages":
+//
+//	pages/index.html       -> /
+//	pages/about.html       -> /about
+//	pages/blog/index.html  -> /blog
+//	pages/blog/[slug].html -> /blog/{slug} (ctx: {{ params.slug }})
+//
+// Params are extracted from the trailing segments of the request path, so the
+// router can be mounted under a prefix.
+func RegisterPages(router Router, ld *Loader, dir string) error {
+	pages, err := ld.pages(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, page := range pages {
+		segments := pageSegments(dir, page)
+		router.Get("/"+strings.Join(segments, "/"), pageHandler(ld, page, segments))
+	}
+
+	return nil
+}
+
+// pages returns all page templates within dir, with pages without url params
+// sorted first.
+func (ld *Loader) pages(dir string) ([]string, error) {
+	conf := ld.conf()
+
+	fsys := conf.FS
+	if fsys == nil {
+		return nil, errors.New("pages require a loader with Config.FS")
+	}
+
+	var pages []string
+
+	err := fs.WalkDir(fsys, dir, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if fpath != dir && strings.HasPrefix(d.Name(), "_") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if !d.IsDir() && hasExt(conf.TemplateExts, fpath) {
+			pages = append(pages, fpath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Static routes are registered before routes with params, for routers which
+	// match in the order routes were registered.
+	sort.SliceStable(pages, func(i, j int) bool {
+		return strings.Count(pages[i], "[") < strings.Count(pages[j], "[")
+	})
+
+	return pages, nil
+}
+
+// pageSegments converts the template path into route segments, converting
+// "[param]" segments into "{param}".
+func pageSegments(dir, page string) []string {
+	name := strings.TrimPrefix(strings.TrimPrefix(page, dir), "/")
+	name = strings.TrimSuffix(name, path.Ext(name))
+
+	segments := strings.Split(name, "/")
+	if segments[len(segments)-1] == "index" {
+		segments = segments[:len(segments)-1]
+	}
+
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, "[") && strings.HasSuffix(seg, "]") {
+			segments[i] = "{" + seg[1:len(seg)-1] + "}"
+		}
+	}
+
+	return segments
+}
+
+func pageHandler(ld *Loader, page string, segments []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := make(map[string]string)
+
+		rsegments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if offset := len(rsegments) - len(segments); offset >= 0 {
+			for i, seg := range segments {
+				if strings.HasPrefix(seg, "{") {
+					params[seg[1:len(seg)-1]] = rsegments[offset+i]
+				}
+			}
+		}
+
+		ld.Render(w, r, page, M{"params": params})
+	}
+}
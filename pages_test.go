@@ -0,0 +1,79 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRegisterPages(t *testing.T) {
+	ld := New("pages", Config{
+		FS: fstest.MapFS{
+			"pages/index.html":         {Data: []byte(`home`)},
+			"pages/about.html":         {Data: []byte(`about`)},
+			"pages/blog/index.html":    {Data: []byte(`blog`)},
+			"pages/blog/[slug].html":   {Data: []byte(`post {{ params.slug }}`)},
+			"pages/_partials/nav.html": {Data: []byte(`nav`)},
+			"pages/_draft.html":        {Data: []byte(`draft`)},
+			"pages/blog/cover.png":     {Data: []byte(`png`)},
+			"layouts/base.html":        {Data: []byte(`base`)},
+		},
+	})
+
+	router := testRouter{}
+	if err := RegisterPages(router, ld, "pages"); err != nil {
+		t.Fatal(err)
+	}
+
+	var patterns []string
+	for pattern := range router {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	if want := []string{"/", "/about", "/blog", "/blog/{slug}"}; !reflect.DeepEqual(patterns, want) {
+		t.Fatalf("patterns = %v, want %v", patterns, want)
+	}
+
+	rec := httptest.NewRecorder()
+	router["/blog/{slug}"](rec, httptest.NewRequest(http.MethodGet, "/en/blog/hello", nil))
+	if rec.Body.String() != "post hello" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "post hello")
+	}
+}
+
+func TestPagesOrder(t *testing.T) {
+	ld := New("pages-order", Config{
+		FS: fstest.MapFS{
+			"[id].html":      {Data: []byte(``)},
+			"new.html":       {Data: []byte(``)},
+			"[a]/[b].html":   {Data: []byte(``)},
+			"users/new.html": {Data: []byte(``)},
+		},
+	})
+
+	pages, err := ld.pages(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pages) != 4 {
+		t.Fatalf("pages = %v, want 4 pages", pages)
+	}
+
+	// Pages with params are sorted after all static pages.
+	for i, page := range pages {
+		if static := !strings.Contains(page, "["); static != (i < 2) {
+			t.Errorf("pages = %v, want static pages first", pages)
+			break
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+// Package pdf renders pt templates to PDF documents (e.g. invoices or
+// reports), using a pluggable HTML to PDF conversion backend.
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"os/exec"
+	"regexp"
+
+	"github.com/lrstanley/pt"
+)
+
+// ContentType is the Content-Type of PDF documents.
+const ContentType = "application/pdf"
+
+// Options are the document options passed to the converter.
+type Options struct {
+	// PageSize is the page size (e.g. "A4" or "Letter"). Converters should
+	// default to "A4".
+	PageSize string
+	// Landscape uses landscape orientation, rather than portrait.
+	Landscape bool
+	// BaseURL is used to resolve relative URLs (e.g. stylesheets and images)
+	// within the HTML.
+	BaseURL string
+}
+
+// Converter converts a HTML document into a PDF document. Implementations
+// can wrap a headless browser (e.g. chromedp), or a command line tool (see
+// WKHTMLToPDF).
+type Converter interface {
+	Convert(ctx context.Context, html []byte, opts Options) ([]byte, error)
+}
+
+// ConverterFunc is an adapter to allow the use of ordinary functions as a
+// Converter.
+type ConverterFunc func(ctx context.Context, html []byte, opts Options) ([]byte, error)
+
+// Convert calls fn(ctx, html, opts).
+func (fn ConverterFunc) Convert(ctx context.Context, html []byte, opts Options) ([]byte, error) {
+	return fn(ctx, html, opts)
+}
+
+// WKHTMLToPDF is a Converter which uses the wkhtmltopdf command line tool.
+type WKHTMLToPDF struct {
+	// Path is the path to the wkhtmltopdf binary. Defaults to "wkhtmltopdf",
+	// looked up in PATH.
+	Path string
+	// Args are additional arguments passed to wkhtmltopdf.
+	Args []string
+}
+
+// Convert implements Converter.
+func (c *WKHTMLToPDF) Convert(ctx context.Context, html []byte, opts Options) ([]byte, error) {
+	bin := c.Path
+	if bin == "" {
+		bin = "wkhtmltopdf"
+	}
+
+	args := []string{"--quiet", "--encoding", "utf-8"}
+
+	if opts.PageSize != "" {
+		args = append(args, "--page-size", opts.PageSize)
+	}
+
+	if opts.Landscape {
+		args = append(args, "--orientation", "Landscape")
+	}
+
+	args = append(args, c.Args...)
+	args = append(args, "-", "-")
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, bin, args...) //nolint:gosec
+	cmd.Stdin = bytes.NewReader(withBase(html, opts.BaseURL))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("wkhtmltopdf: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// Renderer renders templates from a loader into PDF documents.
+type Renderer struct {
+	Loader    *pt.Loader
+	Converter Converter
+	Options   Options
+	// Logger is an optional logger, which errors are written to when using
+	// Respond().
+	Logger *log.Logger
+}
+
+// Render renders the template into HTML using the loader (including the
+// default context, see pt.Loader.RenderRequestBytes()), and converts it into
+// a PDF. Template errors are returned.
+func (p *Renderer) Render(r *http.Request, path string, ctx map[string]interface{}) ([]byte, error) {
+	if p.Loader == nil || p.Converter == nil {
+		return nil, errors.New("pdf: renderer requires a loader and converter")
+	}
+
+	html, err := p.Loader.RenderRequestBytes(r, path, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %w", err)
+	}
+
+	opts := p.Options
+	if opts.BaseURL == "" {
+		opts.BaseURL = p.Loader.AbsoluteURL(r, "/")
+	}
+
+	return p.Converter.Convert(r.Context(), html, opts)
+}
+
+// Respond renders the template into a PDF, and sends it to the client as an
+// attachment with the provided filename (e.g. "invoice-1234.pdf"). Errors are
+// logged to Logger, and a 500 is returned to the client.
+func (p *Renderer) Respond(w http.ResponseWriter, r *http.Request, path string, ctx map[string]interface{}, filename string) {
+	data, err := p.Render(r, path, ctx)
+	if err != nil {
+		pt.Error(p.Logger, w, http.StatusInternalServerError, err, false)
+		return
+	}
+
+	pt.Attachment(w, r, filename, ContentType, data)
+}
+
+var (
+	reBase      = regexp.MustCompile(`(?i)<base[\s>]`)
+	reHeadStart = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+)
+
+// withBase adds a "<base>" tag with the provided URL to the "<head>" of the
+// document, so relative URLs resolve when the document is read from stdin.
+// The document is returned unchanged if it already has a "<base>" tag, or no
+// URL is provided.
+func withBase(doc []byte, baseURL string) []byte {
+	if baseURL == "" || reBase.Match(doc) {
+		return doc
+	}
+
+	tag := `<base href="` + html.EscapeString(baseURL) + `">`
+
+	loc := reHeadStart.FindIndex(doc)
+	if loc == nil {
+		return append([]byte(tag), doc...)
+	}
+
+	out := make([]byte, 0, len(doc)+len(tag))
+	out = append(out, doc[:loc[1]]...)
+	out = append(out, tag...)
+	return append(out, doc[loc[1]:]...)
+}
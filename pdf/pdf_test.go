@@ -0,0 +1,94 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pdf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lrstanley/pt"
+)
+
+func newTestRenderer(t *testing.T, got *[]byte) *Renderer {
+	t.Helper()
+
+	ld := pt.New("pdf-"+t.Name(), pt.Config{
+		FS: fstest.MapFS{
+			"invoice.html": {Data: []byte(`<html><head></head><body>invoice {{ id }}</body></html>`)},
+			"broken.html":  {Data: []byte(`{{ fail() }}`)},
+		},
+		Compress:        true,
+		CompressMinSize: 1,
+		ConditionalGET:  true,
+	})
+
+	return &Renderer{
+		Loader: ld,
+		Converter: ConverterFunc(func(_ context.Context, html []byte, _ Options) ([]byte, error) {
+			*got = append([]byte(nil), html...)
+			return []byte("%PDF"), nil
+		}),
+	}
+}
+
+func TestRendererUsesFullHTML(t *testing.T) {
+	var got []byte
+	p := newTestRenderer(t, &got)
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		r := httptest.NewRequest(method, "/invoice", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+
+		if _, err := p.Render(r, "invoice.html", pt.M{"id": 1}); err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+
+		if want := `<html><head></head><body>invoice 1</body></html>`; string(got) != want {
+			t.Errorf("%s: converter got %q, want %q", method, got, want)
+		}
+	}
+}
+
+func TestRendererReturnsErrors(t *testing.T) {
+	var got []byte
+	p := newTestRenderer(t, &got)
+
+	r := httptest.NewRequest(http.MethodGet, "/invoice", nil)
+
+	if _, err := p.Render(r, "missing.html", nil); !errors.Is(err, pt.ErrTemplateNotFound) {
+		t.Errorf("missing template: err = %v, want ErrTemplateNotFound", err)
+	}
+
+	_, err := p.Render(r, "broken.html", pt.M{"fail": func() (string, error) { return "", errors.New("boom") }})
+	if err == nil {
+		t.Error("broken template: expected error")
+	}
+
+	if got != nil {
+		t.Errorf("converter called with %q", got)
+	}
+}
+
+func TestWithBase(t *testing.T) {
+	tests := []struct {
+		doc, base, want string
+	}{
+		{`<html><head><title>x</title></head></html>`, "https://example.com/", `<html><head><base href="https://example.com/"><title>x</title></head></html>`},
+		{`<HEAD lang="en"></HEAD>`, "https://example.com/?a=1&b=2", `<HEAD lang="en"><base href="https://example.com/?a=1&amp;b=2"></HEAD>`},
+		{`<p>no head</p>`, "https://example.com/", `<base href="https://example.com/"><p>no head</p>`},
+		{`<head><base href="/x/"></head>`, "https://example.com/", `<head><base href="/x/"></head>`},
+		{`<head></head>`, "", `<head></head>`},
+	}
+
+	for _, tt := range tests {
+		if got := string(withBase([]byte(tt.doc), tt.base)); got != tt.want {
+			t.Errorf("withBase(%q, %q) = %q, want %q", tt.doc, tt.base, got, tt.want)
+		}
+	}
+}
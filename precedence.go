@@ -0,0 +1,97 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// LoaderPrecedence declares which template source is used first when both
+// Config.Loader and Config.FS are provided. See Config.Precedence.
+type LoaderPrecedence int
+
+const (
+	// PrecedenceUnset means no precedence was declared. If both sources are
+	// provided, only Config.Loader is used, and a warning is logged (or
+	// NewWithOptions() returns an error).
+	PrecedenceUnset LoaderPrecedence = iota
+	// LoaderFirst loads templates from Config.Loader, falling back to
+	// Config.FS for templates it doesn't have.
+	LoaderFirst
+	// FSFirst loads templates from Config.FS, falling back to Config.Loader
+	// for templates it doesn't have.
+	FSFirst
+)
+
+// chainLoader is a template loader which tries each loader in order, until
+// one of them has the template. Only errors which signal a missing template
+// (see isNotFound()) fall through to the next loader, so other errors aren't
+// masked by later loaders.
+type chainLoader struct {
+	loaders []pongo2.TemplateLoader
+
+	// served is the index of the loader which served each template path, so
+	// references are resolved by the same loader as the template containing
+	// them.
+	served sync.Map
+}
+
+func newChainLoader(loaders ...pongo2.TemplateLoader) *chainLoader {
+	return &chainLoader{loaders: loaders}
+}
+
+func (c *chainLoader) Abs(base, name string) string {
+	loader := c.loaders[0]
+
+	// Root templates may be loaded with the layoutSuffix.
+	if i, ok := c.served.Load(strings.TrimSuffix(base, layoutSuffix)); ok {
+		loader = c.loaders[i.(int)]
+	}
+
+	return loader.Abs(base, name)
+}
+
+func (c *chainLoader) Get(path string) (io.Reader, error) {
+	var err error
+
+	for i, loader := range c.loaders {
+		var r io.Reader
+		if r, err = loader.Get(path); err == nil {
+			c.served.Store(path, i)
+			return r, nil
+		}
+
+		if !isNotFound(err) {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+// newTemplateLoader returns the template loader for the configured sources,
+// taking into account Config.Precedence when both are provided.
+func newTemplateLoader(conf *Config) pongo2.TemplateLoader {
+	switch {
+	case conf.Loader == nil:
+		return pongo2.NewFSLoader(conf.FS)
+	case conf.FS == nil:
+		return &memLoader{loaderFunc: conf.Loader}
+	}
+
+	switch conf.Precedence {
+	case LoaderFirst:
+		return newChainLoader(&memLoader{loaderFunc: conf.Loader}, pongo2.NewFSLoader(conf.FS))
+	case FSFirst:
+		return newChainLoader(pongo2.NewFSLoader(conf.FS), &memLoader{loaderFunc: conf.Loader})
+	default:
+		conf.logf(LevelWarn, "both Config.Loader and Config.FS provided without Config.Precedence, only Config.Loader is used")
+		return &memLoader{loaderFunc: conf.Loader}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPrecedenceResolvesWithServingLoader(t *testing.T) {
+	ld := New("precedence-abs", Config{
+		Loader: func(path string) ([]byte, error) {
+			if path == "override.html" {
+				return []byte(`override`), nil
+			}
+			return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, path)
+		},
+		FS: fstest.MapFS{
+			"index.html":    {Data: []byte(`{% include "nav.html" %}|{% include "override.html" %}`)},
+			"nav.html":      {Data: []byte(`nav`)},
+			"override.html": {Data: []byte(`default`)},
+		},
+		Precedence: LoaderFirst,
+	})
+
+	out, err := ld.RenderBytes("index.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(out), "nav|override"; got != want {
+		t.Errorf("RenderBytes() = %q, want %q", got, want)
+	}
+}
+
+func TestPrecedenceLoaderErrors(t *testing.T) {
+	failed := errors.New("backend unavailable")
+
+	ld := New("precedence-errors", Config{
+		Loader: func(path string) ([]byte, error) {
+			return nil, failed
+		},
+		FS:         fstest.MapFS{"index.html": {Data: []byte(`index`)}},
+		Precedence: LoaderFirst,
+	})
+
+	// Errors other than missing templates don't fall back to Config.FS.
+	if _, err := ld.RenderBytes("index.html", nil); err == nil || errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("RenderBytes() = %v, want a non-not-found error", err)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+type principalKey struct{}
+
+// Principal returns the authenticated principal (e.g. user) of the request,
+// using Config.PrincipalFromRequest. Returns nil if the request isn't
+// authenticated, or no PrincipalFromRequest function is provided. The result
+// is reused if the request passed through RequireAuth().
+func (ld *Loader) Principal(r *http.Request) interface{} {
+	return principal(ld.conf(), r)
+}
+
+// principal is Loader.Principal(), using the provided config.
+func principal(conf *Config, r *http.Request) interface{} {
+	if p := r.Context().Value(principalKey{}); p != nil {
+		return p
+	}
+
+	fn := conf.PrincipalFromRequest
+	if fn == nil {
+		return nil
+	}
+
+	// Typed nil pointers (e.g. a nil *User) would otherwise be treated as an
+	// authenticated principal.
+	p := fn(r)
+	if rv := reflect.ValueOf(p); !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return nil
+	}
+	return p
+}
+
+// RequireAuth is a middleware which only allows requests with a principal
+// (see Config.PrincipalFromRequest), responding with Unauthorized()
+// otherwise.
+//
+// For example:
+//
+//	r.With(ld.RequireAuth).Get("/account", accountHandler)
+func (ld *Loader) RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := ld.Principal(r)
+		if p == nil {
+			ld.Unauthorized(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+// Unauthorized responds to a request which requires authentication. If
+// Config.LoginURL is provided, GET and HEAD requests are redirected to it,
+// with the current URL as the NextKey query param (see GetNextURL()).
+// Otherwise, a 401 is returned.
+func (ld *Loader) Unauthorized(w http.ResponseWriter, r *http.Request) {
+	login := ld.conf().LoginURL
+
+	if login == "" || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	sep := "?"
+	if strings.Contains(login, "?") {
+		sep = "&"
+	}
+
+	http.Redirect(w, r, login+sep+NextKey+"="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+type testUser struct{ Name string }
+
+func TestRequireAuth(t *testing.T) {
+	calls := 0
+
+	ld := New("principal", Config{
+		FS: fstest.MapFS{"account.html": {Data: []byte(`{{ user.Name }}`)}},
+		PrincipalFromRequest: func(r *http.Request) interface{} {
+			calls++
+
+			var u *testUser
+			if name := r.Header.Get("X-User"); name != "" {
+				u = &testUser{Name: name}
+			}
+			return u
+		},
+		LoginURL: "/login?theme=dark",
+	})
+
+	h := ld.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ld.Render(w, r, "account.html", nil)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/account", nil)
+	r.Header.Set("X-User", "alice")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	if rec.Body.String() != "alice" {
+		t.Errorf("body = %q, want alice", rec.Body.String())
+	}
+	if calls != 1 {
+		t.Errorf("PrincipalFromRequest called %d times, want 1", calls)
+	}
+
+	// A nil *testUser isn't an authenticated principal.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/account?tab=1", nil))
+
+	if want := "/login?theme=dark&next=%2Faccount%3Ftab%3D1"; rec.Code != http.StatusFound || rec.Header().Get("Location") != want {
+		t.Errorf("code = %d, location = %q, want redirect to %q", rec.Code, rec.Header().Get("Location"), want)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/account", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST code = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestPrincipalWithoutFunc(t *testing.T) {
+	ld := New("principal-none", Config{FS: fstest.MapFS{}})
+
+	if p := ld.Principal(httptest.NewRequest(http.MethodGet, "/", nil)); p != nil {
+		t.Errorf("Principal() = %v, want nil", p)
+	}
+}
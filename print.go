@@ -0,0 +1,33 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// PrintQuery returns a Config.PrintSelector which enables print mode when the
+// provided query param is present, and not "0" or "false" (e.g. "?print=1" or
+// "?print").
+func PrintQuery(param string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		values, ok := r.URL.Query()[param]
+		if !ok {
+			return false
+		}
+
+		v := strings.ToLower(values[0])
+		return v != "0" && v != "false"
+	}
+}
+
+// variantPath returns the path of a variant of the template, e.g.
+// "invoice.print.html" for the "print" variant of "invoice.html".
+func variantPath(name, variant string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + variant + ext
+}
@@ -0,0 +1,60 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPrintQuery(t *testing.T) {
+	selector := PrintQuery("print")
+
+	for target, want := range map[string]bool{
+		"/":              false,
+		"/?print":        true,
+		"/?print=1":      true,
+		"/?print=0":      false,
+		"/?print=FALSE":  false,
+		"/?printable=1":  false,
+		"/?a=b&print=on": true,
+	} {
+		if got := selector(httptest.NewRequest(http.MethodGet, target, nil)); got != want {
+			t.Errorf("%s: got %v, want %v", target, got, want)
+		}
+	}
+}
+
+func TestPrintVariant(t *testing.T) {
+	ld := New("print", Config{
+		FS: fstest.MapFS{
+			"invoice.html":       {Data: []byte(`screen {{ print_mode }}`)},
+			"invoice.print.html": {Data: []byte(`print {{ print_mode }}`)},
+			"receipt.html":       {Data: []byte(`receipt {{ print_mode }}`)},
+		},
+		PrintSelector: PrintQuery("print"),
+	})
+
+	tests := []struct {
+		target string
+		path   string
+		want   string
+	}{
+		{"/", "invoice.html", "screen False"},
+		{"/?print=1", "invoice.html", "print True"},
+		{"/?print=1", "receipt.html", "receipt True"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		ld.Render(rec, httptest.NewRequest(http.MethodGet, tt.target, nil), tt.path, nil)
+
+		if rec.Body.String() != tt.want {
+			t.Errorf("%s %s: body = %q, want %q", tt.target, tt.path, rec.Body.String(), tt.want)
+		}
+	}
+}
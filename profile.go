@@ -0,0 +1,147 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// ProfileStats are the accumulated execution statistics of a single template,
+// or a single "{% profile %}" section within a template. Allocations are
+// measured process-wide, so they are approximate when renders are concurrent.
+type ProfileStats struct {
+	Count        uint64        `json:"count"`
+	TotalTime    time.Duration `json:"total_time_ns"`
+	MaxTime      time.Duration `json:"max_time_ns"`
+	AvgTime      time.Duration `json:"avg_time_ns"`
+	BytesWritten uint64        `json:"bytes_written"`
+	Allocs       uint64        `json:"allocs"`
+	AllocBytes   uint64        `json:"alloc_bytes"`
+}
+
+// profiler records execution profiles, keyed by template path (and section
+// name, for profile sections).
+type profiler struct {
+	mu      sync.Mutex
+	entries map[string]*ProfileStats
+}
+
+// profileSample is an in-progress measurement.
+type profileSample struct {
+	start  time.Time
+	allocs uint64
+	bytes  uint64
+}
+
+func startProfile() profileSample {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return profileSample{start: time.Now(), allocs: mem.Mallocs, bytes: mem.TotalAlloc}
+}
+
+// record records the sample under the provided key.
+func (p *profiler) record(key string, sample profileSample, written uint64) {
+	elapsed := time.Since(sample.start)
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.entries == nil {
+		p.entries = make(map[string]*ProfileStats)
+	}
+
+	stats, ok := p.entries[key]
+	if !ok {
+		stats = &ProfileStats{}
+		p.entries[key] = stats
+	}
+
+	stats.Count++
+	stats.TotalTime += elapsed
+	stats.AvgTime = stats.TotalTime / time.Duration(stats.Count)
+	if elapsed > stats.MaxTime {
+		stats.MaxTime = elapsed
+	}
+	stats.BytesWritten += written
+	stats.Allocs += mem.Mallocs - sample.allocs
+	stats.AllocBytes += mem.TotalAlloc - sample.bytes
+}
+
+// snapshot returns a copy of all recorded profiles.
+func (p *profiler) snapshot() map[string]ProfileStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make(map[string]ProfileStats, len(p.entries))
+	for key, stats := range p.entries {
+		out[key] = *stats
+	}
+	return out
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += uint64(n) //nolint:gosec
+	return n, err
+}
+
+func (cw *countingWriter) WriteString(s string) (int, error) {
+	return cw.Write([]byte(s))
+}
+
+type tagProfileNode struct {
+	key     string
+	wrapper *pongo2.NodeWrapper
+}
+
+func (node *tagProfileNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	state := stateFromCtx(ctx)
+	if state == nil || !state.conf.Profile {
+		return node.wrapper.Execute(ctx, writer)
+	}
+
+	cw := &countingWriter{w: writer}
+	sample := startProfile()
+
+	err := node.wrapper.Execute(ctx, cw)
+
+	state.ld.profiles.record(node.key, sample, cw.n)
+	return err
+}
+
+// tagProfileParser parses the "profile" tag, which records the execution
+// profile of the wrapped section when Config.Profile is enabled. For example:
+//
+//	{% profile "sidebar" %}{% include "partials/sidebar.html" %}{% endprofile %}
+func tagProfileParser(doc *pongo2.Parser, start *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	name := arguments.MatchType(pongo2.TokenString)
+	if name == nil || arguments.Remaining() > 0 {
+		return nil, arguments.Error("The profile-tag requires a single string argument.", nil)
+	}
+
+	wrapper, _, err := doc.WrapUntilTag("endprofile")
+	if err != nil {
+		return nil, err
+	}
+
+	key := strings.TrimSuffix(start.Filename, layoutSuffix) + "#" + name.Val
+	return &tagProfileNode{key: key, wrapper: wrapper}, nil
+}
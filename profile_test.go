@@ -0,0 +1,60 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProfile(t *testing.T) {
+	ld := New("profile", Config{
+		FS: fstest.MapFS{
+			"base.html":  {Data: []byte(`{% block content %}{% endblock %}`)},
+			"index.html": {Data: []byte(`{% profile "sidebar" %}{% include "nav.html" %}{% endprofile %}`)},
+			"nav.html":   {Data: []byte(`nav`)},
+		},
+		DefaultLayout: "base.html",
+		Profile:       true,
+	})
+
+	for i := 0; i < 2; i++ {
+		ld.Render(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "index.html", nil)
+	}
+
+	profiles := ld.Stats().Profiles
+
+	var keys []string
+	for key := range profiles {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if want := []string{"index.html", "index.html#sidebar"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("profile keys = %v, want %v", keys, want)
+	}
+
+	section := profiles["index.html#sidebar"]
+	if section.Count != 2 || section.BytesWritten != 6 || section.AvgTime != section.TotalTime/2 {
+		t.Errorf("section profile = %+v", section)
+	}
+}
+
+func TestProfileDisabled(t *testing.T) {
+	ld := New("profile-disabled", Config{
+		FS: fstest.MapFS{"index.html": {Data: []byte(`{% profile "a" %}content{% endprofile %}`)}},
+	})
+
+	if out, err := ld.RenderBytes("index.html", nil); err != nil || string(out) != "content" {
+		t.Errorf("RenderBytes() = %q, %v", out, err)
+	}
+	if n := len(ld.Stats().Profiles); n != 0 {
+		t.Errorf("recorded %d profiles while disabled", n)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+)
+
+// WebManifest is a web app manifest, see:
+// https://developer.mozilla.org/en-US/docs/Web/Manifest
+type WebManifest struct {
+	Name            string             `json:"name"`
+	ShortName       string             `json:"short_name,omitempty"`
+	Description     string             `json:"description,omitempty"`
+	StartURL        string             `json:"start_url,omitempty"`
+	Scope           string             `json:"scope,omitempty"`
+	Display         string             `json:"display,omitempty"`
+	Orientation     string             `json:"orientation,omitempty"`
+	BackgroundColor string             `json:"background_color,omitempty"`
+	ThemeColor      string             `json:"theme_color,omitempty"`
+	Icons           []WebManifestIcon  `json:"icons,omitempty"`
+	Shortcuts       []WebManifestEntry `json:"shortcuts,omitempty"`
+}
+
+// WebManifestIcon is an icon within a WebManifest.
+type WebManifestIcon struct {
+	Src     string `json:"src"`
+	Sizes   string `json:"sizes,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Purpose string `json:"purpose,omitempty"`
+}
+
+// WebManifestEntry is a shortcut within a WebManifest.
+type WebManifestEntry struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// WebAppManifestHandler returns a http.HandlerFunc which serves the provided
+// web app manifest, with the "application/manifest+json" Content-Type.
+//
+// For example:
+//
+//	r.Get("/manifest.json", pt.WebAppManifestHandler(&pt.WebManifest{
+//		Name:     "Example",
+//		StartURL: "/",
+//		Display:  "standalone",
+//	}))
+func WebAppManifestHandler(m *WebManifest) http.HandlerFunc {
+	data, err := json.Marshal(m)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/manifest+json")
+		_, _ = w.Write(data)
+	}
+}
+
+// ServiceWorkerConfig is the configuration for ServiceWorkerHandler() and
+// Loader.ServiceWorkerHandler().
+type ServiceWorkerConfig struct {
+	// FS is the filesystem which contains the service worker script.
+	FS fs.FS
+	// Path is the path of the service worker script within FS.
+	Path string
+	// Scope is the maximum scope the service worker is allowed to control,
+	// sent using the Service-Worker-Allowed header. Only needed when the
+	// scope is broader than the path the worker is served from.
+	Scope string
+	// Precache is an optional list of URLs which is made available to the
+	// service worker as "self.__precache", for precaching on install. When
+	// served with Loader.ServiceWorkerHandler(), this defaults to the
+	// versioned URLs of all assets within Config.Assets.
+	Precache []string
+}
+
+// ServiceWorkerHandler returns a http.HandlerFunc which serves a service worker
+// script. Service workers are always served with "Cache-Control: no-cache", to
+// ensure browsers check for updated workers.
+func ServiceWorkerHandler(conf ServiceWorkerConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := fs.ReadFile(conf.FS, conf.Path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		var buf bytes.Buffer
+
+		if len(conf.Precache) > 0 {
+			precache, _ := json.Marshal(conf.Precache)
+
+			buf.WriteString("self.__precache = ")
+			buf.Write(precache)
+			buf.WriteString(";\n")
+		}
+
+		buf.Write(data)
+
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+
+		if conf.Scope != "" {
+			w.Header().Set("Service-Worker-Allowed", conf.Scope)
+		}
+
+		_, _ = buf.WriteTo(w)
+	}
+}
+
+// ServiceWorkerHandler is the same as ServiceWorkerHandler(), however if
+// conf.Precache is empty, it defaults to the URLs of all assets within
+// Config.Assets (see Loader.StaticURL()). As the URLs include the content
+// hash of each asset, the worker script changes whenever an asset does, so
+// browsers install the updated worker. Panics if the assets can't be listed.
+//
+// For example:
+//
+//	r.Get("/sw.js", ld.ServiceWorkerHandler(pt.ServiceWorkerConfig{
+//		FS:   static,
+//		Path: "js/sw.js",
+//	}))
+func (ld *Loader) ServiceWorkerHandler(conf ServiceWorkerConfig) http.HandlerFunc {
+	if assets := ld.conf().Assets; len(conf.Precache) == 0 && assets != nil {
+		paths, err := assets.Paths()
+		if err != nil {
+			panic(err)
+		}
+
+		conf.Precache = make([]string, len(paths))
+		for i, path := range paths {
+			conf.Precache[i] = ld.StaticURL(path)
+		}
+	}
+
+	return ServiceWorkerHandler(conf)
+}
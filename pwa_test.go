@@ -0,0 +1,43 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestServiceWorkerHandlerPrecache(t *testing.T) {
+	static := fstest.MapFS{
+		"sw.js":       {Data: []byte("// worker")},
+		"css/app.css": {Data: []byte("body{}")},
+	}
+
+	assets := NewAssetManifest(static)
+	ld := New("pwa", Config{FS: fstest.MapFS{}, Assets: assets})
+
+	w := httptest.NewRecorder()
+	ld.ServiceWorkerHandler(ServiceWorkerConfig{FS: static, Path: "sw.js"}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sw.js", nil))
+
+	want := `self.__precache = ["/static/css/app.css?v=` + assets.Version("css/app.css") +
+		`","/static/sw.js?v=` + assets.Version("sw.js") + `"];` + "\n// worker"
+	if got := w.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Cache-Control = %q", cc)
+	}
+
+	// An explicit list takes priority.
+	w = httptest.NewRecorder()
+	ld.ServiceWorkerHandler(ServiceWorkerConfig{FS: static, Path: "sw.js", Precache: []string{"/"}}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sw.js", nil))
+
+	if got := w.Body.String(); !strings.HasPrefix(got, `self.__precache = ["/"];`) {
+		t.Errorf("body = %q", got)
+	}
+}
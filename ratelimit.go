@@ -0,0 +1,179 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig is the configuration for RateLimit().
+type RateLimitConfig struct {
+	// Rate is the number of requests per second which are allowed, on
+	// average, per key.
+	Rate float64
+	// Burst is the maximum number of requests which can be made at once, per
+	// key. Defaults to the rate (rounded up), or 1.
+	Burst int
+	// KeyFunc returns the key requests are limited by. Defaults to the IP of
+	// the direct peer, or with Loader.RateLimit(), Loader.RealIP().
+	KeyFunc func(r *http.Request) string
+	// LimitHandler is an optional handler invoked when a request is limited.
+	// By default, a 429 is returned as JSON (when preferred by the client) or
+	// text, or with Loader.RateLimit(), using Template.
+	LimitHandler http.HandlerFunc
+	// Template is the optional error page rendered for limited requests when
+	// using Loader.RateLimit(), through Loader.RespondStatus(), so API clients
+	// still receive JSON. The "error" and "retry_after" (in seconds) ctx keys
+	// are provided.
+	Template string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	conf RateLimitConfig
+
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+// RateLimit returns a token-bucket rate limiting middleware. The standard
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers are set on
+// all responses, and Retry-After on limited responses.
+//
+// For example:
+//
+//	r.Use(pt.RateLimit(pt.RateLimitConfig{Rate: 5, Burst: 20}))
+func RateLimit(conf RateLimitConfig) func(http.Handler) http.Handler {
+	if conf.Rate <= 0 {
+		panic("rate limit must be greater than 0")
+	}
+
+	if conf.Burst <= 0 {
+		conf.Burst = int(math.Max(1, math.Ceil(conf.Rate)))
+	}
+
+	if conf.KeyFunc == nil {
+		conf.KeyFunc = remoteIP
+	}
+
+	if conf.LimitHandler == nil {
+		conf.LimitHandler = defaultLimitHandler
+	}
+
+	rl := &rateLimiter{conf: conf, buckets: make(map[string]*bucket), lastCleanup: time.Now()}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, remaining, reset := rl.take(conf.KeyFunc(r), time.Now())
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(conf.Burst))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(reset))
+
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter(conf)))
+				conf.LimitHandler(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit is the same as RateLimit(), however limited requests are
+// responded to with conf.Template (when provided and no LimitHandler is set),
+// using the same content negotiation as Loader.Respond().
+//
+// For example:
+//
+//	r.Use(ld.RateLimit(pt.RateLimitConfig{Rate: 5, Burst: 20, Template: "errors/429.html"}))
+func (ld *Loader) RateLimit(conf RateLimitConfig) func(http.Handler) http.Handler {
+	if conf.KeyFunc == nil {
+		conf.KeyFunc = ld.RealIP
+	}
+
+	if conf.LimitHandler == nil && conf.Template != "" {
+		conf.LimitHandler = func(w http.ResponseWriter, r *http.Request) {
+			ld.RespondStatus(w, r, http.StatusTooManyRequests, conf.Template, M{
+				"error":       http.StatusText(http.StatusTooManyRequests),
+				"retry_after": retryAfter(conf),
+			})
+		}
+	}
+
+	return RateLimit(conf)
+}
+
+// retryAfter returns the seconds until a token is available again.
+func retryAfter(conf RateLimitConfig) int {
+	return int(math.Ceil(1 / conf.Rate))
+}
+
+// take takes a token from the bucket for key, returning if the request is
+// allowed, the remaining tokens, and the seconds until the bucket is full.
+func (rl *rateLimiter) take(key string, now time.Time) (allowed bool, remaining, reset int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	burst := float64(rl.conf.Burst)
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		rl.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rl.conf.Rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		allowed = true
+	}
+
+	rl.cleanup(now)
+
+	return allowed, int(b.tokens), int(math.Ceil((burst - b.tokens) / rl.conf.Rate))
+}
+
+// cleanup removes buckets which have been refilled completely, at most once
+// per minute. Must be called with the lock held.
+func (rl *rateLimiter) cleanup(now time.Time) {
+	if now.Sub(rl.lastCleanup) < time.Minute {
+		return
+	}
+	rl.lastCleanup = now
+
+	full := time.Duration(float64(rl.conf.Burst) / rl.conf.Rate * float64(time.Second))
+
+	for key, b := range rl.buckets {
+		if now.Sub(b.last) > full {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+func defaultLimitHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+
+	if negotiate(r.Header.Get("Accept"), "text/plain", "application/json") == "application/json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":"` + http.StatusText(http.StatusTooManyRequests) + `"}` + "\n"))
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
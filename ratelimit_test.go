@@ -0,0 +1,89 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoaderRateLimit(t *testing.T) {
+	ld := New("ratelimit", Config{
+		FS: fstest.MapFS{"errors/429.html": {Data: []byte(`<h1>{{ error }}</h1> retry in {{ retry_after }}s`)}},
+	})
+
+	handler := ld.RateLimit(RateLimitConfig{
+		Rate:     1,
+		Burst:    1,
+		KeyFunc:  func(*http.Request) string { return "key" },
+		Template: "errors/429.html",
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		accept string
+		ct     string
+		body   string
+	}{
+		{accept: "", ct: "", body: "ok"},
+		{accept: "text/html", ct: "text/html; charset=utf-8", body: "<h1>Too Many Requests</h1> retry in 1s"},
+		{accept: "application/json", ct: "application/json", body: `{"error":"Too Many Requests","retry_after":1}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tt.accept)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if tt.body == "ok" {
+			if w.Code != http.StatusOK {
+				t.Fatalf("first request status = %d", w.Code)
+			}
+			continue
+		}
+
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("Accept %q: status = %d, want 429", tt.accept, w.Code)
+		}
+		if got := w.Header().Get("Content-Type"); got != tt.ct {
+			t.Errorf("Accept %q: Content-Type = %q, want %q", tt.accept, got, tt.ct)
+		}
+		if got := w.Body.String(); got != tt.body {
+			t.Errorf("Accept %q: body = %q, want %q", tt.accept, got, tt.body)
+		}
+		if w.Header().Get("Retry-After") != "1" {
+			t.Errorf("Accept %q: Retry-After = %q", tt.accept, w.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestRateLimitDefaultHandler(t *testing.T) {
+	handler := RateLimit(RateLimitConfig{
+		Rate:    1,
+		KeyFunc: func(*http.Request) string { return "key" },
+	})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	for accept, ct := range map[string]string{
+		"application/json, text/plain;q=0.5": "application/json",
+		"application/json;q=0, */*":          "text/plain; charset=utf-8",
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", accept)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Content-Type") != ct {
+			t.Errorf("Accept %q: %d %q, want 429 %q", accept, w.Code, w.Header().Get("Content-Type"), ct)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// parseTrustedProxies parses the provided proxies (IPs or CIDR ranges), see
+// Config.TrustedProxies.
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipnet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		nets = append(nets, ipnet)
+	}
+
+	return nets, nil
+}
+
+// isTrustedProxy checks if the provided IP is one of Config.TrustedProxies.
+func (c *Config) isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, ipnet := range c.trustedProxies {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the IP of the direct peer of the request.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// IsTrustedProxy checks if the direct peer of the request is one of
+// Config.TrustedProxies.
+func (ld *Loader) IsTrustedProxy(r *http.Request) bool {
+	return ld.conf().isTrustedProxy(net.ParseIP(remoteIP(r)))
+}
+
+// RealIP returns the IP of the client which made the request. If the direct
+// peer is one of Config.TrustedProxies, the X-Forwarded-For (or Forwarded)
+// header is walked from right to left, skipping trusted proxies, and the
+// first untrusted address is returned. Without trusted proxies, the headers
+// are ignored, as they can be spoofed by clients.
+//
+// This is available within templates as "{{ request.ip }}".
+func (ld *Loader) RealIP(r *http.Request) string {
+	return realIP(ld.conf(), r)
+}
+
+func realIP(conf *Config, r *http.Request) string {
+	ip := remoteIP(r)
+	if !conf.isTrustedProxy(net.ParseIP(ip)) {
+		return ip
+	}
+
+	hops := forwardedFor(r)
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(hops[i])
+		if hop == nil {
+			// Malformed entries can't be trusted to be proxies.
+			return ip
+		}
+
+		ip = hop.String()
+		if !conf.isTrustedProxy(hop) {
+			return ip
+		}
+	}
+
+	return ip
+}
+
+// forwardedFor returns the client addresses from the Forwarded (RFC 7239)
+// header, or the X-Forwarded-For header if not provided, in order.
+func forwardedFor(r *http.Request) []string {
+	var hops []string
+
+	for _, header := range r.Header.Values("Forwarded") {
+		for _, elem := range strings.Split(header, ",") {
+			for _, pair := range strings.Split(elem, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) != 2 || !strings.EqualFold(kv[0], "for") {
+					continue
+				}
+
+				hops = append(hops, cleanForwardedHost(kv[1]))
+			}
+		}
+	}
+
+	if len(hops) > 0 {
+		return hops
+	}
+
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	return hops
+}
+
+// cleanForwardedHost strips quotes, brackets and ports from a Forwarded "for"
+// value (e.g. `"[2001:db8::1]:4711"`).
+func cleanForwardedHost(value string) string {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		return host
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+}
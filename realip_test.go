@@ -0,0 +1,80 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRealIP(t *testing.T) {
+	trusting := New("realip", Config{FS: fstest.MapFS{}, TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1"}})
+	untrusting := New("realip-none", Config{FS: fstest.MapFS{}})
+
+	tests := []struct {
+		name   string
+		remote string
+		header string
+		value  string
+		want   string
+	}{
+		{name: "untrusted peer", remote: "203.0.113.1:1234", header: "X-Forwarded-For", value: "198.51.100.1", want: "203.0.113.1"},
+		{name: "trusted peer", remote: "10.0.0.1:1234", header: "X-Forwarded-For", value: "198.51.100.1", want: "198.51.100.1"},
+		{name: "trusted chain", remote: "10.0.0.1:1234", header: "X-Forwarded-For", value: "198.51.100.1, 192.0.2.1, 10.1.1.1", want: "198.51.100.1"},
+		{name: "spoofed prefix", remote: "10.0.0.1:1234", header: "X-Forwarded-For", value: "1.1.1.1, 198.51.100.1", want: "198.51.100.1"},
+		{name: "forwarded", remote: "192.0.2.1:1234", header: "Forwarded", value: `for="[2001:db8::1]:4711"`, want: "2001:db8::1"},
+		{name: "malformed", remote: "10.0.0.1:1234", header: "X-Forwarded-For", value: "nope", want: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remote
+		r.Header.Set(tt.header, tt.value)
+
+		if got := trusting.RealIP(r); got != tt.want {
+			t.Errorf("%s: RealIP() = %q, want %q", tt.name, got, tt.want)
+		}
+
+		if got, want := untrusting.RealIP(r), remoteIP(r); got != want {
+			t.Errorf("%s: RealIP() without trusted proxies = %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestTrustedProxiesPerLoader(t *testing.T) {
+	fsys := fstest.MapFS{"index.html": {Data: []byte(`{{ request.ip }}`)}}
+
+	a := New("realip-a", Config{FS: fsys, TrustedProxies: []string{"10.0.0.1"}})
+	b := New("realip-b", Config{FS: fsys})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	for ld, want := range map[*Loader]string{a: "198.51.100.1", b: "10.0.0.1"} {
+		w := httptest.NewRecorder()
+		ld.Render(w, r, "index.html", nil)
+
+		if got := w.Body.String(); got != want {
+			t.Errorf("request.ip = %q, want %q", got, want)
+		}
+	}
+
+	b.UpdateConfig(func(c *Config) { c.TrustedProxies = []string{"10.0.0.0/8"} })
+	if got := b.RealIP(r); got != "198.51.100.1" {
+		t.Errorf("RealIP() after UpdateConfig() = %q", got)
+	}
+}
+
+func TestTrustedProxiesInvalid(t *testing.T) {
+	conf := Config{FS: fstest.MapFS{}, TrustedProxies: []string{"10.0.0.0/33"}}
+
+	if err := conf.Validate(); err == nil || !strings.Contains(err.Error(), "TrustedProxies") {
+		t.Errorf("Validate() = %v, want a TrustedProxies error", err)
+	}
+}
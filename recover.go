@@ -0,0 +1,83 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// RegisterFilter registers a pongo2 filter, the same as pongo2.RegisterFilter(),
+// however panics within the filter are converted into template errors, which
+// include the filter name and the template path and line. These are handled
+// in the same way as other template errors (see Config.OnError), rather than
+// crashing the request.
+func RegisterFilter(name string, fn pongo2.FilterFunction) error {
+	return pongo2.RegisterFilter(name, recoverFilter(name, fn))
+}
+
+// RegisterTag registers a pongo2 tag, the same as pongo2.RegisterTag(),
+// however panics while executing the tag are converted into template errors,
+// which include the tag name and the template path and line. See
+// RegisterFilter().
+func RegisterTag(name string, parser pongo2.TagParser) error {
+	return pongo2.RegisterTag(name, recoverTag(name, parser))
+}
+
+// recoverFilter wraps fn, converting panics into errors. pongo2 adds the
+// template path and line to errors returned from filters.
+func recoverFilter(name string, fn pongo2.FilterFunction) pongo2.FilterFunction {
+	return func(in, param *pongo2.Value) (out *pongo2.Value, err *pongo2.Error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				out, err = nil, &pongo2.Error{
+					Sender:    "filter:" + name,
+					OrigError: fmt.Errorf("panic: %v", rec),
+				}
+			}
+		}()
+
+		return fn(in, param)
+	}
+}
+
+// recoverTag wraps the nodes returned by parser, converting panics during
+// execution into errors.
+func recoverTag(name string, parser pongo2.TagParser) pongo2.TagParser {
+	return func(doc *pongo2.Parser, start *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+		node, err := parser(doc, start, arguments)
+		if err != nil {
+			return nil, err
+		}
+
+		return &recoverNode{name: name, start: start, node: node}, nil
+	}
+}
+
+type recoverNode struct {
+	name  string
+	start *pongo2.Token
+	node  pongo2.INodeTag
+}
+
+func (node *recoverNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) (err *pongo2.Error) {
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+
+		// Canceled renders unwind through templates using a panic, see
+		// executeCancelable().
+		if _, ok := rec.(renderCanceled); ok {
+			panic(rec)
+		}
+
+		err = ctx.Error(fmt.Sprintf("panic in %s tag: %v", node.name, rec), node.start)
+	}()
+
+	return node.node.Execute(ctx, writer)
+}
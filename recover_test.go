@@ -0,0 +1,71 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+type panicNode struct{}
+
+func (panicNode) Execute(*pongo2.ExecutionContext, pongo2.TemplateWriter) *pongo2.Error {
+	panic("tag exploded")
+}
+
+// Filters and tags are registered globally, so only once per process (e.g.
+// with -count).
+var registerPanics sync.Once
+
+func TestRecoverPanics(t *testing.T) {
+	registerPanics.Do(func() {
+		err := RegisterFilter("test_panic", func(*pongo2.Value, *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+			panic("filter exploded")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = RegisterTag("test_panic", func(*pongo2.Parser, *pongo2.Token, *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+			return panicNode{}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ld := New("recover", Config{
+		FS: fstest.MapFS{
+			"filter.html": {Data: []byte("line 1\n{{ 1|test_panic }}")},
+			"tag.html":    {Data: []byte("{% test_panic %}")},
+		},
+	})
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"filter.html", []string{"filter:test_panic", "panic: filter exploded", "filter.html", "Line 2"}},
+		{"tag.html", []string{"panic in test_panic tag: tag exploded", "tag.html"}},
+	}
+
+	for _, tt := range tests {
+		_, err := ld.RenderBytes(tt.path, nil)
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.path)
+			continue
+		}
+
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: error %q doesn't contain %q", tt.path, err, want)
+			}
+		}
+	}
+}
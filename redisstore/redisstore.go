@@ -0,0 +1,104 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+// Package redisstore provides a pt.CacheStore backed by Redis (or any
+// compatible server), allowing page and fragment caches to be shared across
+// replicas. It depends on a minimal client interface rather than a specific
+// Redis library.
+//
+// For example, with go-redis:
+//
+//	type client struct{ *redis.Client }
+//
+//	func (c client) Get(ctx context.Context, key string) ([]byte, error) {
+//		b, err := c.Client.Get(ctx, key).Bytes()
+//		if errors.Is(err, redis.Nil) {
+//			return nil, nil
+//		}
+//		return b, err
+//	}
+//
+//	func (c client) Set(ctx context.Context, key string, value []byte) error {
+//		return c.Client.Set(ctx, key, value, 0).Err()
+//	}
+//
+//	func (c client) Del(ctx context.Context, key string) error {
+//		return c.Client.Del(ctx, key).Err()
+//	}
+//
+//	func (c client) Expire(ctx context.Context, key string, ttl time.Duration) error {
+//		return c.Client.Expire(ctx, key, ttl).Err()
+//	}
+//
+//	ld := pt.New("", pt.Config{
+//		FS:         templates,
+//		CacheStore: redisstore.New(client{rdb}, "myapp:"),
+//	})
+package redisstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/lrstanley/pt"
+)
+
+// Client is the minimal subset of Redis commands used by Store.
+type Client interface {
+	// Get returns the value of key, or nil (with no error) if the key doesn't
+	// exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set sets the value of key, without an expiry.
+	Set(ctx context.Context, key string, value []byte) error
+	// Del deletes key.
+	Del(ctx context.Context, key string) error
+	// Expire sets the expiry of key.
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+}
+
+// Store is a pt.CacheStore backed by a Redis client.
+type Store struct {
+	client Client
+	prefix string
+}
+
+var _ pt.CacheStore = (*Store)(nil)
+
+// New returns a new Store using the provided client. prefix is prepended to
+// all keys (e.g. "myapp:"), which allows multiple applications to share the
+// same server.
+func New(client Client, prefix string) *Store {
+	return &Store{client: client, prefix: prefix}
+}
+
+// Get implements pt.CacheStore.
+func (s *Store) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, s.prefix+key)
+	if err != nil || value == nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set implements pt.CacheStore. If the expiry can't be set, the key is
+// deleted, so entries never outlive their ttl.
+func (s *Store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	key = s.prefix + key
+
+	if err := s.client.Set(ctx, key, value); err != nil {
+		return err
+	}
+
+	if err := s.client.Expire(ctx, key, ttl); err != nil {
+		_ = s.client.Del(ctx, key)
+		return err
+	}
+
+	return nil
+}
+
+// Delete implements pt.CacheStore.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key)
+}
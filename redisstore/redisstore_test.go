@@ -0,0 +1,112 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/lrstanley/pt"
+)
+
+// memClient is an in-memory Client, which records expiries.
+type memClient struct {
+	values    map[string][]byte
+	ttls      map[string]time.Duration
+	expireErr error
+}
+
+func newMemClient() *memClient {
+	return &memClient{values: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (c *memClient) Get(_ context.Context, key string) ([]byte, error) {
+	return c.values[key], nil
+}
+
+func (c *memClient) Set(_ context.Context, key string, value []byte) error {
+	c.values[key] = value
+	return nil
+}
+
+func (c *memClient) Del(_ context.Context, key string) error {
+	delete(c.values, key)
+	delete(c.ttls, key)
+	return nil
+}
+
+func (c *memClient) Expire(_ context.Context, key string, ttl time.Duration) error {
+	if c.expireErr != nil {
+		return c.expireErr
+	}
+	c.ttls[key] = ttl
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	client := newMemClient()
+	s := New(client, "app:")
+
+	if _, ok, err := s.Get(ctx, "a"); ok || err != nil {
+		t.Errorf("Get() of missing key = %v, %v", ok, err)
+	}
+
+	if err := s.Set(ctx, "a", []byte("value"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if client.ttls["app:a"] != time.Minute {
+		t.Errorf("ttl = %v, want 1m", client.ttls["app:a"])
+	}
+
+	if value, ok, err := s.Get(ctx, "a"); !ok || err != nil || string(value) != "value" {
+		t.Errorf("Get() = %q, %v, %v", value, ok, err)
+	}
+
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.values["app:a"]; ok {
+		t.Error("key not deleted")
+	}
+}
+
+func TestStoreExpireError(t *testing.T) {
+	client := newMemClient()
+	client.expireErr = errors.New("expire failed")
+
+	if err := New(client, "").Set(context.Background(), "a", []byte("value"), time.Minute); !errors.Is(err, client.expireErr) {
+		t.Errorf("Set() = %v, want the expire error", err)
+	}
+	if _, ok := client.values["a"]; ok {
+		t.Error("key without an expiry wasn't deleted")
+	}
+}
+
+func TestStoreCacheTag(t *testing.T) {
+	client := newMemClient()
+
+	ld := pt.New("redisstore", pt.Config{
+		FS:         fstest.MapFS{"index.html": {Data: []byte(`{% cache "sidebar" 60 %}{{ n }}{% endcache %}`)}},
+		CacheStore: New(client, "app:"),
+	})
+
+	for i, want := range []string{"1", "1"} {
+		out, err := ld.RenderBytes("index.html", pt.M{"n": i + 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != want {
+			t.Errorf("render %d = %q, want %q", i, out, want)
+		}
+	}
+
+	if len(client.values) != 1 {
+		t.Errorf("store contains %d values, want 1", len(client.values))
+	}
+}
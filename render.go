@@ -6,15 +6,18 @@ package pt
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"mime"
+	"net"
 	"net/http"
-	"os"
+	"path"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/flosch/pongo2/v6"
@@ -30,20 +33,25 @@ func New(set string, conf Config) *Loader {
 		panic("no loader provided")
 	}
 
-	if conf.ErrorLogger == nil {
-		conf.ErrorLogger = io.Discard
-	}
+	provided := conf
+	conf.setDefaults()
 
-	var fileServer pongo2.TemplateLoader
-	if conf.Loader != nil {
-		fileServer = &memLoader{loaderFunc: conf.Loader}
-	} else {
-		fileServer = pongo2.NewFSLoader(conf.FS)
-	}
+	fileServer := newTemplateLoader(&conf)
 
 	ld := &Loader{
-		fs: pongo2.NewSet(set, fileServer),
-		ts: time.Now(), conf: &conf,
+		dataFS:    pongo2.NewSet(set+"-data", rawLoader{fileServer}),
+		loader:    fileServer,
+		cache:     newTemplateCache(),
+		dataCache: newTemplateCache(),
+		ts:        time.Now(),
+		provided:  provided,
+	}
+	ld.fs = pongo2.NewSet(set, layoutLoader{fileServer, ld})
+	ld.config.Store(&conf)
+
+	if conf.Watch && conf.FS != nil && (conf.Loader == nil || conf.Precedence != PrecedenceUnset) {
+		ld.watchStop = make(chan struct{})
+		go ld.watch(conf.FS, scanFS(conf.FS), conf.WatchInterval)
 	}
 
 	return ld
@@ -56,36 +64,733 @@ type Config struct {
 	// while the application is running (or when you are using ricebox or
 	// similar.)
 	CacheParsed bool
+	// CacheTTL is the optional duration parsed templates are cached for when
+	// CacheParsed is enabled, after which they are transparently re-read
+	// from the loader and parsed again on their next use. Templates are
+	// cached until Loader.Invalidate() is called if not provided.
+	CacheTTL time.Duration
+	// CacheMaxEntries is the optional maximum number of parsed templates
+	// cached when CacheParsed is enabled, after which the least recently used
+	// templates are evicted. Unbounded if not provided.
+	CacheMaxEntries int
+	// CacheMaxBytes is the optional maximum total size of parsed templates
+	// cached when CacheParsed is enabled, after which the least recently used
+	// templates are evicted. The size of each template is approximated using
+	// the size of its source (excluding the templates it references).
+	// Unbounded if not provided.
+	CacheMaxBytes int
+	// Watch polls Config.FS for changes every WatchInterval, invalidating the
+	// changed templates (and the templates which reference them) from the
+	// parse cache, so CacheParsed can stay enabled during development. Only
+	// Config.FS is watched (Config.Loader functions can't be), and hidden
+	// directories (e.g. ".git") are skipped. Use Loader.Close() to stop
+	// watching.
+	//
+	// Polling is used rather than filesystem notifications, as Config.FS can
+	// be any fs.FS (e.g. ChainFS(), or an fs.Sub() of os.DirFS()), without
+	// adding a dependency for a development-only feature.
+	Watch bool
+	// WatchInterval is the interval at which Config.FS is polled for changes
+	// when Watch is enabled. Defaults to 1 second.
+	WatchInterval time.Duration
+	// LiveReload injects a script at the end of the "<body>" of HTML pages
+	// rendered with Render(), RenderE() and RenderStatus(), which reloads the
+	// page when Watch detects template changes. Loader.LiveReloadHandler()
+	// must be mounted at LiveReloadPath. Only intended for development.
+	LiveReload bool
+	// LiveReloadPath is the path Loader.LiveReloadHandler() is mounted at.
+	// Defaults to "/_pt/livereload".
+	LiveReloadPath string
 	// Loader is the template loader to use to load a template. This can
 	// be some kind of filesystem loader, or a assetfs/memory-based loader
 	// (re: go-ricebox).
 	//
 	// For example:
 	//   rice.MustFindBox("static").Bytes
+	//
+	// Missing templates must be reported with an error wrapping
+	// ErrTemplateNotFound or fs.ErrNotExist. Only these errors trigger
+	// Config.NotFoundHandler, and fall back to FS (see Precedence). Other
+	// errors (e.g. an unavailable backend) fail the render, rather than
+	// falling back.
 	Loader func(path string) ([]byte, error)
-	FS     fs.FS
+	// FS is the filesystem templates are loaded from, if Loader isn't
+	// provided. Use ChainFS() to load from multiple filesystems (e.g.
+	// overrides on disk, with embedded defaults).
+	FS fs.FS
+	// Precedence declares which of Loader and FS is used first when both are
+	// provided, with the other used for templates the first doesn't have.
+	// Without it, only Loader is used and a warning is logged.
+	Precedence LoaderPrecedence
+	// TemplateExts are the file extensions of templates within FS, used by
+	// Loader.ParseAll() and RegisterPages() to skip other files (e.g. static
+	// assets, or data templates). Defaults to ".html" and ".tmpl".
+	TemplateExts []string
 	// DefaultCtx is an optional function which you can supply, which is
 	// called every time the Render() function is called, which allows you
 	// to add additional context variables to the ctx map. Useful if you are
 	// adding variables to multiple handlers frequently.
 	DefaultCtx func(http.ResponseWriter, *http.Request) (ctx map[string]interface{})
+	// DefaultCtxE is the same as DefaultCtx, however it can return an error
+	// (e.g. if the session store is unavailable), which aborts the render. If
+	// both are provided, DefaultCtxE is called after DefaultCtx, and its
+	// values take priority. See also Loader.AddCtxProvider().
+	DefaultCtxE func(http.ResponseWriter, *http.Request) (ctx map[string]interface{}, err error)
 	// NotFoundHandler is an optional handler which you can define when the
 	// template cannot be found based on what's returned from the Loader
 	// method. If this is not defined, the Render() function will panic, as
-	// this indicates the use of an undefined template.
+	// this indicates the use of an undefined template. Loaders signal that a
+	// template doesn't exist by returning an error wrapping either
+	// ErrTemplateNotFound or fs.ErrNotExist.
 	NotFoundHandler http.HandlerFunc
 	// ErrorLogger is an optional io.Writer which errors are written to. Note
 	// that these are request-specific errors (e.g. error while writing to the
 	// client). Almost all template execution errors will cause a panic.
+	// Messages of all levels are written, see ErrorSinks for filtering.
 	ErrorLogger io.Writer
+	// ErrorSinks are additional destinations for errors (in addition to the
+	// ErrorLogger), each with their own minimum level. For example, to write
+	// errors to stderr, and forward warnings and errors to a callback:
+	//
+	//	ErrorSinks: []pt.ErrorSink{
+	//		{MinLevel: pt.LevelError, Writer: os.Stderr},
+	//		{MinLevel: pt.LevelWarn, Func: func(level pt.LogLevel, msg string) {
+	//			sentry.CaptureMessage(msg)
+	//		}},
+	//	}
+	ErrorSinks []ErrorSink
+	// DetectDevice enables User-Agent based device classification (see
+	// DetectDevice()), which is exposed as the "device" ctx key (e.g.
+	// "{{ device.mobile }}" or "{{ device.bot }}").
+	DetectDevice bool
+	// DeviceTemplates, when used with DetectDevice, will prefer a device
+	// specific variant of the requested template if one exists. For example,
+	// "index.html" will be rendered as "index.mobile.html" for mobile clients,
+	// or "index.bot.html" for bots. See Device.Class() for the class names.
+	DeviceTemplates bool
+	// PrintSelector is an optional function which returns true if the request
+	// should be rendered in print mode (see PrintQuery()), for pages like
+	// invoices and reports which need a distinct print layout. In print mode,
+	// a "print" variant of the requested template is preferred if one exists
+	// (e.g. "invoice.print.html" for "invoice.html"), and the "print_mode" ctx
+	// key is true.
+	PrintSelector func(r *http.Request) bool
+	// Debug enables additional (and slower) checks which are useful during
+	// development, like validation of the render ctx against schemas
+	// registered with Loader.SetSchema().
+	Debug bool
+	// AuditSafe is a debug option which logs (to ErrorLogger, as warnings)
+	// the raw-HTML sinks of every template that is rendered, as found by
+	// Loader.AuditSafe(), once per template. Every value which then reaches
+	// the output unescaped through a "{{ }}" sink is also logged on each
+	// render, along with the template path and line. Templates are
+	// instrumented when parsed, so enabling this with UpdateConfig() only
+	// affects templates which haven't been cached yet.
+	AuditSafe bool
+	// StaticBaseURL is the base URL used when generating static asset URLs, via
+	// the "static" tag (e.g. "{% static "css/app.css" %}") and
+	// Loader.StaticURL(). This can be a path (e.g. "/static"), or a full URL
+	// (e.g. "https://cdn.example.com/static"), which allows switching between
+	// serving assets locally and from a CDN without template changes. Defaults
+	// to "/static".
+	StaticBaseURL string
+	// Favicons is the optional set of icons registered with Favicons(), used
+	// to generate the "<link>" tags via the "favicons" tag.
+	Favicons *FaviconSet
+	// Profile enables recording of execution time, bytes written and
+	// allocations for each rendered template, and each "{% profile "name" %}"
+	// section within templates. Profiles are available via Loader.Stats() and
+	// the DebugHandler(). Recording allocations is expensive, so this should
+	// only be used temporarily.
+	Profile bool
+	// UsageSampleRate is the fraction (between 0 and 1) of renders whose
+	// template usage is recorded: the rendered templates and blocks, and the
+	// templates they include, extend or import. Usage is available via
+	// Loader.Stats(), the DebugHandler() and Loader.Unused(), which helps
+	// find templates which can be deleted. Disabled if not provided.
+	UsageSampleRate float64
+	// DeepMergeCtx merges nested maps (map[string]interface{}, M or
+	// pongo2.Context) when combining the default ctx (DefaultCtx, DefaultCtxE
+	// and ctx providers) and the ctx provided to Render(), rather than the
+	// later ctx replacing the key entirely. For example,
+	// with DefaultCtx returning {"meta": {"title": "x", "lang": "en"}}, and
+	// Render() called with {"meta": {"title": "y"}}, the result will be
+	// {"meta": {"title": "y", "lang": "en"}}.
+	DeepMergeCtx bool
+	// Assets is an optional asset manifest, which provides per-file content
+	// hashes for static assets. When provided, the "static" tag and
+	// Loader.StaticURL() will include the version of the asset (e.g.
+	// "/static/css/app.css?v=2c26b46b68ff"), and the "asset_version" ctx
+	// function will return the hash of the asset.
+	Assets *AssetManifest
+	// StaticFS is the optional filesystem static assets are served from,
+	// which is used to read assets for the "inline_asset" tag. Defaults to
+	// the filesystem of Assets, if provided.
+	StaticFS fs.FS
+	// InlineAssetMaxSize is the maximum size of assets which are inlined by
+	// the "inline_asset" tag. Defaults to 8KiB.
+	InlineAssetMaxSize int
+	// BaseURL is the optional base URL (e.g. "https://example.com") used when
+	// generating absolute URLs, see Loader.AbsoluteURL(). If not provided, the
+	// scheme and host of the request are used.
+	BaseURL string
+	// TrustForwardedHeaders allows the X-Forwarded-Proto and X-Forwarded-Host
+	// headers to be used when generating absolute URLs without a BaseURL. Only
+	// enable this when behind a proxy which sets (or strips) these headers, as
+	// they can otherwise be spoofed by clients.
+	TrustForwardedHeaders bool
+	// TrustedProxies are the proxies (IPs or CIDR ranges, e.g. "10.0.0.0/8")
+	// which are trusted to provide the client IP through the X-Forwarded-For
+	// and Forwarded headers (see Loader.RealIP()), and the scheme and host
+	// through the X-Forwarded-Proto and X-Forwarded-Host headers (see
+	// Loader.AbsoluteURL()). Invalid entries are logged and ignored, see also
+	// Config.Validate().
+	TrustedProxies []string
+	// ThemeSelector is an optional function which returns the name of the
+	// theme (registered with Loader.RegisterTheme()) to use for the request.
+	// An empty string, or an unknown theme, uses the default templates. The
+	// selected theme is available as the "theme" ctx key (e.g.
+	// "{{ theme.name }}").
+	ThemeSelector func(r *http.Request) string
+	// Features is an optional source of feature flags, which are evaluated for
+	// each request, and exposed as the "flags" ctx key (e.g.
+	// "{{ flags.new_nav }}"), and through the "feature" tag:
+	//
+	//	{% feature "new_nav" %}...{% else %}...{% endfeature %}
+	Features FeatureSource
+	// PrincipalFromRequest is an optional function which returns the
+	// authenticated principal (e.g. user) of the request, or nil if the
+	// request isn't authenticated. The result is exposed as the "user" ctx
+	// key, and used by Loader.Principal() and Loader.RequireAuth().
+	PrincipalFromRequest func(r *http.Request) interface{}
+	// LoginURL is the optional URL of the login page, which unauthenticated
+	// requests are redirected to (with the requested URL as the NextKey query
+	// param) by Loader.Unauthorized() and Loader.RequireAuth().
+	LoginURL string
+	// UnsupportedClient is an optional matcher which reports if the client of
+	// the request is unsupported (e.g. LegacyClient()), in which case
+	// Loader.RequireSupportedClient() renders UnsupportedTemplate instead.
+	UnsupportedClient func(r *http.Request) bool
+	// UnsupportedTemplate is the template rendered for unsupported clients,
+	// see UnsupportedClient.
+	UnsupportedTemplate string
+	// UnsupportedStatus is the status code sent with UnsupportedTemplate
+	// (e.g. http.StatusNotAcceptable). Defaults to 200.
+	UnsupportedStatus int
+	// Locales are the optional supported locales (e.g. "en" and "de"), used
+	// as URL path prefixes by Loader.LocalePrefix() and the "url" tag. The
+	// locale of the request is exposed as the "locale" ctx key.
+	Locales []string
+	// DefaultLocale is the locale of requests without a locale prefix, which
+	// is never prefixed. Defaults to the first of Locales.
+	DefaultLocale string
+	// Authorizer is an optional authorizer used by the "can" tag (and
+	// Loader.Can()), so permission checks within templates use the same
+	// policy as handlers:
+	//
+	//	{% can "posts:edit" post %}...{% else %}...{% endcan %}
+	Authorizer Authorizer
+	// CtxKeys are request context keys (e.g. populated by middleware), whose
+	// values are copied into the ctx of every render, so they don't each need
+	// to be added through DefaultCtx. The ctx name is the key itself for
+	// string keys (including custom string types), or the result of String()
+	// for keys implementing fmt.Stringer. Other keys, and keys without a value
+	// in the request context, are ignored. These have the same priority as
+	// globals, see Render().
+	//
+	// For example:
+	//
+	//	type ctxKey string
+	//
+	//	const userKey ctxKey = "user"
+	//
+	//	// Within middleware:
+	//	r = r.WithContext(context.WithValue(r.Context(), userKey, user))
+	//
+	//	// Config:
+	//	CtxKeys: []interface{}{userKey}, // {{ user }}
+	CtxKeys []interface{}
+	// NoIndex prevents search engines from indexing all rendered templates
+	// (e.g. for staging or preview deployments), by setting the
+	// "X-Robots-Tag: noindex, nofollow" header. The "robots" ctx key is set
+	// to "noindex, nofollow" for these renders (otherwise "index, follow"),
+	// for use within a robots meta tag:
+	//
+	//	<meta name="robots" content="{{ robots }}">
+	NoIndex bool
+	// NoIndexPaths are template path patterns (see path.Match(), e.g.
+	// "admin/*") which are treated as if NoIndex was enabled.
+	NoIndexPaths []string
+	// NoAutoescapePaths are template path patterns (see path.Match(), e.g.
+	// "*.txt"), or directory prefixes ending in "/" (e.g. "emails/text/"),
+	// for templates which are rendered without HTML autoescaping, such as
+	// plain-text emails. All other templates are escaped. This applies to the
+	// template itself, regardless of which template includes or extends it.
+	// Templates are parsed with the policy active at the time, so a change
+	// only affects templates which haven't been cached yet.
+	NoAutoescapePaths []string
+	// DefaultLayout is an optional layout template path (e.g.
+	// "layouts/base.html"), which all templates rendered with Render() (and
+	// similar) are wrapped in, so templates don't need their own
+	// "{% extends %}" boilerplate. The template is executed as the
+	// DefaultLayoutBlock block of the layout. Templates which need to
+	// override other blocks of the layout (e.g. "{% block title %}") can
+	// define the DefaultLayoutBlock block themselves, in which case only the
+	// blocks are used, as with "{% extends %}". Templates which extend
+	// another template, and the layout itself, are rendered as-is.
+	DefaultLayout string
+	// DefaultLayoutBlock is the name of the block within the DefaultLayout
+	// which templates are executed as. Defaults to "content".
+	DefaultLayoutBlock string
+	// CacheStore is the store used for cached pages and fragments (see
+	// Loader.Cached(), Loader.RenderCached() and the "cache" tag). Defaults
+	// to an in-memory store.
+	CacheStore CacheStore
+	// CacheStale is how long expired cache entries can be served for, while
+	// a single request re-renders them. If not provided, requests wait for
+	// the re-render instead.
+	CacheStale time.Duration
+	// CacheVary are ctx keys (e.g. "user_id", "locale" or "theme") whose
+	// values are included in the key of every "cache" tag fragment, so
+	// fragments which depend on them are cached separately, and never
+	// served to another user. See also the "vary" argument of the "cache"
+	// tag.
+	CacheVary []string
+	// CacheKeySecret is used to sign fragment cache keys which include vary
+	// values (HMAC-SHA256), so values like user IDs aren't exposed to the
+	// CacheStore, and keys can't be guessed. If not provided, keys are
+	// hashed with SHA256 instead.
+	CacheKeySecret []byte
+	// StreamOutput writes the output of templates directly to the
+	// http.ResponseWriter, flushing it every StreamFlushSize bytes, rather
+	// than buffering the entire output. This improves time-to-first-byte for
+	// large templates, however an execution error results in a partial
+	// response (so RenderE() can't guarantee that nothing was written).
+	// Renders which must be buffered (e.g. RenderStatus() with a status code
+	// other than 200, or cached renders) are unaffected.
+	StreamOutput bool
+	// StreamFlushSize is the number of bytes written between flushes when
+	// StreamOutput is enabled. Defaults to 16KiB.
+	StreamFlushSize int
+	// FallbackOnError always renders templates into a buffer (ignoring
+	// StreamOutput), and when a template fails to render with Render() or
+	// RenderStatus(), logs the error and responds with a 500 using the
+	// ErrorTemplate (or a plain text error if not provided), rather than
+	// panicking. This ensures clients never receive partial output.
+	FallbackOnError bool
+	// ErrorTemplate is the optional template path rendered (with a 500
+	// status) when a render with Render() or RenderStatus() fails. Providing
+	// an ErrorTemplate enables FallbackOnError. When Debug is enabled, the
+	// error message is provided as the "error" ctx key.
+	ErrorTemplate string
+	// BeforeRender are hooks which are called (in order) for each render,
+	// once the ctx has been built, and before the template is executed. Hooks
+	// can modify the ctx.
+	BeforeRender []BeforeRenderHook
+	// AfterRender are hooks which are called (in order) once a render with
+	// Render(), RenderE() or RenderStatus() has completed, including when it
+	// failed. This is useful for timing and auditing.
+	AfterRender []AfterRenderHook
+	// OnCancel is an optional hook which is called when a render is aborted
+	// because the request context was canceled (e.g. the client
+	// disconnected), which can be used to clean up resources acquired for
+	// the render. See ErrRenderCanceled.
+	OnCancel func(r *http.Request, path string, err error)
+	// ConditionalGET buffers the output of Render() and RenderE() for GET and
+	// HEAD requests, setting a weak ETag (a hash of the uncompressed output,
+	// so it is shared by all content encodings) and a Last-Modified header,
+	// and responding with 304 Not Modified when the request has a matching
+	// If-None-Match or If-Modified-Since header. Last-Modified is
+	// the modification time of the template within FS, or the time the
+	// loader was created (see the "cachets" ctx key) if unavailable, so it
+	// doesn't reflect changes to data. As clients prefer the ETag when both
+	// are sent, this is only an issue for clients which only send
+	// If-Modified-Since.
+	ConditionalGET bool
+	// OnError is an optional handler which is called when a template can't
+	// be parsed or executed, rather than panicking (see Render()). This takes
+	// priority over FallbackOnError. Renders which write output before the
+	// template has finished executing (e.g. RenderStream(), RenderMulti() or
+	// StreamOutput) may have already written a partial response.
+	//
+	// For example:
+	//
+	//	OnError: func(w http.ResponseWriter, r *http.Request, err error) {
+	//		logger.Printf("render error: %v", err)
+	//		http.Error(w, "something went wrong", http.StatusInternalServerError)
+	//	},
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+	// MaxIncludeDepth is the maximum depth of nested includes, extends and
+	// imports, which is checked (along with include cycles) before a
+	// template is parsed, as these would otherwise overflow the stack. Only
+	// templates referenced using string literals are checked. Defaults to 32.
+	MaxIncludeDepth int
+	// Compress gzip compresses the output of Render(), RenderE(),
+	// RenderStatus() and Respond(), when the client supports it, and the
+	// output is at least CompressMinSize bytes. See Compress() for a
+	// middleware which can be used for other responses.
+	Compress bool
+	// CompressMinSize is the minimum size of output which is compressed.
+	// Defaults to 1024 bytes.
+	CompressMinSize int
+	// ContentType is the Content-Type header sent with rendered templates.
+	// Defaults to "text/html; charset=utf-8". This can be overridden per
+	// template using header presets (see Loader.SetHeaders()), or per render
+	// using the ContentTypeKey ctx key.
+	ContentType string
+	// StrictVars logs (as a warning) every variable referenced by a rendered
+	// template (or the templates it includes, extends or imports) which isn't
+	// within the ctx, including the template path and line, rather than
+	// silently rendering it as empty. This catches typos in variable names
+	// during development and testing. Templates are scanned on every render,
+	// so this shouldn't be enabled in production.
+	StrictVars bool
+	// SlowRenderThreshold is the optional duration after which renders with
+	// Render(), RenderE() and RenderStatus() are considered slow. Slow renders
+	// are logged (as a warning) with the template path, and the time spent
+	// preparing the render (loading the template and building the ctx),
+	// building the ctx, and executing the template. The number of slow
+	// renders is available via Loader.Stats().
+	SlowRenderThreshold time.Duration
+
+	// trustedProxies are the parsed TrustedProxies, see setDefaults().
+	trustedProxies []*net.IPNet
+}
+
+// BeforeRenderHook is called before a template is executed, see
+// Config.BeforeRender.
+type BeforeRenderHook func(r *http.Request, path string, ctx map[string]interface{})
+
+// AfterRenderHook is called once a render has completed, see
+// Config.AfterRender. ctx is nil if the render failed before the ctx was
+// built (e.g. the template wasn't found), and err is nil on success.
+type AfterRenderHook func(r *http.Request, path string, ctx map[string]interface{}, elapsed time.Duration, err error)
+
+// setDefaults sets the default values for unset fields.
+func (c *Config) setDefaults() {
+	if c.ErrorLogger == nil {
+		c.ErrorLogger = io.Discard
+	}
+
+	if c.StaticBaseURL == "" {
+		c.StaticBaseURL = "/static"
+	}
+
+	if c.ErrorTemplate != "" {
+		c.FallbackOnError = true
+	}
+
+	if c.DefaultLayoutBlock == "" {
+		c.DefaultLayoutBlock = "content"
+	}
+
+	if nets, err := parseTrustedProxies(c.TrustedProxies); err == nil {
+		c.trustedProxies = nets
+	} else {
+		c.logf(LevelWarn, "%v, ignoring Config.TrustedProxies", err)
+		c.trustedProxies = nil
+	}
+
+	if len(c.TemplateExts) == 0 {
+		c.TemplateExts = []string{".html", ".tmpl"}
+	}
+
+	if c.CacheStore == nil {
+		c.CacheStore = NewMemoryCacheStore()
+	}
+
+	if c.StreamFlushSize <= 0 {
+		c.StreamFlushSize = 16 << 10
+	}
+
+	if c.MaxIncludeDepth <= 0 {
+		c.MaxIncludeDepth = 32
+	}
+
+	if c.CompressMinSize <= 0 {
+		c.CompressMinSize = 1024
+	}
+
+	if c.InlineAssetMaxSize <= 0 {
+		c.InlineAssetMaxSize = 8 << 10
+	}
+
+	if c.WatchInterval <= 0 {
+		c.WatchInterval = time.Second
+	}
+
+	if c.UnsupportedStatus == 0 {
+		c.UnsupportedStatus = http.StatusOK
+	}
+
+	if c.DefaultLocale == "" && len(c.Locales) > 0 {
+		c.DefaultLocale = c.Locales[0]
+	}
+
+	if c.LiveReloadPath == "" {
+		c.LiveReloadPath = "/_pt/livereload"
+	}
+
+	if c.ContentType == "" {
+		c.ContentType = "text/html; charset=utf-8"
+	}
+}
+
+// noIndex checks if the template path shouldn't be indexed, see
+// Config.NoIndex.
+func (c *Config) noIndex(tpath string) bool {
+	if c.NoIndex {
+		return true
+	}
+
+	for _, pattern := range c.NoIndexPaths {
+		if ok, _ := path.Match(pattern, tpath); ok {
+			return true
+		}
+	}
+	return false
 }
 
 // Loader is a template loader and executor. This should be created as a
 // global variable to execution speed.
+//
+// All methods of Loader are safe for concurrent use, including those which
+// reconfigure the loader at runtime (e.g. UpdateConfig() and
+// RegisterTheme()). Each method call (and each render, including the ctx
+// functions, tags and filters it calls) uses a single snapshot of the
+// configuration, so renders which are already in progress continue to use the
+// configuration which was active when they started.
 type Loader struct {
+	config    atomic.Value // *Config
+	provided  Config       // config without defaults, guarded by configMu.
+	fs        *pongo2.TemplateSet
+	dataFS    *pongo2.TemplateSet
+	loader    pongo2.TemplateLoader
+	cache     *templateCache
+	dataCache *templateCache
+	ts        time.Time
+
+	audited     sync.Map // see Config.AuditSafe.
+	assetHashes sync.Map // see CacheTSFor(), without Config.Assets.
+	schemas     sync.Map
+	profiles    profiler
+	usage       usageTracker
+	warm        atomic.Value // *WarmStats
+	flights     flightGroup
+
+	themesMu sync.RWMutex
+	themes   map[string]*themeSet
+
+	headersMu sync.RWMutex
+	headers   []headerPreset
+
+	criticalMu sync.RWMutex
+	critical   []criticalCSSPreset
+
+	capturesMu sync.RWMutex
+	captures   []capturePreset
+
+	globalsMu sync.RWMutex
+	globals   map[string]interface{}
+
+	providersMu sync.RWMutex
+	providers   []ctxProvider
+
+	menusMu sync.RWMutex
+	menus   map[string][]*MenuItem
+
+	routesMu sync.RWMutex
+	routes   map[string]string // route name -> pattern, see Loader.Routes().
+
+	alternatesMu sync.RWMutex
+	alternates   []alternatePreset
+
+	slowRenders uint64 // atomic, see Config.SlowRenderThreshold.
+
+	watchStop chan struct{} // see Config.Watch.
+	closeOnce sync.Once
+
+	reloadMu   sync.Mutex
+	reloadSubs map[chan struct{}]struct{} // see LiveReloadHandler().
+
+	configMu sync.Mutex // guards UpdateConfig.
+	parseMu  sync.Mutex // guards template parsing.
+}
+
+// conf returns the active configuration.
+func (ld *Loader) conf() *Config {
+	return ld.config.Load().(*Config)
+}
+
+// UpdateConfig safely updates the configuration of the loader at runtime. fn
+// is called with a copy of the active configuration, as provided to New() (or
+// the previous UpdateConfig()) without defaults, which is swapped in once fn
+// returns. Defaults are then applied again, so fields derived from other
+// fields (e.g. FallbackOnError from ErrorTemplate) follow the update. Changes
+// to Config.Loader and Config.FS are ignored, as the template sources can't
+// be changed once the loader is created.
+//
+// For example:
+//
+//	ld.UpdateConfig(func(c *pt.Config) {
+//		c.Debug = true
+//	})
+func (ld *Loader) UpdateConfig(fn func(c *Config)) {
+	ld.configMu.Lock()
+	defer ld.configMu.Unlock()
+
+	current := ld.conf()
+	conf := ld.provided
+	fn(&conf)
+
+	conf.Loader, conf.FS = current.Loader, current.FS
+	defaultStore := conf.CacheStore == nil && ld.provided.CacheStore == nil
+	ld.provided = conf
+
+	// The default cache store is kept, so cached entries aren't lost.
+	if defaultStore {
+		conf.CacheStore = current.CacheStore
+	}
+	conf.setDefaults()
+
+	ld.config.Store(&conf)
+}
+
+// ctxStateKey is the ctx key used to provide the request-specific state to
+// the tags and filters provided by this package.
+const ctxStateKey = "_pt"
+
+// renderState is the request-specific state which is available to tags and
+// filters, through the ctxStateKey ctx key.
+type renderState struct {
+	ld *Loader
+	w  http.ResponseWriter
+	r  *http.Request
+
+	// conf is the config snapshot of the render. Tags and filters must use it
+	// rather than Loader.conf(), so each render uses a single config, even if
+	// it is updated with UpdateConfig() during the render.
 	conf *Config
-	fs   *pongo2.TemplateSet
-	ts   time.Time
+
+	// stream is only set when rendering with RenderStream().
+	stream *streamState
+
+	// status is the status code set by the "status" tag, if any.
+	status int
+}
+
+// stateFromCtx returns the render state from the execution context, if the
+// template was executed through a Loader.
+func stateFromCtx(ctx *pongo2.ExecutionContext) *renderState {
+	state, _ := ctx.Public[ctxStateKey].(*renderState)
+	return state
+}
+
+// StaticURL returns the URL for the provided static asset path, using
+// Config.StaticBaseURL. If Config.Assets is provided, the version of the asset
+// is appended as the "v" query parameter.
+func (ld *Loader) StaticURL(path string) string {
+	return staticURL(ld.conf(), path)
+}
+
+// staticURL is StaticURL(), using the provided config.
+func staticURL(conf *Config, path string) string {
+	u := strings.TrimSuffix(conf.StaticBaseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+
+	if conf.Assets != nil {
+		if version := conf.Assets.Version(path); version != "" {
+			u += "?v=" + version
+		}
+	}
+
+	return u
+}
+
+// AssetVersion returns the version of the provided static asset path, based on
+// its content hash, when Config.Assets is provided. Otherwise, or when the
+// asset doesn't exist, the timestamp of when the loader was created is used
+// (same as "cachets"). This is available in templates as the "asset_version"
+// ctx function:
+//
+//	<script src="/static/js/app.js?v={{ asset_version("js/app.js") }}"></script>
+func (ld *Loader) AssetVersion(path string) string {
+	return ld.assetVersion(ld.conf(), path)
+}
+
+// assetVersion is AssetVersion(), using the provided config.
+func (ld *Loader) assetVersion(conf *Config, path string) string {
+	if assets := conf.Assets; assets != nil {
+		if version := assets.Version(path); version != "" {
+			return version
+		}
+	}
+
+	return strconv.FormatInt(ld.ts.Unix(), 10)
+}
+
+// Exists checks if the provided template path can be loaded by the underlying
+// template loader (Config.FS or Config.Loader, excluding themes), which is
+// useful for optional templates, e.g. tenant-specific overrides:
+//
+//	tpl := "index.html"
+//	if ld.Exists("tenants/" + tenant + "/index.html") {
+//		tpl = "tenants/" + tenant + "/index.html"
+//	}
+func (ld *Loader) Exists(path string) bool {
+	return loaderExists(ld.loader, path)
+}
+
+// List returns the paths of all templates within Config.FS, in lexical order.
+// Config.Loader functions can't be listed, so an error is returned if
+// Config.FS isn't provided.
+func (ld *Loader) List() ([]string, error) {
+	fsys := ld.conf().FS
+	if fsys == nil {
+		return nil, errors.New("listing templates requires a loader with Config.FS")
+	}
+
+	var paths []string
+
+	err := fs.WalkDir(fsys, ".", func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		paths = append(paths, fpath)
+		return nil
+	})
+
+	return paths, err
+}
+
+// loaderExists checks if the provided template path can be loaded by the
+// template loader.
+func loaderExists(loader pongo2.TemplateLoader, path string) bool {
+	return loaderLookup(loader, path) == nil
+}
+
+// loaderLookup attempts to open the provided template path with the template
+// loader, returning the loaders error, if any.
+func loaderLookup(loader pongo2.TemplateLoader, path string) error {
+	rd, err := loader.Get(loader.Abs("", path))
+	if err != nil {
+		return err
+	}
+
+	if c, ok := rd.(io.Closer); ok {
+		_ = c.Close()
+	}
+	return nil
+}
+
+// notFound checks if the provided template path doesn't exist within the
+// loader (or the theme, if provided). pongo2 doesn't retain the original
+// error from loaders, so this checks the loaders directly.
+func (ld *Loader) notFound(theme *themeSet, path string) bool {
+	if theme != nil && !isNotFound(loaderLookup(theme.loader, path)) {
+		return false
+	}
+	return isNotFound(loaderLookup(ld.loader, path))
 }
 
 // Render is used to render a specific template, where "path" is the path
@@ -96,75 +801,626 @@ type Loader struct {
 // ctx keys:
 //
 //	url     -> request.URL
+//	request -> Request details: ip (see Loader.RealIP()), method, and host.
+//	device  -> The detected client device, when Config.DetectDevice is enabled.
+//	flags   -> The feature flags of the request, when Config.Features is provided.
+//	print_mode -> If the request is in print mode, see Config.PrintSelector.
+//	user    -> The principal of the request, see Config.PrincipalFromRequest.
+//	locale  -> The locale of the request, when Config.Locales is provided.
+//	nav     -> The navigation menus registered with Loader.SetMenu().
+//	alternates -> The alternate representations of the page, see
+//	           Loader.SetAlternates().
+//	robots  -> "noindex, nofollow" or "index, follow", see Config.NoIndex.
 //	cachets -> The timestamp of when the loader was defined. This is useful
 //	           to append at the end of your css/js/etc as a way of allowing
 //	           the browser to not use the same cache after the application
-//	           has been recompiled/restarted.
+//	           has been recompiled/restarted. Deprecated: use asset_version
+//	           or cachets_for, which only change when the asset itself
+//	           changes.
+//	cachets_for -> Function which returns the modification time (or content
+//	           hash) of a static asset, see Loader.CacheTSFor().
+//	asset_version -> Function which returns the version of a static asset,
+//	           see Loader.AssetVersion().
 //
 // ctx keys can be overridden. The priority is:
 //  1. Context defined via Render().
-//  2. Context defined via the default context function.
-//  3. Default defined context by the package, mentioned above.
+//  2. Context defined via the default context functions, and ctx providers
+//     (see Loader.AddCtxProvider()).
+//  3. Request context values (see Config.CtxKeys), and globals defined via
+//     Loader.SetGlobal().
+//  4. Default defined context by the package, mentioned above.
+//
+// For HEAD requests, the template is still executed (so the headers and
+// status code match those of a GET request), however the body isn't written.
+//
+// Render panics if the template can't be parsed or executed, unless
+// Config.OnError or Config.FallbackOnError is provided. See RenderE() for a variant which returns
+// these errors instead.
 func (ld *Loader) Render(w http.ResponseWriter, r *http.Request, path string, rctx map[string]interface{}) {
-	var atmpl *pongo2.Template
-	var err error
+	if err := ld.RenderE(w, r, path, rctx); err != nil {
+		ld.renderErr(w, r, err)
+	}
+}
 
-	if ld.conf.CacheParsed {
-		atmpl, err = ld.fs.FromCache(path)
-	} else {
-		atmpl, err = ld.fs.FromFile(path)
+// RenderE is the same as Render(), however template errors (not found, parse
+// and execution errors) are returned rather than causing a panic. As the
+// template is executed into a buffer, nothing is written to w when an error
+// is returned (unless Config.StreamOutput is enabled), so the caller can
+// decide how to respond. Template not found errors wrap ErrTemplateNotFound,
+// and are only returned when no NotFoundHandler is configured.
+//
+// If the request context is canceled during the render (e.g. the client
+// disconnected), execution is aborted, and an error wrapping
+// ErrRenderCanceled is returned. Render() ignores these errors.
+func (ld *Loader) RenderE(w http.ResponseWriter, r *http.Request, path string, rctx map[string]interface{}) error {
+	return ld.render(w, r, http.StatusOK, path, rctx)
+}
+
+// RenderStatus is the same as Render(), however the response is written with
+// the provided status code. This is useful for rendering error pages (e.g.
+// 403, 404 or 500) through the same pipeline as regular pages. The status
+// code is only written once the template has executed successfully.
+//
+// For example:
+//
+//	ld.RenderStatus(w, r, http.StatusForbidden, "errors/403.html", nil)
+func (ld *Loader) RenderStatus(w http.ResponseWriter, r *http.Request, code int, path string, rctx map[string]interface{}) {
+	if err := ld.render(w, r, code, path, rctx); err != nil {
+		ld.renderErr(w, r, err)
 	}
+}
 
-	var orig *pongo2.Error
+// handleErr handles render errors, ignoring canceled renders, and calling
+// Config.OnError if provided, otherwise panicking. This is used directly by
+// renders which may have already written output, see renderErr().
+func (ld *Loader) handleErr(w http.ResponseWriter, r *http.Request, err error) {
+	conf := ld.conf()
 
-	if errors.As(err, &orig) {
-		if os.IsNotExist(orig.OrigError) {
-			if ld.conf.NotFoundHandler != nil {
-				ld.conf.NotFoundHandler(w, r)
-				return
+	// There is nobody left to respond to.
+	if errors.Is(err, ErrRenderCanceled) {
+		conf.logf(LevelDebug, "error: %v", err)
+		return
+	}
+
+	if conf.OnError != nil {
+		conf.OnError(w, r, err)
+		return
+	}
+
+	panic(err)
+}
+
+// renderErr handles errors returned by render(), see handleErr(). If
+// Config.FallbackOnError is enabled (and there is no Config.OnError), the
+// error is logged, and the Config.ErrorTemplate (or a plain text error) is
+// sent instead.
+func (ld *Loader) renderErr(w http.ResponseWriter, r *http.Request, err error) {
+	conf := ld.conf()
+
+	if !conf.FallbackOnError || conf.OnError != nil || errors.Is(err, ErrRenderCanceled) {
+		ld.handleErr(w, r, err)
+		return
+	}
+
+	conf.logf(LevelError, "error: %v", err)
+
+	if conf.ErrorTemplate != "" {
+		var ctx map[string]interface{}
+		if conf.Debug {
+			ctx = M{"error": err.Error()}
+		}
+
+		terr := ld.render(w, r, http.StatusInternalServerError, conf.ErrorTemplate, ctx)
+		if terr == nil {
+			return
+		}
+
+		conf.logf(LevelError, "error: rendering error template: %v", terr)
+	}
+
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// render renders the template with the provided status code, returning
+// template errors.
+func (ld *Loader) render(w http.ResponseWriter, r *http.Request, code int, path string, rctx map[string]interface{}) (err error) {
+	conf := ld.conf()
+	start := time.Now()
+
+	var j *renderJob
+
+	if r != nil && r.Method == http.MethodHead {
+		w = headWriter{w}
+	}
+
+	if conf.Compress {
+		if cw := newCompressWriter(w, r, conf.CompressMinSize); cw != nil {
+			defer cw.close()
+			w = cw
+		}
+	}
+
+	if len(conf.AfterRender) > 0 {
+		defer func() {
+			var ctx map[string]interface{}
+			if j != nil {
+				ctx = j.ctx
+			}
+
+			for _, hook := range conf.AfterRender {
+				hook(r, path, ctx, time.Since(start), err)
+			}
+		}()
+	}
+
+	j, err = ld.prepare(w, r, conf, path, rctx)
+	if err != nil || j == nil {
+		return err
+	}
+
+	if conf.SlowRenderThreshold > 0 {
+		defer ld.slowRender(j, start, time.Now())
+	}
+
+	conditional := conf.ConditionalGET && code == http.StatusOK && r != nil &&
+		(r.Method == http.MethodGet || r.Method == http.MethodHead)
+
+	if code == http.StatusOK && !conf.FallbackOnError && !conditional && j.criticalCSS == "" && !j.liveReload && len(j.captures) == 0 {
+		return ld.execute(&statusWriter{ResponseWriter: w, state: j.state}, j)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = ld.execute(buf, j); err != nil {
+		return err
+	}
+
+	if j.state.status != 0 {
+		conditional = conditional && j.state.status == http.StatusOK
+		code = j.state.status
+	}
+
+	// The output is post-processed after execution, which shifts output
+	// positions. Errors and Config.AuditSafe findings are reported against the
+	// template source (whose line numbers the source rewrites preserve), so
+	// no mapping back from the output is needed.
+	if j.criticalCSS != "" {
+		buf = bytes.NewBuffer(ld.inlineCriticalCSS(j, buf.Bytes()))
+	}
+
+	if j.liveReload {
+		buf = bytes.NewBuffer(injectLiveReload(conf, buf.Bytes()))
+	}
+
+	for _, fn := range j.captures {
+		fn(r, path, code, buf.Bytes())
+	}
+
+	if conditional && ld.notModified(w, r, j, buf.Bytes()) {
+		return nil
+	}
+
+	w.WriteHeader(code)
+	_, err = buf.WriteTo(w)
+	return ld.execErr(conf, err)
+}
+
+// RenderBytes renders the provided template outside of a HTTP request (e.g.
+// for emails or background jobs), returning the output. As there is no
+// request, Config.DefaultCtx isn't called, themes and device specific
+// templates aren't used, and the "url" and "request" ctx keys aren't
+// provided. Templates aren't wrapped in Config.DefaultLayout, as the output
+// generally isn't a page of the site. Template not found errors wrap
+// ErrTemplateNotFound.
+func (ld *Loader) RenderBytes(path string, rctx map[string]interface{}) ([]byte, error) {
+	conf := ld.conf()
+
+	tpl, err := ld.load(conf, ld.fs, ld.cache, path)
+	if err != nil {
+		if ld.notFound(nil, path) {
+			return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, path)
+		}
+		return nil, err
+	}
+
+	if conf.AuditSafe {
+		ld.logSafe(conf, nil, path)
+	}
+
+	ctx, err := ld.buildCtx(conf, nil, nil, rctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.Debug {
+		ld.validateSchema(conf, path, ctx)
+	}
+
+	if conf.StrictVars {
+		ld.checkUndefinedVars(conf, path, ctx)
+	}
+
+	var buf bytes.Buffer
+
+	if err = ld.execute(&buf, &renderJob{conf: conf, path: path, tpl: tpl, ctx: ctx}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderString is the same as RenderBytes(), however the output is returned
+// as a string.
+func (ld *Loader) RenderString(path string, rctx map[string]interface{}) (string, error) {
+	out, err := ld.RenderBytes(path, rctx)
+	return string(out), err
+}
+
+// RenderRequestBytes renders the provided template for the request in the
+// same way as RenderE() (including the default ctx, themes and device
+// specific templates), however the output is returned, rather than written to
+// a response. This is useful when the output is post-processed (e.g.
+// converted to a PDF or image) or cached. The output is always the full,
+// uncompressed body, regardless of the method (e.g. HEAD), Accept-Encoding or
+// conditional headers of the request. An error is returned if the template
+// sets a status other than 200, or if the NotFoundHandler is invoked.
+func (ld *Loader) RenderRequestBytes(r *http.Request, path string, rctx map[string]interface{}) ([]byte, error) {
+	r = r.Clone(r.Context())
+	r.Method = http.MethodGet
+	for _, key := range []string{"Accept-Encoding", "If-None-Match", "If-Modified-Since", "Range"} {
+		r.Header.Del(key)
+	}
+
+	buf := &bufferWriter{header: make(http.Header)}
+
+	if err := ld.render(buf, r, http.StatusOK, path, rctx); err != nil {
+		return nil, err
+	}
+
+	if buf.code != 0 && buf.code != http.StatusOK {
+		return nil, fmt.Errorf("rendering %q returned status %d", path, buf.code)
+	}
+
+	return buf.body.Bytes(), nil
+}
+
+// bufferWriter is a http.ResponseWriter which buffers the response in memory,
+// see RenderRequestBytes().
+type bufferWriter struct {
+	header http.Header
+	body   bytes.Buffer
+	code   int
+}
+
+func (b *bufferWriter) Header() http.Header { return b.header }
+
+func (b *bufferWriter) Write(data []byte) (int, error) {
+	if b.code == 0 {
+		b.code = http.StatusOK
+	}
+	return b.body.Write(data)
+}
+
+func (b *bufferWriter) WriteHeader(code int) {
+	if b.code == 0 {
+		b.code = code
+	}
+}
+
+// renderJob is a single prepared template render.
+type renderJob struct {
+	conf *Config
+	r    *http.Request
+	path string
+	tpl  *pongo2.Template
+	ctx  map[string]interface{}
+
+	// state is the render state provided to tags and filters through
+	// ctxStateKey. It's kept on the job, as hooks can modify the ctx.
+	state *renderState
+
+	// criticalCSS is the path of the critical CSS to inline, see
+	// Loader.SetCriticalCSS().
+	criticalCSS string
+
+	// captures receive the final output, see Loader.CaptureBody().
+	captures []CaptureFunc
+
+	// liveReload injects the live reload script, see Config.LiveReload.
+	liveReload bool
+
+	// ctxTime is the time spent building the ctx.
+	ctxTime time.Duration
+}
+
+// prepare resolves and loads the template (taking into account the selected
+// theme and device), and builds the ctx for the render. Returns a nil job if
+// the template wasn't found, and the NotFoundHandler was invoked.
+func (ld *Loader) prepare(w http.ResponseWriter, r *http.Request, conf *Config, path string, rctx map[string]interface{}) (*renderJob, error) {
+	var device *Device
+
+	requested := path
+
+	set, cache := ld.fs, ld.cache
+	theme := ld.theme(conf, r)
+	if theme != nil {
+		set, cache = theme.fs, theme.cache
+	}
+
+	if conf.DetectDevice {
+		device = DetectDevice(r)
+
+		if conf.DeviceTemplates {
+			candidate := deviceCandidate(device, path)
+
+			if candidate != "" && (ld.Exists(candidate) || (theme != nil && loaderExists(theme.loader, candidate))) {
+				path = candidate
 			}
+		}
+	}
+
+	printMode := conf.PrintSelector != nil && conf.PrintSelector(r)
+	if printMode {
+		candidate := variantPath(requested, "print")
 
-			panic(err)
+		if ld.Exists(candidate) || (theme != nil && loaderExists(theme.loader, candidate)) {
+			path = candidate
 		}
 	}
 
-	tpl := pongo2.Must(atmpl, err)
+	tpl, err := ld.load(conf, set, cache, layoutPath(conf, path))
+	if err != nil {
+		if !ld.notFound(theme, path) {
+			return nil, err
+		}
+
+		if conf.NotFoundHandler != nil {
+			conf.NotFoundHandler(w, r)
+			return nil, nil
+		}
 
-	var ctx map[string]interface{}
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, path)
+	}
 
-	if ld.conf.DefaultCtx != nil {
-		ctx = ld.conf.DefaultCtx(w, r)
+	if conf.AuditSafe {
+		ld.logSafe(conf, theme, path)
 	}
 
-	switch {
-	case ctx == nil && rctx != nil:
-		ctx = rctx
-	case ctx == nil:
-		ctx = make(map[string]interface{})
-	case rctx != nil:
-		for key := range rctx {
-			ctx[key] = rctx[key]
+	ctxStart := time.Now()
+
+	ctx, err := ld.buildCtx(conf, w, r, rctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxTime := time.Since(ctxStart)
+	state := ctx[ctxStateKey].(*renderState)
+
+	if _, ok := ctx["device"]; !ok && device != nil {
+		ctx["device"] = device.ctx()
+	}
+	if _, ok := ctx["theme"]; !ok && theme != nil {
+		ctx["theme"] = theme.theme.ctx()
+	}
+	if _, ok := ctx["print_mode"]; !ok && conf.PrintSelector != nil {
+		ctx["print_mode"] = printMode
+	}
+
+	if conf.noIndex(requested) {
+		w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+		ctx["robots"] = "noindex, nofollow"
+	} else if _, ok := ctx["robots"]; !ok {
+		ctx["robots"] = "index, follow"
+	}
+
+	ld.applyAlternates(w, r, requested, ctx)
+
+	for _, hook := range conf.BeforeRender {
+		hook(r, requested, ctx)
+	}
+
+	// Hooks may have removed or replaced the state along with other keys.
+	ctx[ctxStateKey] = state
+
+	if conf.Debug {
+		ld.validateSchema(conf, path, ctx)
+	}
+
+	if conf.StrictVars {
+		ld.checkUndefinedVars(conf, path, ctx)
+	}
+
+	w.Header().Set("Content-Type", conf.ContentType)
+	ld.applyHeaders(w, requested, ctx)
+
+	return &renderJob{
+		conf:        conf,
+		r:           r,
+		path:        path,
+		tpl:         tpl,
+		ctx:         ctx,
+		state:       state,
+		criticalCSS: ld.criticalCSS(requested),
+		captures:    ld.captureFuncs(requested),
+		liveReload:  liveReloadEnabled(conf, w),
+		ctxTime:     ctxTime,
+	}, nil
+}
+
+// execute executes the prepared template, writing the result to w. Only
+// template execution errors are returned, see execErr().
+func (ld *Loader) execute(w io.Writer, j *renderJob) (err error) {
+	defer func() {
+		if err == nil && sampleUsage(j.conf) {
+			ld.recordUsage(j.path, "")
+		}
+	}()
+
+	exec := j.tpl.ExecuteWriter
+
+	if rw, ok := w.(http.ResponseWriter); ok && j.conf.StreamOutput {
+		exec = j.tpl.ExecuteWriterUnbuffered
+		w = newFlushWriter(rw, j.conf.StreamFlushSize)
+	}
+
+	if j.r != nil && j.r.Context().Done() != nil {
+		return ld.executeCancelable(w, j)
+	}
+
+	if j.conf.Profile {
+		cw := &countingWriter{w: w}
+		sample := startProfile()
+
+		err = exec(j.ctx, cw)
+		ld.profiles.record(j.path, sample, cw.n)
+	} else {
+		err = exec(j.ctx, w)
+	}
+
+	return ld.execErr(j.conf, err)
+}
+
+// execErr returns template execution errors, and logs all other errors (e.g.
+// errors writing to the client).
+func (ld *Loader) execErr(conf *Config, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var pongoErr *pongo2.Error
+
+	if errors.As(err, &pongoErr) {
+		// pongo2 only records the token of errors returned from filters, so
+		// the template path is taken from it.
+		if pongoErr.Filename == "" && pongoErr.Token != nil {
+			pongoErr.Filename = strings.TrimSuffix(pongoErr.Token.Filename, layoutSuffix)
+		}
+		return err
+	}
+
+	conf.logf(LevelError, "error: %v", err)
+	return nil
+}
+
+// load loads the provided template path from the template set, using the
+// provided cache if Config.CacheParsed is enabled.
+func (ld *Loader) load(conf *Config, set *pongo2.TemplateSet, cache *templateCache, path string) (*pongo2.Template, error) {
+	// pongo2 template sets aren't safe for concurrent parsing, so all parsing
+	// (cached or not) is serialized, which also covers Config.CacheParsed
+	// being toggled with UpdateConfig().
+	parse := func(path string) (*pongo2.Template, error) {
+		ld.parseMu.Lock()
+		defer ld.parseMu.Unlock()
+
+		if err := ld.checkIncludes(conf, path); err != nil {
+			return nil, err
+		}
+		return set.FromFile(path)
+	}
+
+	if conf.CacheParsed {
+		policy := cachePolicy{ttl: conf.CacheTTL, maxEntries: conf.CacheMaxEntries, maxBytes: conf.CacheMaxBytes}
+
+		return cache.get(path, policy, func(path string) (*pongo2.Template, int, error) {
+			tpl, err := parse(path)
+			if err != nil || policy.maxBytes <= 0 {
+				return tpl, 0, err
+			}
+
+			src, _ := ld.source(strings.TrimSuffix(path, layoutSuffix))
+			return tpl, len(src), nil
+		})
+	}
+
+	return parse(path)
+}
+
+// buildCtx merges the default context, the render context, the globals, and
+// the package provided context keys. See Render() for the priority. r is nil
+// when rendering outside of a request, see RenderBytes().
+func (ld *Loader) buildCtx(conf *Config, w http.ResponseWriter, r *http.Request, rctx map[string]interface{}) (map[string]interface{}, error) {
+	// The ctx is always a new map, as the maps provided by the caller (and
+	// DefaultCtx) may be shared across concurrent renders.
+	ctx := make(map[string]interface{}, len(rctx)+16)
+
+	if r != nil {
+		if err := ld.defaultCtx(conf, w, r, ctx); err != nil {
+			return nil, err
 		}
 	}
 
-	if _, ok := ctx["url"]; !ok {
-		ctx["url"] = r.URL
+	mergeCtx(conf, ctx, rctx)
+
+	if r != nil {
+		applyCtxKeys(conf, ctx, r)
+	}
+
+	ld.applyGlobals(ctx)
+
+	if r != nil {
+		if _, ok := ctx["url"]; !ok {
+			ctx["url"] = r.URL
+		}
+		if _, ok := ctx["request"]; !ok {
+			ctx["request"] = M{"ip": realIP(conf, r), "method": r.Method, "host": r.Host}
+		}
+		if _, ok := ctx["user"]; !ok && conf.PrincipalFromRequest != nil {
+			ctx["user"] = principal(conf, r)
+		}
+		if _, ok := ctx["locale"]; !ok && len(conf.Locales) > 0 {
+			ctx["locale"] = requestLocale(conf, r)
+		}
+		if _, ok := ctx["nav"]; !ok {
+			if nav := ld.nav(r); nav != nil {
+				ctx["nav"] = nav
+			}
+		}
+		if _, ok := ctx["flags"]; !ok && conf.Features != nil {
+			flags := conf.Features.Flags(r)
+			if flags == nil {
+				flags = map[string]bool{}
+			}
+			ctx["flags"] = flags
+		}
 	}
 	if _, ok := ctx["cachets"]; !ok {
 		ctx["cachets"] = ld.ts.Unix()
 	}
+	if _, ok := ctx["cachets_for"]; !ok {
+		ctx["cachets_for"] = func(name string) string { return ld.cacheTSFor(conf, name) }
+	}
+	if _, ok := ctx["asset_version"]; !ok {
+		ctx["asset_version"] = func(path string) string { return ld.assetVersion(conf, path) }
+	}
 
-	w.Header().Set("Content-Type", "text/html")
+	ctx[ctxStateKey] = &renderState{ld: ld, w: w, r: r, conf: conf}
+	return ctx, nil
+}
 
-	err = tpl.ExecuteWriter(ctx, w)
-	if err != nil {
-		var pongoErr *pongo2.Error
+// Handler returns a http.HandlerFunc which renders the provided template,
+// useful for simple pages which don't need a dedicated handler. ctxFn is
+// optional, and can be used to provide additional context for each request.
+// If ctxFn returns an error, it is written to the ErrorLogger, and a 500 is
+// returned to the client.
+//
+// For example:
+//
+//	r.Get("/about", ld.Handler("about.html", nil))
+func (ld *Loader) Handler(path string, ctxFn func(r *http.Request) (map[string]interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var ctx map[string]interface{}
+
+		if ctxFn != nil {
+			var err error
 
-		if errors.As(err, &pongoErr) {
-			panic(err)
+			ctx, err = ctxFn(r)
+			if err != nil {
+				ld.conf().logf(LevelError, "error: %s: %v", path, err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
 		}
 
-		fmt.Fprint(ld.conf.ErrorLogger, "error: "+err.Error())
+		ld.Render(w, r, path, ctx)
 	}
 }
 
@@ -199,32 +1455,75 @@ func FileServer(router Router, path string, root http.FileSystem) {
 	}))
 }
 
-// JSONEscapeHTMLKey is a context key which can be used with JSON() to set
-// HTML escaping to true.
+// Attachment writes data to the client as a downloadable file, with the
+// provided filename and Content-Type.
+func Attachment(w http.ResponseWriter, r *http.Request, filename, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	_, _ = w.Write(data)
+}
+
+// JSONEscapeHTMLKey is a context key which can be used with JSON() to toggle
+// HTML escaping.
 const JSONEscapeHTMLKey = "JSONEscapeHTML"
 
-// JSON marshals 'v' to JSON, and setting the Content-Type as application/json.
-// Note that this does NOT auto-escape HTML. If you would like to escape HTML,
-// set the JSONEscapeHTMLKey context value to true.
+// JSON marshals 'v' to JSON (using DefaultJSONEncoder), and setting the
+// Content-Type as application/json. HTML is escaped by default (as with
+// encoding/json). If you would like to disable HTML escaping, set the
+// JSONEscapeHTMLKey context value to false.
 //
 // JSON also supports prettification when the origin request has "?pretty=true"
 // or similar.
 func JSON(w http.ResponseWriter, r *http.Request, v interface{}) {
-	buf := &bytes.Buffer{}
-	enc := json.NewEncoder(buf)
+	opts := JSONOptions{EscapeHTML: true}
 
-	if escape, ok := r.Context().Value(JSONEscapeHTMLKey).(bool); ok && escape {
-		enc.SetEscapeHTML(escape)
+	if escape, ok := r.Context().Value(JSONEscapeHTMLKey).(bool); ok {
+		opts.EscapeHTML = escape
 	}
 
 	if pretty, _ := strconv.ParseBool(r.FormValue("pretty")); pretty {
-		enc.SetIndent("", "    ")
+		opts.Indent = "    "
+	}
+
+	// Small, flat values (e.g. health and status responses) skip the encoder
+	// entirely, unless a custom encoder was provided.
+	if _, std := DefaultJSONEncoder.(stdJSONEncoder); std && opts.Indent == "" {
+		if out, ok := appendFlatJSON(make([]byte, 0, 128), v, opts.EscapeHTML); ok {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(out)
+			return
+		}
 	}
 
-	if err := enc.Encode(v); err != nil {
+	buf := &bytes.Buffer{}
+
+	if err := DefaultJSONEncoder.Encode(buf, v, opts); err != nil {
 		panic(err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_, _ = w.Write(buf.Bytes())
 }
+
+// headWriter discards the body of responses to HEAD requests, while still
+// sending the headers and status code.
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (hw headWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// Flush implements http.Flusher.
+func (hw headWriter) Flush() {
+	if flusher, ok := hw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Respond inspects the Accept header of the request, and either renders the
+// provided template (see Render()), or serializes v as JSON (see JSON()). This
+// allows a single handler to serve both browsers and API clients. HTML is
+// preferred when the client accepts both equally (or doesn't provide an
+// Accept header).
+//
+// When rendering HTML, v is used as the ctx if it is a map (M or
+// map[string]interface{}), otherwise it is provided as the "data" ctx key.
+//
+// For example:
+//
+//	ld.Respond(w, r, "posts.html", pt.M{"posts": posts})
+func (ld *Loader) Respond(w http.ResponseWriter, r *http.Request, path string, v interface{}) {
+	ld.RespondStatus(w, r, http.StatusOK, path, v)
+}
+
+// RespondStatus is the same as Respond(), however the response is written
+// with the provided status code (see RenderStatus()). This is useful for
+// error pages which are shared by browsers and API clients.
+//
+// For example:
+//
+//	ld.RespondStatus(w, r, http.StatusNotFound, "errors/404.html", pt.M{"error": "post not found"})
+func (ld *Loader) RespondStatus(w http.ResponseWriter, r *http.Request, code int, path string, v interface{}) {
+	w.Header().Add("Vary", "Accept")
+
+	if negotiate(r.Header.Get("Accept"), "text/html", "application/json") == "application/json" {
+		if conf := ld.conf(); conf.Compress {
+			if cw := newCompressWriter(w, r, conf.CompressMinSize); cw != nil {
+				defer cw.close()
+				w = cw
+			}
+		}
+
+		if code != http.StatusOK {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(code)
+		}
+
+		JSON(w, r, v)
+		return
+	}
+
+	var ctx map[string]interface{}
+
+	switch v := v.(type) {
+	case M:
+		ctx = v
+	case map[string]interface{}:
+		ctx = v
+	default:
+		ctx = M{"data": v}
+	}
+
+	ld.RenderStatus(w, r, code, path, ctx)
+}
+
+// negotiate returns the offered media type which best matches the Accept
+// header. The quality value of each offer is taken from the most specific
+// matching media range, and ties are broken by specificity (e.g. an exact
+// match is preferred over "*/*"). The first offer is returned if the header
+// is empty, or when offers match equally.
+func negotiate(accept string, offers ...string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	best, bestQ, bestSpecificity := offers[0], 0.0, -1
+
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+
+		for _, spec := range strings.Split(accept, ",") {
+			params := strings.Split(spec, ";")
+
+			s := mediaSpecificity(strings.ToLower(strings.TrimSpace(params[0])), offer)
+			if s <= specificity {
+				continue
+			}
+
+			specificity, q = s, 1.0
+			for _, param := range params[1:] {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+						q = v
+					}
+				}
+			}
+		}
+
+		if q > bestQ || (q == bestQ && q > 0 && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = offer, q, specificity
+		}
+	}
+
+	return best
+}
+
+// mediaSpecificity returns how specifically the media range matches the media
+// type (2 for an exact match, 1 for "type/*", 0 for "*/*"), or -1 if it
+// doesn't match.
+func mediaSpecificity(mediaRange, mediaType string) int {
+	switch {
+	case mediaRange == mediaType:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	default:
+		return -1
+	}
+}
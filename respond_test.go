@@ -0,0 +1,79 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "text/html"},
+		{"application/json", "application/json"},
+		{"text/html, application/json", "text/html"},
+		{"application/json, text/html;q=0.9", "application/json"},
+		{"*/*;q=0.5, application/json", "application/json"},
+		{"text/*;q=0.1, application/*;q=0.2", "application/json"},
+		{"text/html;q=0, */*", "application/json"},
+		{"image/png", "text/html"},
+	}
+
+	for _, tt := range tests {
+		if got := negotiate(tt.accept, "text/html", "application/json"); got != tt.want {
+			t.Errorf("negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestRespondStatus(t *testing.T) {
+	ld := New("respond", Config{
+		FS: fstest.MapFS{
+			"post.html":  {Data: []byte(`post {{ title }}`)},
+			"error.html": {Data: []byte(`error {{ data }}`)},
+		},
+	})
+
+	tests := []struct {
+		accept      string
+		code        int
+		path        string
+		v           interface{}
+		contentType string
+		body        string
+	}{
+		{"text/html", http.StatusOK, "post.html", M{"title": "Hello"}, "text/html", "post Hello"},
+		{"application/json", http.StatusOK, "post.html", M{"title": "Hello"}, "application/json", `{"title":"Hello"}`},
+		{"text/html", http.StatusNotFound, "error.html", "not found", "text/html", "error not found"},
+		{"application/json", http.StatusNotFound, "error.html", "not found", "application/json", `"not found"`},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tt.accept)
+		rec := httptest.NewRecorder()
+
+		ld.RespondStatus(rec, r, tt.code, tt.path, tt.v)
+
+		if rec.Code != tt.code {
+			t.Errorf("%s %s: code = %d, want %d", tt.accept, tt.path, rec.Code, tt.code)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+			t.Errorf("%s %s: Content-Type = %q, want %q", tt.accept, tt.path, ct, tt.contentType)
+		}
+		if body := strings.TrimSpace(rec.Body.String()); body != tt.body {
+			t.Errorf("%s %s: body = %q, want %q", tt.accept, tt.path, body, tt.body)
+		}
+		if rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("%s %s: Vary = %q", tt.accept, tt.path, rec.Header().Get("Vary"))
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// MethodRouter is an optional interface which routers can implement (e.g.
+// go-chi/chi.Router), to register handlers for methods other than GET.
+type MethodRouter interface {
+	Router
+	MethodFunc(method, pattern string, h http.HandlerFunc)
+}
+
+// Route is a render preset for a single route, which renders a template with
+// the context returned from Ctx. See Loader.Routes().
+type Route struct {
+	// Name is the unique name of the route, used for lookups with
+	// RouteSet.Path().
+	Name string
+	// Pattern is the router pattern (e.g. "/about").
+	Pattern string
+	// Template is the template path to render.
+	Template string
+	// Ctx is an optional function which builds the context for each request.
+	// See Loader.Handler() for how errors are handled.
+	Ctx func(r *http.Request) (map[string]interface{}, error)
+	// CacheControl is the optional Cache-Control header for responses (e.g.
+	// "public, max-age=300" or "private, no-store").
+	CacheControl string
+	// Methods are the allowed request methods. Defaults to GET. Methods other
+	// than GET require a router which implements MethodRouter.
+	Methods []string
+}
+
+// RouteSet is a set of render presets, created with Loader.Routes().
+type RouteSet struct {
+	ld     *Loader
+	routes []Route
+	byName map[string]int
+}
+
+// Routes returns a new set of render presets, which standardizes handlers that
+// only build a context and render a template. Routes are registered on a
+// router with RouteSet.Mount().
+//
+// For example:
+//
+//	ld.Routes(
+//		pt.Route{Name: "home", Pattern: "/", Template: "index.html", Ctx: homeCtx},
+//		pt.Route{Name: "about", Pattern: "/about", Template: "about.html", CacheControl: "public, max-age=3600"},
+//	).Mount(r)
+func (ld *Loader) Routes(routes ...Route) *RouteSet {
+	rs := &RouteSet{ld: ld, byName: make(map[string]int)}
+
+	for _, route := range routes {
+		rs.Add(route)
+	}
+
+	return rs
+}
+
+// Add adds a route to the set. Panics if a route with the same name already
+// exists. Named routes can be referenced with the "url" tag.
+func (rs *RouteSet) Add(route Route) *RouteSet {
+	if route.Name != "" {
+		if _, ok := rs.byName[route.Name]; ok {
+			panic(fmt.Sprintf("route %q already registered", route.Name))
+		}
+		rs.byName[route.Name] = len(rs.routes)
+
+		rs.ld.routesMu.Lock()
+		if rs.ld.routes == nil {
+			rs.ld.routes = make(map[string]string)
+		}
+		rs.ld.routes[route.Name] = route.Pattern
+		rs.ld.routesMu.Unlock()
+	}
+
+	rs.routes = append(rs.routes, route)
+	return rs
+}
+
+// Path returns the pattern of the route with the provided name, or an empty
+// string if no route exists with that name.
+func (rs *RouteSet) Path(name string) string {
+	if i, ok := rs.byName[name]; ok {
+		return rs.routes[i].Pattern
+	}
+	return ""
+}
+
+// Mount registers all routes within the set on the router. Panics if a route
+// allows methods other than GET, and the router doesn't implement
+// MethodRouter.
+func (rs *RouteSet) Mount(router Router) {
+	for _, route := range rs.routes {
+		handler := rs.handler(route)
+
+		methods := route.Methods
+		if len(methods) == 0 {
+			methods = []string{http.MethodGet}
+		}
+
+		for _, method := range methods {
+			method = strings.ToUpper(method)
+
+			if method == http.MethodGet {
+				router.Get(route.Pattern, handler)
+				continue
+			}
+
+			mr, ok := router.(MethodRouter)
+			if !ok {
+				panic(fmt.Sprintf("route %q: router does not support method %s", route.Pattern, method))
+			}
+			mr.MethodFunc(method, route.Pattern, handler)
+		}
+	}
+}
+
+func (rs *RouteSet) handler(route Route) http.HandlerFunc {
+	handler := rs.ld.Handler(route.Template, route.Ctx)
+
+	if route.CacheControl == "" {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", route.CacheControl)
+		handler(w, r)
+	}
+}
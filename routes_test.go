@@ -0,0 +1,89 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+// testMethodRouter is a MethodRouter which records the registered handlers,
+// keyed by "METHOD pattern".
+type testMethodRouter map[string]http.HandlerFunc
+
+func (tr testMethodRouter) Get(pattern string, h http.HandlerFunc) { tr["GET "+pattern] = h }
+
+func (tr testMethodRouter) MethodFunc(method, pattern string, h http.HandlerFunc) {
+	tr[method+" "+pattern] = h
+}
+
+func TestRoutes(t *testing.T) {
+	ld := New("routes", Config{
+		FS: fstest.MapFS{
+			"index.html":   {Data: []byte(`home {{ name }}`)},
+			"contact.html": {Data: []byte(`contact`)},
+		},
+	})
+
+	rs := ld.Routes(
+		Route{Name: "home", Pattern: "/", Template: "index.html", CacheControl: "public, max-age=60", Ctx: func(r *http.Request) (map[string]interface{}, error) {
+			if r.URL.Query().Get("fail") != "" {
+				return nil, errors.New("failed")
+			}
+			return M{"name": "Jane"}, nil
+		}},
+		Route{Name: "contact", Pattern: "/contact", Template: "contact.html", Methods: []string{"get", "post"}},
+	)
+
+	if rs.Path("contact") != "/contact" || rs.Path("missing") != "" {
+		t.Errorf("Path() = %q, %q", rs.Path("contact"), rs.Path("missing"))
+	}
+
+	router := testMethodRouter{}
+	rs.Mount(router)
+
+	for _, key := range []string{"GET /", "GET /contact", "POST /contact"} {
+		if router[key] == nil {
+			t.Errorf("%s not registered", key)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router["GET /"](rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Body.String() != "home Jane" || rec.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Errorf("body = %q, headers = %v", rec.Body.String(), rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	router["GET /"](rec, httptest.NewRequest(http.MethodGet, "/?fail=1", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("ctx error: code = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestRoutesPanics(t *testing.T) {
+	ld := New("routes-panics", Config{FS: fstest.MapFS{}})
+
+	for name, fn := range map[string]func(){
+		"duplicate name": func() {
+			ld.Routes(Route{Name: "a", Pattern: "/a"}, Route{Name: "a", Pattern: "/b"})
+		},
+		"unsupported method": func() {
+			ld.Routes(Route{Pattern: "/a", Methods: []string{http.MethodPost}}).Mount(testRouter{})
+		},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
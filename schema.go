@@ -0,0 +1,123 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SetSchema associates a schema with the provided template path. schema must
+// be a struct (or a pointer to one), where each exported field describes an
+// expected ctx key, named after the "json" struct tag (or the field name).
+// Fields tagged with "omitempty" are optional. Nested structs are validated
+// against nested map[string]interface{} values.
+//
+// When Config.Debug is enabled, the merged ctx is validated against the
+// schema on every render, and violations are written to the ErrorLogger. This
+// helps catch drift between handlers and templates early. Keys within the ctx
+// which aren't described by the schema are ignored.
+//
+// For example:
+//
+//	type IndexCtx struct {
+//		Title string   `json:"title"`
+//		Posts []*Post  `json:"posts"`
+//		User  *User    `json:"user,omitempty"`
+//	}
+//
+//	ld.SetSchema("index.html", IndexCtx{})
+func (ld *Loader) SetSchema(path string, schema interface{}) {
+	rt := reflect.TypeOf(schema)
+	for rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	if rt == nil || rt.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("schema for %q must be a struct, got %T", path, schema))
+	}
+
+	ld.schemas.Store(path, rt)
+}
+
+// validateSchema validates the ctx against the schema for the provided path
+// (if any), and logs all violations.
+func (ld *Loader) validateSchema(conf *Config, path string, ctx map[string]interface{}) {
+	rt, ok := ld.schemas.Load(path)
+	if !ok {
+		return
+	}
+
+	for _, violation := range validateStruct(rt.(reflect.Type), ctx, "") {
+		conf.logf(LevelWarn, "schema: %s: %s", path, violation)
+	}
+}
+
+func validateStruct(rt reflect.Type, ctx map[string]interface{}, prefix string) (violations []string) {
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		opts := strings.Split(field.Tag.Get("json"), ",")
+		name := opts[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		value, ok := ctx[name]
+		if !ok {
+			if !hasOption(opts[1:], "omitempty") {
+				violations = append(violations, fmt.Sprintf("missing key %q", prefix+name))
+			}
+			continue
+		}
+
+		violations = append(violations, validateValue(field.Type, value, prefix+name)...)
+	}
+
+	return violations
+}
+
+func validateValue(rt reflect.Type, value interface{}, name string) []string {
+	if value == nil {
+		switch rt.Kind() { //nolint:exhaustive
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			return nil
+		default:
+			return []string{fmt.Sprintf("key %q is nil, expected %s", name, rt)}
+		}
+	}
+
+	vt := reflect.TypeOf(value)
+	if vt.AssignableTo(rt) {
+		return nil
+	}
+
+	if rt.Kind() == reflect.Struct {
+		if nested, ok := value.(map[string]interface{}); ok {
+			return validateStruct(rt, nested, name+".")
+		}
+		if nested, ok := value.(M); ok {
+			return validateStruct(rt, nested, name+".")
+		}
+	}
+
+	return []string{fmt.Sprintf("key %q is %s, expected %s", name, vt, rt)}
+}
+
+func hasOption(opts []string, name string) bool {
+	for _, opt := range opts {
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type testSchemaAuthor struct {
+	Name string `json:"name"`
+}
+
+type testSchema struct {
+	Title    string           `json:"title"`
+	Count    int              `json:"count"`
+	Tags     []string         `json:"tags"`
+	Author   testSchemaAuthor `json:"author"`
+	Subtitle string           `json:"subtitle,omitempty"`
+	Ignored  string           `json:"-"`
+	Raw      interface{}
+	private  string
+}
+
+func TestValidateStruct(t *testing.T) {
+	rt := reflect.TypeOf(testSchema{})
+
+	violations := validateStruct(rt, map[string]interface{}{
+		"title":  "Hello",
+		"count":  nil,
+		"tags":   nil,
+		"author": M{"name": 1},
+	}, "")
+	sort.Strings(violations)
+
+	want := []string{
+		`key "author.name" is int, expected string`,
+		`key "count" is nil, expected int`,
+		`missing key "Raw"`,
+	}
+	if !reflect.DeepEqual(violations, want) {
+		t.Errorf("violations = %q, want %q", violations, want)
+	}
+
+	if v := validateStruct(rt, map[string]interface{}{
+		"title": "Hello", "count": 1, "tags": []string{"a"}, "author": testSchemaAuthor{}, "Raw": 1,
+	}, ""); len(v) != 0 {
+		t.Errorf("unexpected violations: %q", v)
+	}
+}
+
+func TestSetSchema(t *testing.T) {
+	var logs bytes.Buffer
+
+	ld := New("schema", Config{
+		FS:          fstest.MapFS{"index.html": {Data: []byte(`{{ title }}`)}},
+		Debug:       true,
+		ErrorLogger: &logs,
+	})
+	ld.SetSchema("index.html", &testSchemaAuthor{})
+
+	if _, err := ld.RenderBytes("index.html", M{"title": "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `schema: index.html: missing key "name"`; !strings.Contains(logs.String(), want) {
+		t.Errorf("logs = %q, want %q", logs.String(), want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a non-struct schema")
+		}
+	}()
+	ld.SetSchema("index.html", "not a struct")
+}
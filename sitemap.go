@@ -0,0 +1,151 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+var (
+	reSitemapTag  = regexp.MustCompile(`{%-?\s*sitemap\s+(.*?)\s*-?%}`)
+	reSitemapAttr = regexp.MustCompile(`(\w+)(?:=(?:"([^"]*)"|'([^']*)'|(\S+)))?`)
+)
+
+// SitemapURL is a single entry within a sitemap.
+type SitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name      `xml:"urlset"`
+	XMLNS   string        `xml:"xmlns,attr"`
+	URLs    []*SitemapURL `xml:"url"`
+}
+
+// Sitemap generates the sitemap entries for all pages within dir (see
+// RegisterPages()). Pages with url params are skipped, as their values are
+// unknown. Pages can declare their metadata using the "sitemap" tag, or be
+// excluded entirely. For example:
+//
+//	{% sitemap priority="0.8" changefreq="weekly" lastmod="2024-01-02" %}
+//	{% sitemap exclude %}
+//
+// Locations are absolute URLs, see Loader.AbsoluteURL().
+func (ld *Loader) Sitemap(r *http.Request, dir string) ([]*SitemapURL, error) {
+	pages, err := ld.pages(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]*SitemapURL, 0, len(pages))
+
+	for _, page := range pages {
+		if strings.Contains(page, "[") {
+			continue
+		}
+
+		src, err := ld.source(page)
+		if err != nil {
+			return nil, err
+		}
+
+		u := &SitemapURL{Loc: ld.AbsoluteURL(r, "/"+strings.Join(pageSegments(dir, page), "/"))}
+
+		if m := reSitemapTag.FindSubmatch(src); m != nil {
+			if !parseSitemapAttrs(string(m[1]), u) {
+				continue
+			}
+		}
+
+		urls = append(urls, u)
+	}
+
+	return urls, nil
+}
+
+// parseSitemapAttrs parses the attributes of a sitemap tag into u, returning
+// false if the page is excluded.
+func parseSitemapAttrs(attrs string, u *SitemapURL) bool {
+	for _, m := range reSitemapAttr.FindAllStringSubmatch(attrs, -1) {
+		value := m[2] + m[3] + m[4]
+
+		switch m[1] {
+		case "exclude":
+			return false
+		case "priority":
+			u.Priority = value
+		case "changefreq":
+			u.ChangeFreq = value
+		case "lastmod":
+			u.LastMod = value
+		}
+	}
+	return true
+}
+
+// SitemapHandler returns a http.HandlerFunc which serves the sitemap for all
+// pages within dir, see Loader.Sitemap().
+func (ld *Loader) SitemapHandler(dir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		urls, err := ld.Sitemap(r, dir)
+		if err != nil {
+			panic(err)
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString(xml.Header)
+
+		enc := xml.NewEncoder(&buf)
+		enc.Indent("", "  ")
+
+		err = enc.Encode(sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9", URLs: urls})
+		if err != nil {
+			panic(err)
+		}
+
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = buf.WriteTo(w)
+	}
+}
+
+type tagSitemapNode struct{}
+
+func (node *tagSitemapNode) Execute(*pongo2.ExecutionContext, pongo2.TemplateWriter) *pongo2.Error {
+	return nil
+}
+
+// tagSitemapParser parses the "sitemap" tag, which declares sitemap metadata
+// for a page. The tag produces no output, and is read by Loader.Sitemap().
+func tagSitemapParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	for arguments.Remaining() > 0 {
+		key := arguments.MatchType(pongo2.TokenIdentifier)
+		if key == nil {
+			return nil, arguments.Error("Malformed sitemap-tag arguments.", nil)
+		}
+
+		if key.Val == "exclude" {
+			continue
+		}
+
+		if arguments.Match(pongo2.TokenSymbol, "=") == nil {
+			return nil, arguments.Error("Expected '=' after sitemap-tag argument.", nil)
+		}
+
+		if arguments.MatchType(pongo2.TokenString) == nil && arguments.MatchType(pongo2.TokenNumber) == nil {
+			return nil, arguments.Error("Sitemap-tag argument values must be strings or numbers.", nil)
+		}
+	}
+
+	return &tagSitemapNode{}, nil
+}
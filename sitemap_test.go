@@ -0,0 +1,63 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSitemap(t *testing.T) {
+	ld := New("sitemap", Config{
+		FS: fstest.MapFS{
+			"pages/index.html":       {Data: []byte(`{% sitemap priority="1.0" changefreq="daily" %}home`)},
+			"pages/about.html":       {Data: []byte(`{% sitemap priority=0.5 lastmod='2024-01-02' %}about`)},
+			"pages/blog/[slug].html": {Data: []byte(`post`)},
+			"pages/draft.html":       {Data: []byte(`{% sitemap exclude %}draft`)},
+		},
+		BaseURL: "https://example.com/",
+	})
+
+	rec := httptest.NewRecorder()
+	ld.SitemapHandler("pages")(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/about</loc>
+    <lastmod>2024-01-02</lastmod>
+    <priority>0.5</priority>
+  </url>
+  <url>
+    <loc>https://example.com/</loc>
+    <changefreq>daily</changefreq>
+    <priority>1.0</priority>
+  </url>
+</urlset>`
+	if rec.Body.String() != want {
+		t.Errorf("body:\n%s\nwant:\n%s", rec.Body.String(), want)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	// The tag produces no output.
+	if out, err := ld.RenderBytes("pages/draft.html", nil); err != nil || string(out) != "draft" {
+		t.Errorf("RenderBytes() = %q, %v", out, err)
+	}
+}
+
+func TestSitemapTagMalformed(t *testing.T) {
+	for _, tpl := range []string{`{% sitemap priority %}`, `{% sitemap priority=x %}`, `{% sitemap "a" %}`} {
+		ld := New("sitemap-malformed", Config{FS: fstest.MapFS{"index.html": {Data: []byte(tpl)}}})
+
+		if _, err := ld.RenderBytes("index.html", nil); err == nil || !strings.Contains(strings.ToLower(err.Error()), "sitemap-tag") {
+			t.Errorf("%s: error = %v, want a sitemap-tag error", tpl, err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// slowRender logs the render if it took longer than
+// Config.SlowRenderThreshold, with the time spent preparing the render
+// (loading the template and building the ctx), building the ctx, and
+// executing the template. start is when the render started, and prepared is
+// when the template was ready to be executed.
+func (ld *Loader) slowRender(j *renderJob, start, prepared time.Time) {
+	total := time.Since(start)
+	if total < j.conf.SlowRenderThreshold {
+		return
+	}
+
+	atomic.AddUint64(&ld.slowRenders, 1)
+
+	j.conf.logf(
+		LevelWarn,
+		"slow render: %s: total=%s prepare=%s ctx=%s execute=%s",
+		j.path,
+		total.Round(time.Microsecond),
+		prepared.Sub(start).Round(time.Microsecond),
+		j.ctxTime.Round(time.Microsecond),
+		time.Since(prepared).Round(time.Microsecond),
+	)
+}
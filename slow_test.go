@@ -0,0 +1,43 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSlowRender(t *testing.T) {
+	var logs bytes.Buffer
+
+	ld := New("slow", Config{
+		FS: fstest.MapFS{
+			"slow.html": {Data: []byte(`{{ wait() }}`)},
+			"fast.html": {Data: []byte(`fast`)},
+		},
+		SlowRenderThreshold: 20 * time.Millisecond,
+		ErrorLogger:         &logs,
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	ld.Render(httptest.NewRecorder(), r, "fast.html", nil)
+	ld.Render(httptest.NewRecorder(), r, "slow.html", M{
+		"wait": func() string { time.Sleep(25 * time.Millisecond); return "" },
+	})
+
+	if n := ld.Stats().SlowRenders; n != 1 {
+		t.Errorf("SlowRenders = %d, want 1", n)
+	}
+
+	re := regexp.MustCompile(`^slow render: slow\.html: total=\S+ prepare=\S+ ctx=\S+ execute=\S+\n$`)
+	if !re.Match(logs.Bytes()) {
+		t.Errorf("logs = %q", logs.String())
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// statusWriter sends the status code set by the "status" tag (if any) before
+// the first write to the underlying http.ResponseWriter.
+type statusWriter struct {
+	http.ResponseWriter
+	state       *renderState
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	sw.wroteHeader = true
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader && sw.state.status != 0 {
+		sw.WriteHeader(sw.state.status)
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher.
+func (sw *statusWriter) Flush() {
+	if !sw.wroteHeader && sw.state.status != 0 {
+		sw.WriteHeader(sw.state.status)
+	}
+
+	if flusher, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+type tagStatusNode struct {
+	code pongo2.IEvaluator
+}
+
+func (node *tagStatusNode) Execute(ctx *pongo2.ExecutionContext, _ pongo2.TemplateWriter) *pongo2.Error {
+	code, err := node.code.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	if code.Integer() < 100 || code.Integer() > 999 {
+		return ctx.Error("status tag requires a valid HTTP status code, got: "+code.String(), nil)
+	}
+
+	state := stateFromCtx(ctx)
+	if state == nil {
+		return ctx.Error("status tag used outside of a pt.Loader render", nil)
+	}
+
+	state.status = code.Integer()
+	return nil
+}
+
+// tagStatusParser parses the "status" tag, which sets the status code of the
+// response, replacing the code provided to Render() or RenderStatus(). This
+// allows content-driven templates to respond with (for example) a soft 404.
+// As with the "header" tag, this has no effect once output has been flushed
+// to the client. The tag produces no output. For example:
+//
+//	{% if not page %}{% status 404 %}{% endif %}
+func tagStatusParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	code, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed status-tag arguments.", nil)
+	}
+
+	return &tagStatusNode{code: code}, nil
+}
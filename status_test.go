@@ -0,0 +1,89 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestStatusTag(t *testing.T) {
+	ld := New("status", Config{
+		FS: fstest.MapFS{
+			"page.html":    {Data: []byte(`{% if not page %}{% status 404 %}not found{% else %}{{ page }}{% endif %}`)},
+			"invalid.html": {Data: []byte(`{% status 42 %}`)},
+		},
+	})
+
+	tests := []struct {
+		code int
+		ctx  M
+		want int
+		body string
+	}{
+		{http.StatusOK, M{"page": "about"}, http.StatusOK, "about"},
+		{http.StatusOK, nil, http.StatusNotFound, "not found"},
+		{http.StatusCreated, nil, http.StatusNotFound, "not found"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		ld.RenderStatus(rec, httptest.NewRequest(http.MethodGet, "/", nil), tt.code, "page.html", tt.ctx)
+
+		if rec.Code != tt.want || rec.Body.String() != tt.body {
+			t.Errorf("RenderStatus(%d, %v) = %d %q, want %d %q", tt.code, tt.ctx, rec.Code, rec.Body.String(), tt.want, tt.body)
+		}
+	}
+
+	if err := ld.RenderE(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "invalid.html", nil); err == nil {
+		t.Error("expected an error for an invalid status code")
+	}
+
+	// Renders outside of a request ignore the status.
+	if out, err := ld.RenderBytes("page.html", nil); err != nil || string(out) != "not found" {
+		t.Errorf("RenderBytes() = %q, %v", out, err)
+	}
+}
+
+func TestStatusTagStreamed(t *testing.T) {
+	ld := New("status-stream", Config{
+		FS:           fstest.MapFS{"page.html": {Data: []byte(`{% status 410 %}gone`)}},
+		StreamOutput: true,
+	})
+
+	rec := httptest.NewRecorder()
+	ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "page.html", nil)
+
+	if rec.Code != http.StatusGone || rec.Body.String() != "gone" {
+		t.Errorf("code = %d, body = %q, want 410 gone", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStatusTagHookDeletesState(t *testing.T) {
+	ld := New("status-hook", Config{
+		FS: fstest.MapFS{"page.html": {Data: []byte(`{% status 404 %}not found`)}},
+		BeforeRender: []BeforeRenderHook{func(_ *http.Request, _ string, ctx map[string]interface{}) {
+			for k := range ctx {
+				delete(ctx, k)
+			}
+		}},
+	})
+
+	for _, cached := range []bool{false, true} {
+		rec := httptest.NewRecorder()
+		if cached {
+			ld.RenderCached(rec, httptest.NewRequest(http.MethodGet, "/", nil), "page", time.Minute, "page.html", nil)
+		} else {
+			ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "page.html", nil)
+		}
+
+		if rec.Code != http.StatusNotFound || rec.Body.String() != "not found" {
+			t.Errorf("cached=%v: got %d %q, want %d %q", cached, rec.Code, rec.Body.String(), http.StatusNotFound, "not found")
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// DeferredFunc resolves a ctx value which is slow to compute (e.g. a slow
+// database query). See Loader.RenderStream().
+type DeferredFunc func(ctx context.Context) (interface{}, error)
+
+type deferredValue struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// streamState is the state of a streaming render.
+type streamState struct {
+	deferred map[string]*deferredValue
+	resolved bool
+}
+
+// resolve waits for all deferred values, and adds them to the ctx.
+func (s *streamState) resolve(ctx pongo2.Context) error {
+	if s.resolved {
+		return nil
+	}
+	s.resolved = true
+
+	for key, dv := range s.deferred {
+		<-dv.done
+
+		if dv.err != nil {
+			return fmt.Errorf("deferred ctx value %q: %w", key, dv.err)
+		}
+
+		ctx[key] = dv.value
+	}
+
+	return nil
+}
+
+// RenderStream renders the template without buffering, allowing the start of
+// the page (e.g. the head and navigation) to be sent to the client while slow
+// ctx values are still being resolved. Each deferred function is started in
+// its own goroutine as soon as RenderStream is called, and their results are
+// added to the ctx at the "{% flush %}" tag, which first flushes all output
+// written so far to the client, then waits for all deferred values. Deferred
+// values must not be used before the flush tag. For example:
+//
+//	<html><head>...</head><body><nav>...</nav>
+//	{% flush %}
+//	{% for post in posts %}...{% endfor %}
+//	</body></html>
+//
+// As the response is not buffered, an execution error after the flush tag will
+// result in a partial response (and a panic, as with Render()). Errors from
+// deferred functions are treated as execution errors.
+func (ld *Loader) RenderStream(w http.ResponseWriter, r *http.Request, path string, rctx map[string]interface{}, deferred map[string]DeferredFunc) {
+	stream := &streamState{deferred: make(map[string]*deferredValue, len(deferred))}
+
+	for key, fn := range deferred {
+		dv := &deferredValue{done: make(chan struct{})}
+		stream.deferred[key] = dv
+
+		go func(fn DeferredFunc) {
+			defer close(dv.done)
+			dv.value, dv.err = fn(r.Context())
+		}(fn)
+	}
+
+	conf := ld.conf()
+
+	j, err := ld.prepare(w, r, conf, path, rctx)
+	if err != nil {
+		ld.handleErr(w, r, err)
+		return
+	}
+
+	if j == nil {
+		return
+	}
+
+	j.state.stream = stream
+
+	err = j.tpl.ExecuteWriterUnbuffered(j.ctx, w)
+	if err == nil {
+		// Ensure deferred functions are always waited on, even if the template
+		// doesn't contain a flush tag.
+		err = stream.resolve(j.ctx)
+	}
+
+	if err = ld.execErr(conf, err); err != nil {
+		ld.handleErr(w, r, err)
+	}
+}
+
+type tagFlushNode struct{}
+
+func (node *tagFlushNode) Execute(ctx *pongo2.ExecutionContext, _ pongo2.TemplateWriter) *pongo2.Error {
+	state := stateFromCtx(ctx)
+	if state == nil || state.stream == nil {
+		return nil
+	}
+
+	if flusher, ok := state.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	if err := state.stream.resolve(ctx.Public); err != nil {
+		return ctx.OrigError(err, nil)
+	}
+
+	return nil
+}
+
+// tagFlushParser parses the "flush" tag, which is a flush point when rendering
+// with Loader.RenderStream(). When rendering with any other method, the tag
+// is a no-op.
+func tagFlushParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("The flush-tag does not take any arguments.", nil)
+	}
+
+	return &tagFlushNode{}, nil
+}
+
+// flushWriter flushes the underlying http.ResponseWriter (if supported) every
+// size bytes, see Config.StreamOutput.
+type flushWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	size    int
+	pending int
+}
+
+func newFlushWriter(w http.ResponseWriter, size int) *flushWriter {
+	flusher, _ := w.(http.Flusher)
+	return &flushWriter{w: w, flusher: flusher, size: size}
+}
+
+func (fw *flushWriter) Write(b []byte) (int, error) {
+	n, err := fw.w.Write(b)
+
+	fw.pending += n
+	if fw.flusher != nil && fw.pending >= fw.size {
+		fw.flusher.Flush()
+		fw.pending = 0
+	}
+
+	return n, err
+}
@@ -0,0 +1,122 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderStream(t *testing.T) {
+	var renderErr error
+
+	ld := New("stream", Config{
+		FS: fstest.MapFS{
+			"page.html":     {Data: []byte(`<nav>{{ title }}</nav>{% flush %}{% for p in posts %}[{{ p }}]{% endfor %}`)},
+			"noflush.html":  {Data: []byte(`{{ title }}`)},
+			"badflush.html": {Data: []byte(`{% flush "now" %}`)},
+		},
+		OnError: func(_ http.ResponseWriter, _ *http.Request, err error) {
+			renderErr = err
+		},
+	})
+
+	posts := func(_ context.Context) (interface{}, error) {
+		return []string{"a", "b"}, nil
+	}
+
+	rec := httptest.NewRecorder()
+	ld.RenderStream(rec, httptest.NewRequest(http.MethodGet, "/", nil), "page.html", M{"title": "t"}, map[string]DeferredFunc{"posts": posts})
+
+	if renderErr != nil {
+		t.Fatalf("unexpected error: %v", renderErr)
+	}
+
+	if want := "<nav>t</nav>[a][b]"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+
+	if !rec.Flushed {
+		t.Error("expected the response to be flushed at the flush tag")
+	}
+
+	// Deferred functions are waited on, even without a flush tag.
+	called := make(chan struct{}, 1)
+	rec = httptest.NewRecorder()
+	ld.RenderStream(rec, httptest.NewRequest(http.MethodGet, "/", nil), "noflush.html", M{"title": "t"}, map[string]DeferredFunc{
+		"unused": func(_ context.Context) (interface{}, error) {
+			called <- struct{}{}
+			return nil, nil
+		},
+	})
+
+	select {
+	case <-called:
+	default:
+		t.Error("expected deferred function to be called before RenderStream returns")
+	}
+
+	if rec.Body.String() != "t" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "t")
+	}
+
+	// Errors from deferred functions are execution errors.
+	errDeferred := errors.New("query failed")
+	ld.RenderStream(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "page.html", nil, map[string]DeferredFunc{
+		"posts": func(_ context.Context) (interface{}, error) { return nil, errDeferred },
+	})
+
+	if renderErr == nil || !strings.Contains(renderErr.Error(), `deferred ctx value "posts": query failed`) {
+		t.Errorf("expected deferred error, got: %v", renderErr)
+	}
+
+	renderErr = nil
+	ld.RenderStream(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "badflush.html", nil, nil)
+
+	if renderErr == nil {
+		t.Error("expected an error for flush tag with arguments")
+	}
+}
+
+func TestFlushTagNoop(t *testing.T) {
+	ld := New("stream-noop", Config{
+		FS: fstest.MapFS{"page.html": {Data: []byte(`a{% flush %}b`)}},
+	})
+
+	out, err := ld.RenderBytes("page.html", nil)
+	if err != nil || string(out) != "ab" {
+		t.Errorf("RenderBytes() = %q, %v", out, err)
+	}
+}
+
+func TestFlushWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	fw := newFlushWriter(rec, 4)
+
+	if _, err := fw.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Flushed {
+		t.Error("flushed before reaching the flush size")
+	}
+
+	if _, err := fw.Write([]byte("de")); err != nil {
+		t.Fatal(err)
+	}
+
+	if !rec.Flushed || fw.pending != 0 {
+		t.Errorf("flushed = %v, pending = %d, want flushed with nothing pending", rec.Flushed, fw.pending)
+	}
+
+	if rec.Body.String() != "abcde" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "abcde")
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	reStrictString = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`)
+	reStrictToken  = regexp.MustCompile(`([|.]?)\s*(\w+)`)
+	reStrictAssign = regexp.MustCompile(`(\w+)\s*=([^=])`)
+)
+
+// strictKeywords are identifiers within expressions and tags which aren't
+// variables.
+var strictKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "in": true, "is": true, "as": true,
+	"true": true, "false": true, "none": true, "True": true, "False": true, "None": true,
+	"with": true, "only": true, "if_exists": true, "reversed": true, "sorted": true,
+	"export": true, "vary": true, "forloop": true,
+}
+
+// varRef is a reference to a variable within a template.
+type varRef struct {
+	path string
+	line int
+	name string
+}
+
+// checkUndefinedVars logs all variables referenced by the template (and any
+// templates it statically includes, extends or imports) which aren't within
+// the ctx, see Config.StrictVars. Variables defined within the templates (e.g.
+// by "for", "set", "with" or "macro" tags) are treated as defined everywhere,
+// as scopes aren't tracked.
+func (ld *Loader) checkUndefinedVars(conf *Config, path string, ctx map[string]interface{}) {
+	paths := []string{path}
+	if conf.DefaultLayout != "" && path != conf.DefaultLayout {
+		paths = append(paths, conf.DefaultLayout)
+	}
+
+	var refs []varRef
+	defined := make(map[string]bool)
+	seen := make(map[string]bool)
+
+	for len(paths) > 0 {
+		p := paths[0]
+		paths = paths[1:]
+
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+
+		src, err := ld.source(p)
+		if err != nil {
+			continue
+		}
+
+		var includes []string
+		refs, includes = scanVars(refs, defined, p, src)
+
+		for _, ref := range includes {
+			paths = append(paths, ld.loader.Abs(p, ref))
+		}
+	}
+
+	for _, ref := range refs {
+		if defined[ref.name] {
+			continue
+		}
+
+		if _, ok := ctx[ref.name]; ok {
+			continue
+		}
+
+		if _, ok := ld.fs.Globals[ref.name]; ok {
+			continue
+		}
+
+		conf.logf(LevelWarn, "undefined variable: %s:%d: %s", ref.path, ref.line, ref.name)
+	}
+}
+
+// scanVars appends the variables referenced within src to refs, adds the
+// variables defined within src to defined, and returns any statically
+// referenced templates.
+func scanVars(refs []varRef, defined map[string]bool, path string, src []byte) (out []varRef, includes []string) {
+	var skipUntil string
+
+	for _, loc := range reAuditToken.FindAllSubmatchIndex(src, -1) {
+		line := 1 + strings.Count(string(src[:loc[0]]), "\n")
+
+		if loc[2] >= 0 {
+			if skipUntil == "" {
+				refs = appendVarRefs(refs, path, line, string(src[loc[2]:loc[3]]))
+			}
+			continue
+		}
+
+		tag := strings.TrimSpace(string(src[loc[4]:loc[5]]))
+		name := tag
+		rest := ""
+		if i := strings.IndexAny(tag, " \t\n"); i >= 0 {
+			name, rest = tag[:i], strings.TrimSpace(tag[i+1:])
+		}
+
+		if skipUntil != "" {
+			if name == skipUntil {
+				skipUntil = ""
+			}
+			continue
+		}
+
+		if m := reAuditInclude.FindStringSubmatch(tag); m != nil {
+			includes = append(includes, m[1])
+		}
+
+		switch name {
+		case "comment", "verbatim", "raw":
+			skipUntil = "end" + name
+		case "if", "elif", "firstof", "ifequal", "ifnotequal", "ifchanged", "widthratio",
+			"cache", "jsondata", "static", "absolute_url", "inline_asset":
+			refs = appendVarRefs(refs, path, line, rest)
+		case "cycle":
+			if i := strings.LastIndex(rest, " as "); i >= 0 {
+				defined[strings.TrimSpace(rest[i+4:])] = true
+				rest = rest[:i]
+			}
+			refs = appendVarRefs(refs, path, line, rest)
+		case "for":
+			i := strings.Index(rest, " in ")
+			if i < 0 {
+				continue
+			}
+
+			for _, v := range strings.Split(rest[:i], ",") {
+				defined[strings.TrimSpace(v)] = true
+			}
+			refs = appendVarRefs(refs, path, line, rest[i+4:])
+		case "set":
+			if i := strings.Index(rest, "="); i >= 0 {
+				defined[strings.TrimSpace(rest[:i])] = true
+				refs = appendVarRefs(refs, path, line, rest[i+1:])
+			}
+		case "with", "include":
+			if name == "include" {
+				i := strings.Index(rest, " with ")
+				if i < 0 {
+					refs = appendVarRefs(refs, path, line, rest)
+					continue
+				}
+				refs = appendVarRefs(refs, path, line, rest[:i])
+				rest = rest[i+6:]
+			}
+
+			if i := strings.LastIndex(rest, " as "); i >= 0 && name == "with" {
+				defined[strings.TrimSpace(rest[i+4:])] = true
+				refs = appendVarRefs(refs, path, line, rest[:i])
+				continue
+			}
+
+			for _, m := range reStrictAssign.FindAllStringSubmatch(rest, -1) {
+				defined[m[1]] = true
+			}
+			refs = appendVarRefs(refs, path, line, reStrictAssign.ReplaceAllString(rest, " $2"))
+		case "macro":
+			for _, m := range reStrictToken.FindAllStringSubmatch(reStrictString.ReplaceAllString(rest, " "), -1) {
+				defined[m[2]] = true
+			}
+		case "import":
+			for _, v := range strings.Split(reStrictString.ReplaceAllString(rest, " "), ",") {
+				fields := strings.Fields(v)
+				if len(fields) > 0 {
+					defined[fields[len(fields)-1]] = true
+				}
+			}
+		}
+	}
+
+	return refs, includes
+}
+
+// appendVarRefs appends the root variables referenced within the expression
+// (e.g. "user" for "user.name|default:fallback") to refs.
+func appendVarRefs(refs []varRef, path string, line int, expr string) []varRef {
+	expr = reStrictString.ReplaceAllString(expr, " ")
+
+	for _, m := range reStrictToken.FindAllStringSubmatch(expr, -1) {
+		if m[1] != "" || strictKeywords[m[2]] {
+			continue
+		}
+
+		// Numbers.
+		if c := m[2][0]; c >= '0' && c <= '9' {
+			continue
+		}
+
+		refs = append(refs, varRef{path: path, line: line, name: m[2]})
+	}
+	return refs
+}
@@ -0,0 +1,89 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStrictVars(t *testing.T) {
+	var logs bytes.Buffer
+
+	ld := New("strict", Config{
+		FS: fstest.MapFS{
+			"index.html": {Data: []byte("{{ title }}\n{{ usr.name|default:\"anon\" }}\n{% include \"partials/footer.html\" %}")},
+			"partials/footer.html": {Data: []byte(
+				"{% for p in posts %}{{ p }}{% endfor %}\n{% set x = 1 %}{{ x }}\n{{ site }}{{ missing }}\n{% comment %}{{ ignored }}{% endcomment %}",
+			)},
+		},
+		StrictVars:  true,
+		ErrorLogger: &logs,
+	})
+	ld.SetGlobal("site", "example")
+
+	if _, err := ld.RenderBytes("index.html", M{"title": "Hello", "posts": []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"undefined variable: index.html:2: usr",
+		"undefined variable: partials/footer.html:3: missing",
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs = %q, want %q", logs.String(), want)
+		}
+	}
+
+	for _, name := range []string{"title", "posts", ": p\n", ": x\n", "site", "ignored", "anon", "name"} {
+		if strings.Contains(logs.String(), name) {
+			t.Errorf("logs = %q, unexpected %q", logs.String(), name)
+		}
+	}
+}
+
+func TestScanVars(t *testing.T) {
+	tests := []struct {
+		src      string
+		refs     []string
+		defined  []string
+		includes []string
+	}{
+		{`{{ a.b|upper }}`, []string{"a"}, nil, nil},
+		{`{{ "str" }}{{ 42 }}{{ a and not b }}`, []string{"a", "b"}, nil, nil},
+		{`{% if a == b %}{% endif %}`, []string{"a", "b"}, nil, nil},
+		{`{% for k, v in m %}{% endfor %}`, []string{"m"}, []string{"k", "v"}, nil},
+		{`{% with a=b %}{% endwith %}`, []string{"b"}, []string{"a"}, nil},
+		{`{% with b as a %}{% endwith %}`, []string{"b"}, []string{"a"}, nil},
+		{`{% macro field(name, label="x") %}{% endmacro %}`, nil, []string{"field", "label", "name"}, nil},
+		{`{% import "forms.html" input, select as sel %}`, nil, []string{"input", "sel"}, []string{"forms.html"}},
+		{`{% include "a.html" with x=y %}`, []string{"y"}, []string{"x"}, []string{"a.html"}},
+		{`{% verbatim %}{{ a }}{% endverbatim %}{{ b }}`, []string{"b"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		defined := make(map[string]bool)
+		refs, includes := scanVars(nil, defined, "t.html", []byte(tt.src))
+
+		var names []string
+		for _, ref := range refs {
+			names = append(names, ref.name)
+		}
+
+		var def []string
+		for name := range defined {
+			def = append(def, name)
+		}
+		sort.Strings(def)
+
+		if !reflect.DeepEqual(names, tt.refs) || !reflect.DeepEqual(def, tt.defined) || !reflect.DeepEqual(includes, tt.includes) {
+			t.Errorf("scanVars(%q) = %v, %v, %v, want %v, %v, %v", tt.src, names, def, includes, tt.refs, tt.defined, tt.includes)
+		}
+	}
+}
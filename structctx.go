@@ -0,0 +1,90 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// StructCtx converts a struct (or pointer to a struct) into a render context,
+// allowing typed page view-models to be passed to Render() and friends in place
+// of a map. Each exported field becomes a top-level ctx key, named after the
+// field, or the name in its "pt" struct tag. Fields tagged with `pt:"-"` are
+// skipped, and fields of embedded structs are promoted, following the same
+// rules as Go (outer fields take priority). A nil pointer returns an empty
+// ctx. Panics if v isn't a struct. For example:
+//
+//	type PostPage struct {
+//		Title    string
+//		Post     *Post  `pt:"post"`
+//		internal string // not exported, skipped.
+//	}
+//
+//	ld.Render(w, r, "post.html", pt.StructCtx(PostPage{Title: "Hello", Post: post}))
+func StructCtx(v interface{}) M {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return M{}
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("pt: StructCtx called with non-struct type %T", v))
+	}
+
+	ctx := M{}
+	addStructFields(ctx, rv)
+	return ctx
+}
+
+// addStructFields adds the exported fields of rv to ctx, promoting the fields
+// of embedded structs after the outer fields, so outer fields always win.
+func addStructFields(ctx M, rv reflect.Value) {
+	var embedded []reflect.Value
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		name := field.Tag.Get("pt")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			fv := rv.Field(i)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+
+			if fv.Kind() == reflect.Struct {
+				embedded = append(embedded, fv)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if _, ok := ctx[name]; !ok {
+			ctx[name] = rv.Field(i).Interface()
+		}
+	}
+
+	for _, fv := range embedded {
+		addStructFields(ctx, fv)
+	}
+}
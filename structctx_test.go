@@ -0,0 +1,84 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+type testBase struct {
+	Title string
+	Site  string
+}
+
+type testMeta struct {
+	Description string
+}
+
+type testPostPage struct {
+	testBase
+	*testMeta
+	Title    string `pt:"title"`
+	Post     string `pt:"post"`
+	Secret   string `pt:"-"`
+	internal string
+}
+
+func TestStructCtx(t *testing.T) {
+	page := testPostPage{
+		testBase: testBase{Title: "base", Site: "example"},
+		testMeta: &testMeta{Description: "desc"},
+		Title:    "Hello",
+		Post:     "body",
+		Secret:   "secret",
+		internal: "internal",
+	}
+
+	want := M{
+		"title":       "Hello",
+		"post":        "body",
+		"Title":       "base",
+		"Site":        "example",
+		"Description": "desc",
+	}
+
+	if got := StructCtx(page); !reflect.DeepEqual(got, want) {
+		t.Errorf("StructCtx() = %v, want %v", got, want)
+	}
+
+	if got := StructCtx(&page); !reflect.DeepEqual(got, want) {
+		t.Errorf("StructCtx(&page) = %v, want %v", got, want)
+	}
+
+	// Nil embedded pointers are skipped.
+	page.testMeta = nil
+	if _, ok := StructCtx(page)["Description"]; ok {
+		t.Error("expected nil embedded struct to be skipped")
+	}
+
+	if got := StructCtx((*testPostPage)(nil)); len(got) != 0 {
+		t.Errorf("StructCtx(nil) = %v, want empty", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a non-struct")
+		}
+	}()
+	StructCtx("not a struct")
+}
+
+func TestStructCtxRender(t *testing.T) {
+	ld := New("structctx", Config{
+		FS: fstest.MapFS{"post.html": {Data: []byte(`{{ title }}: {{ post }}`)}},
+	})
+
+	out, err := ld.RenderBytes("post.html", StructCtx(testPostPage{Title: "Hello", Post: "body"}))
+	if err != nil || string(out) != "Hello: body" {
+		t.Errorf("RenderBytes() = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"bytes"
+	"html"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func init() { //nolint:gochecknoinits
+	tags := map[string]pongo2.TagParser{
+		"static":       tagStaticParser,
+		"favicons":     tagFaviconsParser,
+		"profile":      tagProfileParser,
+		"absolute_url": tagAbsoluteURLParser,
+		"flush":        tagFlushParser,
+		"jsondata":     tagJSONDataParser,
+		"sitemap":      tagSitemapParser,
+		"cache":        tagCacheParser,
+		"inline_asset": tagInlineAssetParser,
+		"header":       tagHeaderParser,
+		"status":       tagStatusParser,
+		"feature":      tagFeatureParser,
+		"can":          tagCanParser,
+		"jsonld":       tagJSONLDParser,
+		"alternates":   tagAlternatesParser,
+		"url":          tagURLParser,
+		"auditsink":    tagAuditSinkParser,
+	}
+
+	for name, parser := range tags {
+		if err := RegisterTag(name, parser); err != nil {
+			panic(err)
+		}
+	}
+}
+
+type tagStaticNode struct {
+	path pongo2.IEvaluator
+}
+
+func (node *tagStaticNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	path, err := node.path.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	state := stateFromCtx(ctx)
+	if state == nil {
+		return ctx.Error("static tag used outside of a pt.Loader render", nil)
+	}
+
+	_, _ = writer.WriteString(html.EscapeString(staticURL(state.conf, path.String())))
+	return nil
+}
+
+// tagStaticParser parses the "static" tag, which outputs the URL of a static
+// asset, based on Config.StaticBaseURL. For example:
+//
+//	<link rel="stylesheet" href="{% static "css/app.css" %}">
+func tagStaticParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	path, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed static-tag arguments.", nil)
+	}
+
+	return &tagStaticNode{path: path}, nil
+}
+
+type tagFaviconsNode struct{}
+
+func (node *tagFaviconsNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	state := stateFromCtx(ctx)
+	if state == nil {
+		return ctx.Error("favicons tag used outside of a pt.Loader render", nil)
+	}
+
+	_, _ = writer.WriteString(state.conf.Favicons.HTML())
+	return nil
+}
+
+// tagFaviconsParser parses the "favicons" tag, which outputs the "<link>" tags
+// for the icons provided via Config.Favicons. For example:
+//
+//	<head>{% favicons %}</head>
+func tagFaviconsParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("The favicons-tag does not take any arguments.", nil)
+	}
+
+	return &tagFaviconsNode{}, nil
+}
+
+type tagAbsoluteURLNode struct {
+	path pongo2.IEvaluator
+}
+
+func (node *tagAbsoluteURLNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	path, err := node.path.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	state := stateFromCtx(ctx)
+	if state == nil {
+		return ctx.Error("absolute_url tag used outside of a pt.Loader render", nil)
+	}
+
+	_, _ = writer.WriteString(html.EscapeString(absoluteURL(state.conf, state.r, path.String())))
+	return nil
+}
+
+// tagAbsoluteURLParser parses the "absolute_url" tag, which outputs the full
+// URL for the provided path. See Loader.AbsoluteURL().
+func tagAbsoluteURLParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	path, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed absolute_url-tag arguments.", nil)
+	}
+
+	return &tagAbsoluteURLNode{path: path}, nil
+}
+
+type tagJSONDataNode struct {
+	id    pongo2.IEvaluator
+	value pongo2.IEvaluator
+}
+
+func (node *tagJSONDataNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	id, err := node.id.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	value, err := node.value.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+
+	// HTML escaping ensures "</script>" (and "<!--") can't appear within the
+	// output, as "<" is encoded as "\u003c".
+	if eerr := DefaultJSONEncoder.Encode(&b, value.Interface(), JSONOptions{EscapeHTML: true}); eerr != nil {
+		return ctx.OrigError(eerr, nil)
+	}
+
+	_, _ = writer.WriteString(`<script type="application/json" id="` + html.EscapeString(id.String()) + `">`)
+	_, _ = writer.Write(bytes.TrimSpace(b.Bytes()))
+	_, _ = writer.WriteString(`</script>`)
+	return nil
+}
+
+// tagJSONDataParser parses the "jsondata" tag, which outputs a JSON script
+// block, the safe way to pass server state to frontend JavaScript. For
+// example:
+//
+//	{% jsondata "app-state" state %}
+//
+// Which can be read with:
+//
+//	JSON.parse(document.getElementById("app-state").textContent)
+func tagJSONDataParser(_ *pongo2.Parser, _ *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	id, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed jsondata-tag arguments.", nil)
+	}
+
+	return &tagJSONDataNode{id: id, value: value}, nil
+}
@@ -0,0 +1,26 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTagStaticEscapes(t *testing.T) {
+	ld := New("tag-static", Config{
+		FS:            fstest.MapFS{"index.html": {Data: []byte(`{% static path %}`)}},
+		StaticBaseURL: "/static",
+	})
+
+	rec := httptest.NewRecorder()
+	ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "index.html", M{"path": `a"><script>&.css`})
+
+	if want := "/static/a&#34;&gt;&lt;script&gt;&amp;.css"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// Theme bundles a set of template overrides and static assets, which can be
+// registered on a Loader with Loader.RegisterTheme(), and selected per request
+// with Config.ThemeSelector.
+type Theme struct {
+	// Name is the unique name of the theme, and is required.
+	Name string
+	// Version is the optional version of the theme.
+	Version string
+	// Templates are the templates of the theme, which are used in place of the
+	// loaders templates with the same path. Templates which don't exist within
+	// the theme fall back to the loaders templates.
+	Templates fs.FS
+	// Dir is a directory within the loaders own templates which contains the
+	// templates of the theme, as an alternative to Templates. For example, with
+	// a Dir of "themes/acme", "index.html" resolves to "themes/acme/index.html",
+	// falling back to "index.html" if it doesn't exist within the theme.
+	Dir string
+	// Static are the optional static assets of the theme. See Theme.Mount().
+	Static fs.FS
+}
+
+// Mount serves the static assets of the theme on the router, under the
+// provided path. See FileServer().
+func (t *Theme) Mount(router Router, path string) {
+	if t.Static == nil {
+		panic(fmt.Sprintf("theme %q has no static assets", t.Name))
+	}
+
+	FileServer(router, path, http.FS(t.Static))
+}
+
+func (t *Theme) ctx() M {
+	return M{"name": t.Name, "version": t.Version}
+}
+
+// themeSet is the template set for a registered theme.
+type themeSet struct {
+	theme  *Theme
+	fs     *pongo2.TemplateSet
+	loader pongo2.TemplateLoader
+	cache  *templateCache
+}
+
+// RegisterTheme registers a theme on the loader, which can be selected per
+// request using Config.ThemeSelector. Panics if a theme with the same name is
+// already registered.
+func (ld *Loader) RegisterTheme(t *Theme) {
+	if t.Name == "" || (t.Templates == nil && t.Dir == "") {
+		panic("theme requires a name and templates (or a template directory)")
+	}
+
+	ld.themesMu.Lock()
+	defer ld.themesMu.Unlock()
+
+	if ld.themes == nil {
+		ld.themes = make(map[string]*themeSet)
+	}
+
+	if _, ok := ld.themes[t.Name]; ok {
+		panic(fmt.Sprintf("theme %q already registered", t.Name))
+	}
+
+	var loader pongo2.TemplateLoader
+	if t.Templates != nil {
+		loader = pongo2.NewFSLoader(t.Templates)
+	} else {
+		loader = dirLoader{ld.loader, strings.Trim(path.Clean(t.Dir), "/")}
+	}
+
+	ld.themes[t.Name] = &themeSet{
+		theme:  t,
+		fs:     pongo2.NewSet(t.Name, layoutLoader{loader, ld}, layoutLoader{ld.loader, ld}),
+		loader: loader,
+		cache:  newTemplateCache(),
+	}
+}
+
+// theme returns the theme selected for the request, if any.
+func (ld *Loader) theme(conf *Config, r *http.Request) *themeSet {
+	selector := conf.ThemeSelector
+	if selector == nil {
+		return nil
+	}
+
+	name := selector(r)
+	if name == "" {
+		return nil
+	}
+
+	ld.themesMu.RLock()
+	defer ld.themesMu.RUnlock()
+
+	return ld.themes[name]
+}
+
+// dirLoader wraps a template loader, loading all templates from within dir.
+// Paths are resolved without dir, so relative includes, imports and extends
+// resolve in the same way as the templates outside of dir.
+type dirLoader struct {
+	pongo2.TemplateLoader
+	dir string
+}
+
+func (l dirLoader) Get(name string) (io.Reader, error) {
+	return l.TemplateLoader.Get(path.Join(l.dir, name))
+}
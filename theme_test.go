@@ -0,0 +1,99 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestThemes(t *testing.T) {
+	ld := New("theme", Config{
+		FS: fstest.MapFS{
+			"index.html":             {Data: []byte(`default {% include "partials/nav.html" %}`)},
+			"about.html":             {Data: []byte(`about`)},
+			"partials/nav.html":      {Data: []byte(`nav`)},
+			"themes/dark/nav.html":   {Data: []byte(`ignored`)},
+			"themes/dark/about.html": {Data: []byte(`dark about {{ theme.name }}`)},
+		},
+		ThemeSelector: func(r *http.Request) string {
+			return r.URL.Query().Get("theme")
+		},
+	})
+
+	ld.RegisterTheme(&Theme{
+		Name:    "acme",
+		Version: "1.2.0",
+		Templates: fstest.MapFS{
+			"index.html":        {Data: []byte(`acme {{ theme.version }} {% include "partials/nav.html" %}`)},
+			"partials/nav.html": {Data: []byte(`acme-nav`)},
+		},
+	})
+	ld.RegisterTheme(&Theme{Name: "dark", Dir: "/themes/dark/"})
+
+	tests := []struct {
+		url  string
+		path string
+		want string
+	}{
+		{"/", "index.html", "default nav"},
+		{"/?theme=unknown", "index.html", "default nav"},
+		{"/?theme=acme", "index.html", "acme 1.2.0 acme-nav"},
+		{"/?theme=acme", "about.html", "about"},
+		{"/?theme=dark", "about.html", "dark about dark"},
+		{"/?theme=dark", "index.html", "default nav"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		ld.Render(rec, httptest.NewRequest(http.MethodGet, tt.url, nil), tt.path, nil)
+
+		if rec.Body.String() != tt.want {
+			t.Errorf("Render(%q, %q) = %q, want %q", tt.url, tt.path, rec.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestRegisterThemePanics(t *testing.T) {
+	ld := New("theme-panics", Config{FS: fstest.MapFS{}})
+	ld.RegisterTheme(&Theme{Name: "acme", Dir: "acme"})
+
+	for name, fn := range map[string]func(){
+		"no name":      func() { ld.RegisterTheme(&Theme{Dir: "other"}) },
+		"no templates": func() { ld.RegisterTheme(&Theme{Name: "other"}) },
+		"duplicate":    func() { ld.RegisterTheme(&Theme{Name: "acme", Dir: "acme"}) },
+		"no static":    func() { (&Theme{Name: "acme"}).Mount(testRouter{}, "/static") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestThemeMount(t *testing.T) {
+	router := testRouter{}
+
+	theme := &Theme{Name: "acme", Static: fstest.MapFS{"style.css": {Data: []byte("body{}")}}}
+	theme.Mount(router, "/static/acme")
+
+	h, ok := router["/static/acme/*"]
+	if !ok {
+		t.Fatalf("expected handler to be registered, got: %v", router)
+	}
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/static/acme/style.css", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "body{}" {
+		t.Errorf("code = %d, body = %q", rec.Code, rec.Body.String())
+	}
+}
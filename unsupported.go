@@ -0,0 +1,53 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import "net/http"
+
+// LegacyClient reports if the request is from a legacy browser (e.g. Internet
+// Explorer), see DetectDevice(). Bots are never considered legacy clients, so
+// they can still crawl the real pages. It can be used as
+// Config.UnsupportedClient.
+func LegacyClient(r *http.Request) bool {
+	d := DetectDevice(r)
+	return d.Legacy && !d.Bot
+}
+
+// RequireSupportedClient is a middleware which renders
+// Config.UnsupportedTemplate (with Config.UnsupportedStatus) instead of
+// calling the next handler, for requests which Config.UnsupportedClient
+// reports as unsupported. Requests are passed through if either isn't
+// provided.
+//
+// For example:
+//
+//	ld := pt.New("", pt.Config{
+//		// [...]
+//		UnsupportedClient:   pt.LegacyClient,
+//		UnsupportedTemplate: "unsupported.html",
+//		UnsupportedStatus:   http.StatusNotAcceptable,
+//	})
+//
+//	r.Use(ld.RequireSupportedClient)
+func (ld *Loader) RequireSupportedClient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conf := ld.conf()
+
+		if conf.UnsupportedClient == nil || conf.UnsupportedTemplate == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "User-Agent")
+
+		if !conf.UnsupportedClient(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		ld.RenderStatus(w, r, conf.UnsupportedStatus, conf.UnsupportedTemplate, nil)
+	})
+}
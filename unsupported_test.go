@@ -0,0 +1,87 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	testLegacyUA = "Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; Trident/4.0)"
+	testBotUA    = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+	testModernUA = "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0"
+)
+
+func TestLegacyClient(t *testing.T) {
+	for ua, want := range map[string]bool{
+		testLegacyUA: true,
+		testBotUA:    false,
+		testModernUA: false,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User-Agent", ua)
+
+		if got := LegacyClient(r); got != want {
+			t.Errorf("LegacyClient(%q) = %v, want %v", ua, got, want)
+		}
+	}
+}
+
+func TestRequireSupportedClient(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("next"))
+	})
+
+	ld := New("unsupported", Config{
+		FS:                  fstest.MapFS{"unsupported.html": {Data: []byte(`unsupported`)}},
+		UnsupportedClient:   LegacyClient,
+		UnsupportedTemplate: "unsupported.html",
+		UnsupportedStatus:   http.StatusNotAcceptable,
+	})
+	h := ld.RequireSupportedClient(next)
+
+	tests := []struct {
+		ua   string
+		code int
+		body string
+	}{
+		{testModernUA, http.StatusOK, "next"},
+		{testBotUA, http.StatusOK, "next"},
+		{testLegacyUA, http.StatusNotAcceptable, "unsupported"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User-Agent", tt.ua)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+
+		if rec.Code != tt.code || rec.Body.String() != tt.body {
+			t.Errorf("%q: got %d %q, want %d %q", tt.ua, rec.Code, rec.Body.String(), tt.code, tt.body)
+		}
+
+		if rec.Header().Get("Vary") != "User-Agent" {
+			t.Errorf("%q: Vary = %q, want User-Agent", tt.ua, rec.Header().Get("Vary"))
+		}
+	}
+
+	// Without a template, requests are always passed through.
+	ld = New("unsupported-passthrough", Config{
+		FS:                fstest.MapFS{},
+		UnsupportedClient: LegacyClient,
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("User-Agent", testLegacyUA)
+	rec := httptest.NewRecorder()
+	ld.RequireSupportedClient(next).ServeHTTP(rec, r)
+
+	if rec.Body.String() != "next" || rec.Header().Get("Vary") != "" {
+		t.Errorf("got %q (vary %q), want passthrough", rec.Body.String(), rec.Header().Get("Vary"))
+	}
+}
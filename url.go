@@ -0,0 +1,73 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AbsoluteURL converts the provided path into a full URL. If Config.BaseURL is
+// provided, it is always used. Otherwise, the scheme and host are taken from
+// the request, honoring the X-Forwarded-Proto and X-Forwarded-Host headers
+// only when Config.TrustForwardedHeaders is enabled, or the request came from
+// one of Config.TrustedProxies. Paths which are already
+// absolute URLs are returned as-is. Without a BaseURL, and when r is nil
+// (e.g. when rendering with Loader.RenderBytes()), the path is returned as-is.
+//
+// This is also available within templates using the "absolute_url" tag:
+//
+//	<meta property="og:image" content="{% absolute_url "/static/og.png" %}">
+func (ld *Loader) AbsoluteURL(r *http.Request, path string) string {
+	return absoluteURL(ld.conf(), r, path)
+}
+
+// absoluteURL is Loader.AbsoluteURL(), using the provided config.
+func absoluteURL(conf *Config, r *http.Request, path string) string {
+	if u, err := url.Parse(path); err == nil && u.IsAbs() {
+		return path
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	if conf.BaseURL != "" {
+		return strings.TrimSuffix(conf.BaseURL, "/") + path
+	}
+
+	if r == nil {
+		return path
+	}
+
+	scheme, host := "http", r.Host
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	if conf.TrustForwardedHeaders || conf.isTrustedProxy(net.ParseIP(remoteIP(r))) {
+		if proto := firstHeaderValue(r, "X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+
+		if fhost := firstHeaderValue(r, "X-Forwarded-Host"); fhost != "" {
+			host = fhost
+		}
+	}
+
+	return scheme + "://" + host + path
+}
+
+// firstHeaderValue returns the first value of a (potentially comma-separated)
+// header.
+func firstHeaderValue(r *http.Request, key string) string {
+	value := r.Header.Get(key)
+	if i := strings.IndexByte(value, ','); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
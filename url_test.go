@@ -0,0 +1,78 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAbsoluteURL(t *testing.T) {
+	forwarded := func(remote string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+		r.RemoteAddr = remote + ":1234"
+		r.Header.Set("X-Forwarded-Proto", "https, http")
+		r.Header.Set("X-Forwarded-Host", "public.example.com")
+		return r
+	}
+
+	secure := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+	secure.TLS = &tls.ConnectionState{}
+
+	tests := []struct {
+		name string
+		conf Config
+		r    *http.Request
+		path string
+		want string
+	}{
+		{"absolute", Config{}, nil, "https://cdn.example.com/a.png", "https://cdn.example.com/a.png"},
+		{"no request", Config{}, nil, "a.png", "/a.png"},
+		{"base url", Config{BaseURL: "https://site.example.com/"}, forwarded("10.0.0.1"), "/a.png", "https://site.example.com/a.png"},
+		{"request", Config{}, httptest.NewRequest(http.MethodGet, "http://example.com/", nil), "/a.png", "http://example.com/a.png"},
+		{"tls", Config{}, secure, "/a.png", "https://example.com/a.png"},
+		{"untrusted", Config{}, forwarded("10.0.0.1"), "/a.png", "http://example.com/a.png"},
+		{"trust headers", Config{TrustForwardedHeaders: true}, forwarded("10.0.0.1"), "/a.png", "https://public.example.com/a.png"},
+		{"trusted proxy", Config{TrustedProxies: []string{"10.0.0.0/8"}}, forwarded("10.0.0.1"), "/a.png", "https://public.example.com/a.png"},
+		{"other proxy", Config{TrustedProxies: []string{"10.0.0.0/8"}}, forwarded("192.0.2.1"), "/a.png", "http://example.com/a.png"},
+	}
+
+	for _, tt := range tests {
+		tt.conf.FS = fstest.MapFS{}
+		ld := New("url-"+tt.name, tt.conf)
+
+		if got := ld.AbsoluteURL(tt.r, tt.path); got != tt.want {
+			t.Errorf("%s: AbsoluteURL(%q) = %q, want %q", tt.name, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAbsoluteURLInvalidProto(t *testing.T) {
+	ld := New("url-proto", Config{FS: fstest.MapFS{}, TrustForwardedHeaders: true})
+
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	r.Header.Set("X-Forwarded-Proto", "javascript")
+
+	if got, want := ld.AbsoluteURL(r, "/"), "http://example.com/"; got != want {
+		t.Errorf("AbsoluteURL() = %q, want %q", got, want)
+	}
+}
+
+func TestAbsoluteURLTag(t *testing.T) {
+	ld := New("url-tag", Config{
+		FS:      fstest.MapFS{"index.html": {Data: []byte(`{% absolute_url "/static/og.png" %}`)}},
+		BaseURL: "https://site.example.com",
+	})
+
+	rec := httptest.NewRecorder()
+	ld.Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), "index.html", nil)
+
+	if want := "https://site.example.com/static/og.png"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UsageStats are the sampled usage statistics of a single template, or a
+// single block within a template. See Config.UsageSampleRate.
+type UsageStats struct {
+	// Renders is the number of sampled renders of the template (or block).
+	Renders uint64 `json:"renders"`
+	// Referenced is the number of sampled renders of other templates which
+	// include, extend or import the template. Only templates referenced using
+	// string literals are tracked.
+	Referenced uint64 `json:"referenced"`
+	// LastUsed is the time the template was last rendered or referenced.
+	LastUsed time.Time `json:"last_used"`
+}
+
+// usageTracker records sampled template usage, keyed by template path (and
+// "path#block" for blocks).
+type usageTracker struct {
+	mu      sync.Mutex
+	entries map[string]*UsageStats
+	refs    map[string][]string // template path -> transitive references.
+}
+
+// sampleUsage returns true if the render should be recorded, see
+// Config.UsageSampleRate.
+func sampleUsage(conf *Config) bool {
+	return conf.UsageSampleRate > 0 && (conf.UsageSampleRate >= 1 || rand.Float64() < conf.UsageSampleRate) //nolint:gosec
+}
+
+// recordUsage records a render of the template (or block within it), and all
+// templates it references.
+func (ld *Loader) recordUsage(tpath, block string) {
+	now := time.Now()
+
+	key := tpath
+	if block != "" {
+		key += "#" + block
+	}
+
+	refs := ld.usageRefs(tpath)
+
+	u := &ld.usage
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.entries == nil {
+		u.entries = make(map[string]*UsageStats)
+	}
+
+	entry := func(key string) *UsageStats {
+		stats, ok := u.entries[key]
+		if !ok {
+			stats = &UsageStats{}
+			u.entries[key] = stats
+		}
+		stats.LastUsed = now
+		return stats
+	}
+
+	entry(key).Renders++
+
+	if block == "" {
+		for _, ref := range refs {
+			entry(ref).Referenced++
+		}
+	}
+}
+
+// usageRefs returns the templates (transitively) referenced by the template,
+// which are cached until the template is invalidated.
+func (ld *Loader) usageRefs(tpath string) []string {
+	u := &ld.usage
+
+	u.mu.Lock()
+	refs, ok := u.refs[tpath]
+	u.mu.Unlock()
+
+	if ok {
+		return refs
+	}
+
+	seen := map[string]bool{tpath: true}
+	queue := []string{tpath}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		src, err := ld.source(current)
+		if err != nil {
+			continue
+		}
+
+		_, direct := auditSource(current, src, true)
+		for _, ref := range direct {
+			ref = ld.loader.Abs(current, ref)
+			if !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+				queue = append(queue, ref)
+			}
+		}
+	}
+
+	u.mu.Lock()
+	if u.refs == nil {
+		u.refs = make(map[string][]string)
+	}
+	u.refs[tpath] = refs
+	u.mu.Unlock()
+
+	return refs
+}
+
+// resetRefs clears the cached references, e.g. when templates change.
+func (u *usageTracker) resetRefs() {
+	u.mu.Lock()
+	u.refs = nil
+	u.mu.Unlock()
+}
+
+// snapshot returns a copy of the recorded usage.
+func (u *usageTracker) snapshot() map[string]UsageStats {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if len(u.entries) == 0 {
+		return nil
+	}
+
+	out := make(map[string]UsageStats, len(u.entries))
+	for key, stats := range u.entries {
+		out[key] = *stats
+	}
+	return out
+}
+
+// Unused returns the templates within Config.FS (see List()) which haven't
+// been rendered or referenced since the loader was created, based on the
+// usage sampled with Config.UsageSampleRate. Rarely used templates may not
+// have been sampled yet, so the results should be confirmed before deleting
+// templates, e.g. by collecting usage over a longer period.
+func (ld *Loader) Unused() ([]string, error) {
+	paths, err := ld.List()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := ld.usage.snapshot()
+
+	used := make(map[string]bool, len(usage))
+	for key := range usage {
+		if i := strings.IndexByte(key, '#'); i >= 0 {
+			key = key[:i]
+		}
+		used[key] = true
+	}
+
+	var unused []string
+	for _, fpath := range paths {
+		if !used[fpath] {
+			unused = append(unused, fpath)
+		}
+	}
+
+	sort.Strings(unused)
+	return unused, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestUsageServer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base.html":   {Data: []byte(`{% block content %}{% endblock %}`)},
+		"index.html":  {Data: []byte(`{% extends "base.html" %}{% block content %}{% include "nav.html" %}{% endblock %}`)},
+		"nav.html":    {Data: []byte(`nav`)},
+		"unused.html": {Data: []byte(`unused`)},
+	}
+
+	ld := New("usage-server", Config{FS: fsys, UsageSampleRate: 1})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/block" {
+			ld.RenderBlock(w, r, "index.html", "content", nil)
+			return
+		}
+		ld.Render(w, r, "index.html", nil)
+	}))
+	defer srv.Close()
+
+	for _, path := range []string{"/", "/block"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	usage := ld.Stats().Usage
+	if usage["index.html"].Renders != 1 {
+		t.Errorf("index.html renders = %d, want 1", usage["index.html"].Renders)
+	}
+	if usage["index.html#content"].Renders != 1 {
+		t.Errorf("index.html#content renders = %d, want 1", usage["index.html#content"].Renders)
+	}
+	for _, ref := range []string{"base.html", "nav.html"} {
+		if usage[ref].Referenced != 1 {
+			t.Errorf("%s referenced = %d, want 1", ref, usage[ref].Referenced)
+		}
+	}
+
+	unused, err := ld.Unused()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"unused.html"}; !reflect.DeepEqual(unused, want) {
+		t.Errorf("Unused() = %v, want %v", unused, want)
+	}
+}
+
+func TestUsageFailedRender(t *testing.T) {
+	fsys := fstest.MapFS{"broken.html": {Data: []byte(`{{ fail() }}`)}}
+	ld := New("usage-failed", Config{
+		FS:              fsys,
+		UsageSampleRate: 1,
+		OnError:         func(http.ResponseWriter, *http.Request, error) {},
+	})
+
+	ld.Render(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "broken.html", M{
+		"fail": func() (string, error) { return "", errors.New("failed") },
+	})
+
+	if n := ld.Stats().Usage["broken.html"].Renders; n != 0 {
+		t.Errorf("failed render recorded: renders = %d", n)
+	}
+}
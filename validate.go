@@ -0,0 +1,105 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// ValidationError is a single template which failed to parse, see
+// Loader.ParseAll().
+type ValidationError struct {
+	// Path is the path of the template containing the error, which may be a
+	// template referenced by (e.g. included from) the validated template.
+	Path string
+	// Line and Col are the location of the error within the template, or 0
+	// if unknown.
+	Line int
+	Col  int
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d: %v", e.Path, e.Line, e.Col, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors are all errors returned by Loader.ParseAll(), sorted by
+// path and line.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return fmt.Sprintf("%d invalid templates:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+func (e ValidationErrors) sort() {
+	sort.SliceStable(e, func(i, j int) bool {
+		if e[i].Path != e[j].Path {
+			return e[i].Path < e[j].Path
+		}
+		return e[i].Line < e[j].Line
+	})
+}
+
+// ValidateAll parses every template within Config.FS (files with one of
+// Config.TemplateExts), returning a ValidationErrors containing every syntax
+// error (with the file and line), rather than stopping at the first one.
+// This is the same as ParseAll() without paths, without the statistics.
+// Useful in CI, or at startup to fail fast.
+//
+// For example:
+//
+//	if err := ld.ValidateAll(); err != nil {
+//		log.Fatal(err)
+//	}
+func (ld *Loader) ValidateAll() error {
+	_, err := ld.ParseAll()
+	return err
+}
+
+// validationError returns the ValidationError for an error returned when
+// loading the template, reporting the location of syntax errors within
+// referenced templates where possible.
+func (ld *Loader) validationError(conf *Config, fpath string, err error) *ValidationError {
+	if ld.notFound(nil, fpath) {
+		return &ValidationError{Path: fpath, Err: ErrTemplateNotFound}
+	}
+
+	verr := &ValidationError{Path: fpath, Err: err}
+
+	var perr *pongo2.Error
+	if !errors.As(err, &perr) {
+		return verr
+	}
+
+	// pongo2 reports unresolvable includes using the filename of the missing
+	// template, rather than the one including it.
+	if name := strings.TrimSuffix(perr.Filename, layoutSuffix); name != "" && conf.FS != nil {
+		if _, serr := fs.Stat(conf.FS, name); serr == nil {
+			verr.Path = name
+		}
+	}
+
+	verr.Line, verr.Col = perr.Line, perr.Column
+	verr.Err = perr.OrigError
+	return verr
+}
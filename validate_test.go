@@ -0,0 +1,61 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestValidationErrors(t *testing.T) {
+	errSyntax := errors.New("syntax error")
+
+	errs := ValidationErrors{
+		{Path: "b.html", Line: 3, Col: 1, Err: errSyntax},
+		{Path: "a.html", Err: ErrTemplateNotFound},
+		{Path: "b.html", Line: 1, Col: 5, Err: errSyntax},
+	}
+	errs.sort()
+
+	want := "3 invalid templates:\n" +
+		"a.html: " + ErrTemplateNotFound.Error() + "\n" +
+		"b.html:1:5: syntax error\n" +
+		"b.html:3:1: syntax error"
+
+	if got := errs.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	if !errors.Is(errs[0], ErrTemplateNotFound) || !errors.Is(errs[1], errSyntax) {
+		t.Errorf("expected ValidationError to unwrap to the underlying error")
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":    {Data: []byte(`index`)},
+		"a.html":        {Data: []byte("ok\n{% if %}")},
+		"b.html":        {Data: []byte(`{{ }}`)},
+		"static/app.js": {Data: []byte(`{{ }}`)},
+	}
+	ld := New("validate-all", Config{FS: fsys})
+
+	var errs ValidationErrors
+	if err := ld.ValidateAll(); !errors.As(err, &errs) {
+		t.Fatalf("ValidateAll() = %v, want ValidationErrors", err)
+	}
+
+	if len(errs) != 2 || errs[0].Path != "a.html" || errs[0].Line != 2 || errs[1].Path != "b.html" {
+		t.Errorf("ValidateAll() = %v, want a.html:2 and b.html errors", errs)
+	}
+
+	delete(fsys, "a.html")
+	delete(fsys, "b.html")
+
+	if err := ld.ValidateAll(); err != nil {
+		t.Errorf("ValidateAll() = %v, want nil", err)
+	}
+}
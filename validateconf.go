@@ -0,0 +1,107 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrInvalidConfig is returned (wrapped) by Config.Validate() and
+// NewWithOptions() when the configuration is invalid.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Validate checks the configuration for mistakes which would otherwise only
+// surface at request time (e.g. conflicting loaders, negative cache sizes or
+// malformed path patterns), returning an error wrapping ErrInvalidConfig
+// which describes all problems found. ErrNoLoader is returned if no loader is
+// provided. NewWithOptions() validates the configuration automatically, New()
+// doesn't.
+func (c *Config) Validate() error {
+	if c.Loader == nil && c.FS == nil {
+		return ErrNoLoader
+	}
+
+	var problems []string
+
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(
+		c.Loader == nil || c.FS == nil || c.Precedence != PrecedenceUnset,
+		"both Loader and FS provided without Precedence",
+	)
+	check(c.Precedence >= PrecedenceUnset && c.Precedence <= FSFirst, "unknown Precedence %d", c.Precedence)
+	check(!c.Watch || c.FS != nil, "Watch requires FS")
+	check(!c.LiveReload || c.Watch, "LiveReload requires Watch")
+
+	check(c.CacheTTL >= 0, "CacheTTL must not be negative")
+	check(c.CacheMaxEntries >= 0, "CacheMaxEntries must not be negative")
+	check(c.CacheMaxBytes >= 0, "CacheMaxBytes must not be negative")
+	check(c.CacheStale >= 0, "CacheStale must not be negative")
+	check(c.WatchInterval >= 0, "WatchInterval must not be negative")
+	check(c.InlineAssetMaxSize >= 0, "InlineAssetMaxSize must not be negative")
+	check(c.StreamFlushSize >= 0, "StreamFlushSize must not be negative")
+	check(c.CompressMinSize >= 0, "CompressMinSize must not be negative")
+	check(c.MaxIncludeDepth >= 0, "MaxIncludeDepth must not be negative")
+	check(c.SlowRenderThreshold >= 0, "SlowRenderThreshold must not be negative")
+	if _, err := parseTrustedProxies(c.TrustedProxies); err != nil {
+		check(false, "TrustedProxies: %v", err)
+	}
+
+	check(c.UsageSampleRate >= 0 && c.UsageSampleRate <= 1, "UsageSampleRate must be between 0 and 1")
+
+	check(
+		c.UnsupportedStatus == 0 || (c.UnsupportedStatus >= 100 && c.UnsupportedStatus <= 999),
+		"UnsupportedStatus %d is not a valid status code", c.UnsupportedStatus,
+	)
+
+	if c.DefaultLocale != "" && len(c.Locales) > 0 {
+		_, ok := supportedLocale(c, c.DefaultLocale)
+		check(ok, "DefaultLocale %q is not one of Locales", c.DefaultLocale)
+	}
+
+	for _, pattern := range c.NoIndexPaths {
+		_, err := path.Match(pattern, "")
+		check(err == nil, "NoIndexPaths: invalid pattern %q", pattern)
+	}
+
+	for _, pattern := range c.NoAutoescapePaths {
+		_, err := path.Match(pattern, "")
+		check(err == nil, "NoAutoescapePaths: invalid pattern %q", pattern)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateTemplates checks that the templates referenced by the configuration
+// exist, returning an error wrapping ErrInvalidConfig if not.
+func (ld *Loader) validateTemplates() error {
+	conf := ld.conf()
+
+	var missing []string
+	for _, t := range []struct{ field, path string }{
+		{"ErrorTemplate", conf.ErrorTemplate},
+		{"UnsupportedTemplate", conf.UnsupportedTemplate},
+		{"DefaultLayout", conf.DefaultLayout},
+	} {
+		if t.path != "" && !ld.Exists(t.path) {
+			missing = append(missing, fmt.Sprintf("%s %q not found", t.field, t.path))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(missing, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestConfigValidate(t *testing.T) {
+	fsys := fstest.MapFS{}
+	loader := func(path string) ([]byte, error) { return fsys.ReadFile(path) }
+
+	tests := []struct {
+		name string
+		conf Config
+		want string
+	}{
+		{"valid", Config{FS: fsys}, ""},
+		{"valid loader", Config{Loader: loader}, ""},
+		{"both sources", Config{FS: fsys, Loader: loader}, "without Precedence"},
+		{"both sources with precedence", Config{FS: fsys, Loader: loader, Precedence: FSFirst}, ""},
+		{"unknown precedence", Config{FS: fsys, Precedence: FSFirst + 1}, "unknown Precedence"},
+		{"watch", Config{Loader: loader, Watch: true}, "Watch requires FS"},
+		{"livereload", Config{FS: fsys, LiveReload: true}, "LiveReload requires Watch"},
+		{"negative", Config{FS: fsys, CacheTTL: -1}, "CacheTTL must not be negative"},
+		{"sample rate", Config{FS: fsys, UsageSampleRate: 1.5}, "UsageSampleRate"},
+		{"status", Config{FS: fsys, UnsupportedStatus: 42}, "UnsupportedStatus 42"},
+		{"locale", Config{FS: fsys, Locales: []string{"en"}, DefaultLocale: "de"}, `DefaultLocale "de"`},
+		{"locale case", Config{FS: fsys, Locales: []string{"en"}, DefaultLocale: "EN"}, ""},
+		{"noindex", Config{FS: fsys, NoIndexPaths: []string{"["}}, "NoIndexPaths"},
+		{"noautoescape", Config{FS: fsys, NoAutoescapePaths: []string{"["}}, "NoAutoescapePaths"},
+	}
+
+	for _, tt := range tests {
+		err := tt.conf.Validate()
+
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Validate() = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	if err := (&Config{}).Validate(); !errors.Is(err, ErrNoLoader) {
+		t.Errorf("Validate() = %v, want ErrNoLoader", err)
+	}
+}
+
+func TestConfigValidateAllProblems(t *testing.T) {
+	conf := Config{FS: fstest.MapFS{}, CacheTTL: -1, WatchInterval: -1}
+
+	err := conf.Validate()
+	if err == nil || !strings.Contains(err.Error(), "CacheTTL") || !strings.Contains(err.Error(), "WatchInterval") {
+		t.Errorf("Validate() = %v, want all problems", err)
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	ld := New("validate-templates", Config{
+		FS:                  fstest.MapFS{"error.html": {Data: []byte(`error`)}},
+		ErrorTemplate:       "error.html",
+		UnsupportedTemplate: "unsupported.html",
+		DefaultLayout:       "base.html",
+	})
+	defer ld.Close()
+
+	err := ld.validateTemplates()
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("validateTemplates() = %v, want ErrInvalidConfig", err)
+	}
+
+	for _, want := range []string{`UnsupportedTemplate "unsupported.html"`, `DefaultLayout "base.html"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validateTemplates() = %v, want %q", err, want)
+		}
+	}
+
+	if strings.Contains(err.Error(), "ErrorTemplate") {
+		t.Errorf("validateTemplates() = %v, ErrorTemplate exists", err)
+	}
+}
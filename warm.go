@@ -0,0 +1,177 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WarmStats are the results of Loader.ParseAll().
+type WarmStats struct {
+	// Templates is the total number of templates parsed.
+	Templates int `json:"templates"`
+	// Duration is the total time spent parsing.
+	Duration time.Duration `json:"duration_ns"`
+	// Dirs are the statistics for each directory, keyed by directory path.
+	Dirs map[string]*WarmDirStats `json:"dirs"`
+}
+
+// WarmDirStats are the parse statistics for a single directory.
+type WarmDirStats struct {
+	Templates int           `json:"templates"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// String returns a single line summary of the statistics, suitable for
+// logging at startup.
+func (s *WarmStats) String() string {
+	dirs := make([]string, 0, len(s.Dirs))
+	for dir := range s.Dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for i, dir := range dirs {
+		dirs[i] = fmt.Sprintf("%s=%d/%s", dir, s.Dirs[dir].Templates, s.Dirs[dir].Duration.Round(time.Microsecond))
+	}
+
+	return fmt.Sprintf(
+		"parsed %d templates in %s (%s)",
+		s.Templates, s.Duration.Round(time.Microsecond), strings.Join(dirs, " "),
+	)
+}
+
+// ParseAll parses the provided templates, and all templates (files with one
+// of Config.TemplateExts) within the provided directories of Config.FS, or
+// the entire FS if no paths are provided. Templates are parsed as they are by
+// Render() (e.g. wrapped in Config.DefaultLayout), so syntax errors surface at
+// startup (or in CI) rather than at request time, and when
+// Config.CacheParsed is enabled, the first requests don't pay the parse cost.
+// Template paths (rather than directories) also work with Config.Loader.
+//
+// Parsing doesn't stop at the first error. All errors are returned as
+// ValidationErrors, alongside the parse duration and counts per directory.
+// The statistics are also available via Loader.Stats(), which makes
+// regressions in the size (or parse cost) of the template set visible across
+// deploys.
+//
+// For example:
+//
+//	stats, err := ld.ParseAll("pages", "partials", "errors/500.html")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	log.Print(stats)
+func (ld *Loader) ParseAll(paths ...string) (*WarmStats, error) {
+	conf := ld.conf()
+
+	if len(paths) == 0 {
+		if conf.FS == nil {
+			return nil, errors.New("parsing all templates requires a loader with Config.FS")
+		}
+		paths = []string{"."}
+	}
+
+	stats := &WarmStats{Dirs: make(map[string]*WarmDirStats)}
+
+	var errs ValidationErrors
+	seen := make(map[string]bool)
+
+	parse := func(fpath string) {
+		start := time.Now()
+
+		if _, err := ld.load(conf, ld.fs, ld.cache, layoutPath(conf, fpath)); err != nil {
+			// Errors within referenced templates (e.g. the layout) are
+			// reported once.
+			verr := ld.validationError(conf, fpath, err)
+			if key := verr.Error(); !seen[key] {
+				seen[key] = true
+				errs = append(errs, verr)
+			}
+			return
+		}
+
+		elapsed := time.Since(start)
+
+		dir, ok := stats.Dirs[path.Dir(fpath)]
+		if !ok {
+			dir = &WarmDirStats{}
+			stats.Dirs[path.Dir(fpath)] = dir
+		}
+
+		dir.Templates++
+		dir.Duration += elapsed
+		stats.Templates++
+		stats.Duration += elapsed
+	}
+
+	for _, root := range paths {
+		if conf.FS != nil {
+			if info, err := fs.Stat(conf.FS, root); err == nil && info.IsDir() {
+				err = fs.WalkDir(conf.FS, root, func(fpath string, d fs.DirEntry, err error) error {
+					if err != nil || d.IsDir() || !hasExt(conf.TemplateExts, fpath) {
+						return err
+					}
+
+					parse(fpath)
+					return nil
+				})
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		parse(root)
+	}
+
+	ld.warm.Store(stats)
+
+	if len(errs) > 0 {
+		errs.sort()
+		return stats, errs
+	}
+
+	return stats, nil
+}
+
+// Preload parses the provided templates, in the same way as ParseAll(), so
+// syntax errors surface at startup rather than at request time, and (when
+// Config.CacheParsed is enabled) the first requests don't pay the parse cost.
+// This works with any loader (including Config.Loader). Errors are returned
+// as ValidationErrors.
+func (ld *Loader) Preload(paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	_, err := ld.ParseAll(paths...)
+	return err
+}
+
+// PreloadAll parses all templates within Config.FS. This is the same as
+// ParseAll() without paths, without the statistics.
+func (ld *Loader) PreloadAll() error {
+	_, err := ld.ParseAll()
+	return err
+}
+
+// hasExt returns true if the file has one of the provided extensions.
+func hasExt(exts []string, fpath string) bool {
+	ext := path.Ext(fpath)
+	for _, e := range exts {
+		if strings.EqualFold(ext, e) {
+			return true
+		}
+	}
+	return false
+}
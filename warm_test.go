@@ -0,0 +1,138 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseAllWarmsRenderKeys(t *testing.T) {
+	ld := New("warm-layout", Config{
+		FS: fstest.MapFS{
+			"layouts/base.html": {Data: []byte(`<main>{% block content %}{% endblock %}</main>`)},
+			"pages/index.html":  {Data: []byte(`index`)},
+			"pages/about.html":  {Data: []byte(`about`)},
+			"static/app.css":    {Data: []byte(`a{{ b }`)},
+			"data/feed.json":    {Data: []byte(`{% if %}`)},
+		},
+		DefaultLayout: "layouts/base.html",
+		CacheParsed:   true,
+	})
+
+	stats, err := ld.ParseAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Templates != 3 {
+		t.Errorf("templates = %d, want 3", stats.Templates)
+	}
+
+	misses := ld.Stats().ParseCache.Misses
+
+	w := httptest.NewRecorder()
+	ld.Render(w, httptest.NewRequest(http.MethodGet, "/", nil), "pages/index.html", nil)
+	if got := w.Body.String(); got != "<main>index</main>" {
+		t.Errorf("body = %q", got)
+	}
+
+	if got := ld.Stats().ParseCache.Misses; got != misses {
+		t.Errorf("render after ParseAll() missed the parse cache (%d misses, want %d)", got, misses)
+	}
+}
+
+func TestParseAllErrors(t *testing.T) {
+	ld := New("warm-errors", Config{
+		FS: fstest.MapFS{
+			"a.html":            {Data: []byte("ok\n{% if %}")},
+			"b.html":            {Data: []byte(`{% include "partials/bad.tmpl" %}`)},
+			"c.html":            {Data: []byte(`{% include "partials/bad.tmpl" %}`)},
+			"partials/bad.tmpl": {Data: []byte(`{{ }}`)},
+			"good.html":         {Data: []byte(`ok`)},
+		},
+	})
+
+	stats, err := ld.ParseAll(".", "missing.html")
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("error = %v, want ValidationErrors", err)
+	}
+
+	if stats == nil || stats.Templates != 1 {
+		t.Errorf("stats = %+v, want 1 template", stats)
+	}
+
+	paths := make(map[string]int)
+	for _, e := range errs {
+		paths[e.Path]++
+	}
+
+	if paths["a.html"] != 1 || errs[0].Path != "a.html" || errs[0].Line != 2 {
+		t.Errorf("missing a.html:2 error: %v", errs)
+	}
+	if paths["partials/bad.tmpl"] != 1 {
+		t.Errorf("want a single partials/bad.tmpl error: %v", errs)
+	}
+	if paths["missing.html"] != 1 {
+		t.Errorf("missing not found error: %v", errs)
+	}
+
+	for _, e := range errs {
+		if e.Path == "missing.html" && !errors.Is(e, ErrTemplateNotFound) {
+			t.Errorf("missing.html error = %v, want ErrTemplateNotFound", e)
+		}
+	}
+}
+
+func TestParseAllLoader(t *testing.T) {
+	ld := New("warm-loader", Config{
+		Loader: func(path string) ([]byte, error) {
+			if path == "index.html" {
+				return []byte(`index`), nil
+			}
+			return nil, ErrTemplateNotFound
+		},
+	})
+
+	if _, err := ld.ParseAll("index.html"); err != nil {
+		t.Errorf("ParseAll() = %v", err)
+	}
+	if _, err := ld.ParseAll(); err == nil {
+		t.Error("ParseAll() without paths or Config.FS succeeded")
+	}
+}
+
+func TestPreload(t *testing.T) {
+	ld := New("warm-preload", Config{
+		FS: fstest.MapFS{
+			"index.html": {Data: []byte(`index`)},
+			"bad.html":   {Data: []byte(`{% if %}`)},
+		},
+		CacheParsed: true,
+	})
+
+	if err := ld.Preload(); err != nil {
+		t.Errorf("Preload() = %v", err)
+	}
+	if err := ld.Preload("index.html"); err != nil {
+		t.Errorf("Preload(index.html) = %v", err)
+	}
+
+	var errs ValidationErrors
+	if err := ld.Preload("index.html", "bad.html"); !errors.As(err, &errs) || len(errs) != 1 || errs[0].Path != "bad.html" {
+		t.Errorf("Preload(bad.html) = %v, want a bad.html ValidationError", err)
+	}
+	if err := ld.PreloadAll(); !errors.As(err, &errs) || len(errs) != 1 {
+		t.Errorf("PreloadAll() = %v, want a bad.html ValidationError", err)
+	}
+
+	if n := ld.Stats().ParseCache.Entries; n != 1 {
+		t.Errorf("cached %d templates, want 1", n)
+	}
+}
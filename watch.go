@@ -0,0 +1,105 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchEntry is the state of a single file, used to detect changes.
+type watchEntry struct {
+	modTime time.Time
+	size    int64
+}
+
+// scanFS returns the state of all files within fsys. Unreadable and hidden
+// directories are skipped.
+func scanFS(fsys fs.FS) map[string]watchEntry {
+	files := make(map[string]watchEntry)
+
+	_ = fs.WalkDir(fsys, ".", func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if fpath != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		files[fpath] = watchEntry{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+
+	return files
+}
+
+// watch polls fsys for changes every interval, invalidating changed templates
+// (and the templates which reference them), until Loader.Close() is called.
+// files is the initial state of fsys, which is scanned before the loader is
+// returned, so changes made right after are detected.
+func (ld *Loader) watch(fsys fs.FS, files map[string]watchEntry, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ld.watchStop:
+			return
+		case <-ticker.C:
+		}
+
+		current := scanFS(fsys)
+
+		var changed []string
+		for fpath, entry := range current {
+			if prev, ok := files[fpath]; !ok || prev != entry {
+				changed = append(changed, fpath)
+			}
+		}
+		for fpath := range files {
+			if _, ok := current[fpath]; !ok {
+				changed = append(changed, fpath)
+			}
+		}
+
+		files = current
+
+		if len(changed) == 0 {
+			continue
+		}
+
+		sort.Strings(changed)
+		for _, fpath := range changed {
+			ld.Invalidate(fpath)
+		}
+
+		ld.conf().logf(LevelDebug, "templates changed: %s", strings.Join(changed, ", "))
+		ld.notifyReload()
+	}
+}
+
+// Close stops the background goroutines of the loader (see Config.Watch). The
+// loader can still be used for rendering once closed.
+func (ld *Loader) Close() {
+	ld.closeOnce.Do(func() {
+		if ld.watchStop != nil {
+			close(ld.watchStop)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package pt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchInvalidates(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, data string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("index.html", `{% include "nav.html" %}`)
+	write("nav.html", `v1`)
+
+	ld := New("watch", Config{
+		FS:            os.DirFS(dir),
+		CacheParsed:   true,
+		Watch:         true,
+		WatchInterval: 10 * time.Millisecond,
+	})
+	defer ld.Close()
+
+	render := func() string {
+		out, err := ld.RenderBytes("index.html", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out)
+	}
+
+	if got := render(); got != "v1" {
+		t.Fatalf("render = %q, want v1", got)
+	}
+
+	write("nav.html", `version 2`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for render() != "version 2" {
+		if time.Now().After(deadline) {
+			t.Fatal("change to included template not picked up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestScanFSSkipsHidden(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"index.html", ".git/HEAD", "partials/nav.html"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := scanFS(os.DirFS(dir))
+	if len(files) != 2 {
+		t.Errorf("scanFS() = %v, want index.html and partials/nav.html", files)
+	}
+	if _, ok := files[".git/HEAD"]; ok {
+		t.Error("scanFS() included a hidden directory")
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+// Package wellknown provides helpers for serving "/.well-known/" endpoints
+// (RFC 8615), like security.txt and change-password.
+package wellknown
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lrstanley/pt"
+)
+
+// Prefix is the path prefix for all well-known endpoints.
+const Prefix = "/.well-known/"
+
+// Config is the configuration for Mount().
+type Config struct {
+	// Security is the optional security.txt (RFC 9116) to serve.
+	Security *SecurityTxt
+	// ChangePassword is the optional URL the "change-password" endpoint
+	// redirects to, allowing password managers to find the change password
+	// page.
+	ChangePassword string
+	// Entries are additional endpoints, keyed by their name within the
+	// well-known prefix (e.g. "assetlinks.json"). See Text() and Template()
+	// for simple handlers.
+	Entries map[string]http.HandlerFunc
+}
+
+// SecurityTxt is the structured form of a security.txt file (RFC 9116).
+type SecurityTxt struct {
+	// Contact is one or more URIs (e.g. "mailto:security@example.com") for
+	// reporting vulnerabilities, and is required.
+	Contact []string
+	// Expires is when the file should be considered stale, and is required.
+	Expires            time.Time
+	Encryption         []string
+	Acknowledgments    []string
+	PreferredLanguages []string
+	Canonical          []string
+	Policy             []string
+	Hiring             []string
+}
+
+// String returns the security.txt representation.
+func (s *SecurityTxt) String() string {
+	var b strings.Builder
+
+	fields := []struct {
+		name   string
+		values []string
+	}{
+		{"Contact", s.Contact},
+		{"Encryption", s.Encryption},
+		{"Acknowledgments", s.Acknowledgments},
+		{"Canonical", s.Canonical},
+		{"Policy", s.Policy},
+		{"Hiring", s.Hiring},
+	}
+
+	for _, field := range fields {
+		for _, value := range field.values {
+			b.WriteString(field.name + ": " + value + "\n")
+		}
+	}
+
+	if len(s.PreferredLanguages) > 0 {
+		b.WriteString("Preferred-Languages: " + strings.Join(s.PreferredLanguages, ", ") + "\n")
+	}
+
+	if !s.Expires.IsZero() {
+		b.WriteString("Expires: " + s.Expires.UTC().Format(time.RFC3339) + "\n")
+	}
+
+	return b.String()
+}
+
+// Mount registers all configured well-known endpoints on the router.
+func Mount(router pt.Router, conf Config) {
+	if conf.Security != nil {
+		router.Get(Prefix+"security.txt", Text("text/plain; charset=utf-8", conf.Security.String()))
+	}
+
+	if conf.ChangePassword != "" {
+		router.Get(Prefix+"change-password", http.RedirectHandler(conf.ChangePassword, http.StatusFound).ServeHTTP)
+	}
+
+	for name, handler := range conf.Entries {
+		router.Get(Prefix+strings.TrimPrefix(name, "/"), handler)
+	}
+}
+
+// Text returns a handler which serves static content with the provided
+// Content-Type.
+func Text(contentType, content string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write([]byte(content))
+	}
+}
+
+// Template returns a handler which renders the provided template, using
+// Loader.RenderData().
+//
+// For example:
+//
+//	wellknown.Mount(r, wellknown.Config{
+//		Entries: map[string]http.HandlerFunc{
+//			"assetlinks.json": wellknown.Template(ld, "wellknown/assetlinks.json", pt.DataJSON),
+//		},
+//	})
+func Template(ld *pt.Loader, path string, format pt.DataFormat) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ld.RenderData(w, r, path, nil, format)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package wellknown
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/lrstanley/pt"
+)
+
+// testRouter is a pt.Router which records the registered handlers.
+type testRouter map[string]http.HandlerFunc
+
+func (tr testRouter) Get(pattern string, h http.HandlerFunc) { tr[pattern] = h }
+
+func TestSecurityTxt(t *testing.T) {
+	s := &SecurityTxt{
+		Contact:            []string{"mailto:security@example.com", "https://example.com/security"},
+		Expires:            time.Date(2030, 1, 2, 3, 4, 5, 0, time.FixedZone("EST", -5*60*60)),
+		Policy:             []string{"https://example.com/policy"},
+		PreferredLanguages: []string{"en", "de"},
+	}
+
+	want := "Contact: mailto:security@example.com\n" +
+		"Contact: https://example.com/security\n" +
+		"Policy: https://example.com/policy\n" +
+		"Preferred-Languages: en, de\n" +
+		"Expires: 2030-01-02T08:04:05Z\n"
+
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestMount(t *testing.T) {
+	router := testRouter{}
+
+	Mount(router, Config{
+		Security:       &SecurityTxt{Contact: []string{"mailto:security@example.com"}},
+		ChangePassword: "/account/password",
+		Entries: map[string]http.HandlerFunc{
+			"/assetlinks.json": Text("application/json", "[]"),
+		},
+	})
+
+	if len(router) != 3 {
+		t.Errorf("registered %d handlers, want 3: %v", len(router), router)
+	}
+
+	tests := []struct {
+		path        string
+		code        int
+		contentType string
+		body        string
+		location    string
+	}{
+		{"/.well-known/security.txt", http.StatusOK, "text/plain; charset=utf-8", "Contact: mailto:security@example.com\n", ""},
+		{"/.well-known/change-password", http.StatusFound, "", "", "/account/password"},
+		{"/.well-known/assetlinks.json", http.StatusOK, "application/json", "[]", ""},
+	}
+
+	for _, tt := range tests {
+		h, ok := router[tt.path]
+		if !ok {
+			t.Errorf("%s: not registered", tt.path)
+			continue
+		}
+
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != tt.code {
+			t.Errorf("%s: code = %d, want %d", tt.path, rec.Code, tt.code)
+		}
+
+		if tt.contentType != "" && (rec.Header().Get("Content-Type") != tt.contentType || rec.Body.String() != tt.body) {
+			t.Errorf("%s: got %q %q, want %q %q", tt.path, rec.Header().Get("Content-Type"), rec.Body.String(), tt.contentType, tt.body)
+		}
+
+		if tt.location != "" && rec.Header().Get("Location") != tt.location {
+			t.Errorf("%s: Location = %q, want %q", tt.path, rec.Header().Get("Location"), tt.location)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	ld := pt.New("wellknown", pt.Config{
+		FS: fstest.MapFS{"wellknown/assetlinks.json": {Data: []byte(`[{"target": "{{ name }}"}]`)}},
+	})
+
+	ld.SetGlobal("name", "app")
+
+	rec := httptest.NewRecorder()
+	Template(ld, "wellknown/assetlinks.json", pt.DataJSON)(rec, httptest.NewRequest(http.MethodGet, "/.well-known/assetlinks.json", nil))
+
+	if want := `[{"target": "app"}]`; rec.Code != http.StatusOK || rec.Body.String() != want {
+		t.Errorf("got %d %q, want %q", rec.Code, rec.Body.String(), want)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}